@@ -1,9 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/web"
 )
@@ -15,6 +21,9 @@ var (
 	port        = flag.String("port", "8080", "サーバーのポート番号")
 )
 
+// shutdownTimeout は停止時に処理中のリクエストとインポートの終了を待つ時間
+const shutdownTimeout = 5 * time.Minute
+
 func main() {
 	flag.Parse()
 
@@ -33,5 +42,23 @@ func main() {
 	fmt.Printf("Table: %s\n", *table)
 	fmt.Printf("Projects Directory: %s\n", *projectsDir)
 
-	e.Logger.Fatal(e.Start(addr))
+	// 停止シグナルを受けるまでサーバーを動かす
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		if err := e.Start(addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			e.Logger.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+
+	// 処理中のリクエストと実行中のインポートの終了を待ってから停止する
+	fmt.Println("Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		e.Logger.Fatal(err)
+	}
 }
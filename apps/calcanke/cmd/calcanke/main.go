@@ -11,12 +11,13 @@ import (
 var rootCmd = &cobra.Command{
 	Use:   "calcanke",
 	Short: "Excelデータ分析ツール",
-	Long: `Calcanke - ExcelファイルをDuckDBにインポートして分析するツール
+	Long: `Calcanke - Excel・CSVなどのファイルをDuckDBにインポートして分析するツール
 
 使い方:
-  calcanke import   - ExcelファイルをDuckDBにインポート
+  calcanke import   - データファイル（xlsx, csv, tsv, parquet, jsonl）をDuckDBにインポート
   calcanke columns  - テーブルの列一覧を表示
-  calcanke analyze  - 対話的にデータ分析（予定）`,
+  calcanke analyze  - 対話的にデータ分析（予定）
+  calcanke banner   - バナー表（表頭×表側の集計表）をファイルに出力`,
 }
 
 func main() {
@@ -24,6 +25,7 @@ func main() {
 	rootCmd.AddCommand(commands.NewImportCmd())
 	rootCmd.AddCommand(commands.NewColumnsCmd())
 	rootCmd.AddCommand(commands.NewAnalyzeCmd())
+	rootCmd.AddCommand(commands.NewBannerCmd())
 
 	// 実行
 	if err := rootCmd.Execute(); err != nil {
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	_ "github.com/marcboeker/go-duckdb"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
 )
 
 // 東京23区のリスト
@@ -149,7 +150,7 @@ func main() {
 	// 東京都のデータで分析
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println("東京都のデータパターン分析")
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	fmt.Print("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
 
 	tokyoRecords := []string{}
 	for _, r := range records {
@@ -187,7 +188,7 @@ func main() {
 	// 23区パターンの詳細分析
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println("東京23区の詳細パターン")
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	fmt.Print("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
 
 	wardPatterns := make(map[string]map[string]int) // ward -> pattern -> count
 	for _, ward := range tokyo23Wards {
@@ -235,7 +236,7 @@ func main() {
 	}
 
 	// 件数が多い区を表示
-	fmt.Println("件数が多い区（上位10件）:\n")
+	fmt.Print("件数が多い区（上位10件）:\n\n")
 	type wardCount struct {
 		Ward  string
 		Total int
@@ -274,7 +275,7 @@ func main() {
 	// その他のパターン（23区にも他のパターンにも当てはまらないもの）
 	fmt.Println("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println("その他のパターン（東京都で23区以外）")
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	fmt.Print("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
 
 	otherCities := make(map[string]int)
 	for _, city := range tokyoRecords {
@@ -287,8 +288,11 @@ func main() {
 			}
 		}
 		if !is23Ward {
-			// 市のパターンを抽出
-			cityName := extractCityName(trimmed)
+			// 市区町村名を取り出す（住所による地域分類と同じ解析）
+			cityName := analyzer.ParseAddress("東京都" + trimmed).Municipality
+			if cityName == "" {
+				cityName = "（市区町村を解析できない）"
+			}
 			otherCities[cityName]++
 		}
 	}
@@ -310,24 +314,8 @@ func main() {
 		}
 	}
 
-	fmt.Println("三多摩・島しょ地域の市町村（上位20件）:\n")
+	fmt.Print("三多摩・島しょ地域の市町村（上位20件）:\n\n")
 	for i := 0; i < 20 && i < len(otherCityCounts); i++ {
 		fmt.Printf("%2d. %s: %d件\n", i+1, otherCityCounts[i].City, otherCityCounts[i].Count)
 	}
 }
-
-// extractCityName は市区町村名から基本的な市名を抽出
-func extractCityName(s string) string {
-	// 「市」「町」「村」で切る
-	for _, suffix := range []string{"市", "町", "村"} {
-		if idx := strings.Index(s, suffix); idx >= 0 {
-			return s[:idx+len(suffix)]
-		}
-	}
-	// 見つからない場合は最初の5文字（町名が続く場合を想定）
-	runes := []rune(s)
-	if len(runes) > 5 {
-		return string(runes[:5])
-	}
-	return s
-}
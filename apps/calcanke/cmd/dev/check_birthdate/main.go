@@ -6,6 +6,7 @@ import (
 	"log"
 
 	_ "github.com/marcboeker/go-duckdb"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
 )
 
 func main() {
@@ -30,7 +31,7 @@ func main() {
 
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println("生年月日のサンプルデータ（50件）")
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	fmt.Print("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
 
 	i := 1
 	birthdates := make(map[string]int)
@@ -43,7 +44,11 @@ func main() {
 		}
 
 		if i <= 20 {
-			fmt.Printf("%2d: %q (長さ: %d)\n", i, birthdate, len(birthdate))
+			parsed := "解析できない"
+			if date, ok := analyzer.ParseDate(birthdate); ok {
+				parsed = date.Format("2006-01-02")
+			}
+			fmt.Printf("%2d: %q (長さ: %d) -> %s\n", i, birthdate, len(birthdate), parsed)
 			samples = append(samples, birthdate)
 		}
 
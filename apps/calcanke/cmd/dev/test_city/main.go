@@ -6,6 +6,7 @@ import (
 	"log"
 
 	_ "github.com/marcboeker/go-duckdb"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
 )
 
 func main() {
@@ -35,7 +36,8 @@ func main() {
 		if err := rows.Scan(&pref, &city); err != nil {
 			log.Fatal(err)
 		}
-		fmt.Printf("%2d: 都道府県=%q, 市区町村=%q\n", i, pref, city)
+		addr := analyzer.ParseAddress(pref + city)
+		fmt.Printf("%2d: 都道府県=%q, 市区町村=%q -> %q\n", i, pref, city, addr.Path())
 		i++
 	}
 
@@ -0,0 +1,545 @@
+package analyzer
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 住所の解析に使う市区町村の一覧（都道府県ごと）と政令指定都市の区の一覧
+//
+//go:embed addressdata/municipalities.txt
+var municipalitiesData string
+
+//go:embed addressdata/designated_city_wards.txt
+var designatedCityWardsData string
+
+// 住所による地域分類（address_region）の単位
+const (
+	AddressLevelPrefecture   = "prefecture"   // 都道府県
+	AddressLevelMunicipality = "municipality" // 市区町村（政令指定都市は市）
+	AddressLevelWard         = "ward"         // 市区町村（政令指定都市は区まで）
+)
+
+// 地域分類のパラメータの既定値
+const (
+	defaultAddressOtherLabel   = "その他" // どのグループにも当てはまらない住所
+	defaultAddressUnknownLabel = "不明"  // 都道府県・市区町村を解析できない住所
+	tokyoSpecialWardsArea      = "東京23区"
+)
+
+// 住所の正規化に使う正規表現（郵便番号・空白を除く）
+const addressNoisePattern = `^〒?[0-9０-９]{3}[-－ー‐]?[0-9０-９]{4}|\s|　`
+
+// 郡の名前（「西多摩郡」など）に一致する正規表現
+const districtPattern = `(?:[^郡]{1,5}郡)?`
+
+// AddressGroup は地域分類のグループ（calculation_type="address_region"の場合）
+// areas のいずれかの地域の住所をこのグループに分類する
+// 地域は都道府県・市区町村・区の名前（例: "埼玉県", "横浜市", "神奈川県横浜市港北区"）か "東京23区"
+type AddressGroup struct {
+	Label string   `yaml:"label" json:"label"`
+	Areas []string `yaml:"areas" json:"areas"`
+}
+
+// Address は住所を解析した結果（解析できなかった部分は空）
+type Address struct {
+	Prefecture   string `json:"prefecture"`
+	Municipality string `json:"municipality"` // 政令指定都市は市の名前
+	Ward         string `json:"ward"`         // 政令指定都市の区
+}
+
+// Path は都道府県・市区町村・区をつないだ名前を返す
+func (addr Address) Path() string {
+	return addr.Prefecture + addr.Municipality + addr.Ward
+}
+
+// addressTable は埋め込みの市区町村の一覧と、住所の解析に使う正規表現
+// 名前の「ヶ」「ヵ」は「ケ」にそろえて照合し、結果は正式な名前に戻す
+type addressTable struct {
+	prefectures    []string            // 都道府県（定義順）
+	municipalities map[string][]string // 都道府県 → 市区町村（定義順）
+	wards          map[string][]string // 政令指定都市 → 区（定義順）
+	official       map[string]string   // 照合用の名前 → 正式な名前（異なるもののみ）
+	prefectureOf   map[string]string   // 市区町村 → 都道府県（全国で1つしかない名前のみ）
+
+	prefecturePattern string            // 先頭の都道府県（グループ1）
+	duplicatePattern  string            // 先頭で繰り返された都道府県（都道府県の列と住所の列をつないだ場合）
+	namePatterns      map[string]string // 都道府県 → 先頭の郡・市区町村・区（グループ1が市区町村・区）
+	inferPatterns     map[string]string // 都道府県 → 先頭がその都道府県にしかない市区町村
+	cityPattern       string            // 先頭の政令指定都市（グループ1）
+
+	prefectureRegexp *regexp.Regexp
+	duplicateRegexp  *regexp.Regexp
+	nameRegexps      map[string]*regexp.Regexp
+	inferRegexps     map[string]*regexp.Regexp
+	cityRegexp       *regexp.Regexp
+	noiseRegexp      *regexp.Regexp
+}
+
+var (
+	addressTableOnce  sync.Once
+	addressTableValue *addressTable
+)
+
+// addressNameReplacer は照合用に「ヶ」「ヵ」を「ケ」にそろえる
+var addressNameReplacer = strings.NewReplacer("ヶ", "ケ", "ヵ", "ケ")
+
+// getAddressTable は市区町村の一覧を読み込み、正規表現を作成する（初回のみ）
+func getAddressTable() *addressTable {
+	addressTableOnce.Do(func() {
+		addressTableValue = newAddressTable()
+	})
+	return addressTableValue
+}
+
+// readNameList は「先頭の名前 + 空白区切りの名前」の行を読み込む（# の行はコメント）
+func readNameList(data string) ([]string, map[string][]string) {
+	var keys []string
+	values := make(map[string][]string)
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		keys = append(keys, fields[0])
+		values[fields[0]] = fields[1:]
+	}
+	return keys, values
+}
+
+func newAddressTable() *addressTable {
+	t := &addressTable{
+		official:     make(map[string]string),
+		prefectureOf: make(map[string]string),
+	}
+	t.prefectures, t.municipalities = readNameList(municipalitiesData)
+	_, t.wards = readNameList(designatedCityWardsData)
+
+	for _, names := range t.municipalities {
+		for _, name := range names {
+			t.addOfficial(name)
+		}
+	}
+	for city, wards := range t.wards {
+		t.addOfficial(city)
+		for _, ward := range wards {
+			t.addOfficial(ward)
+		}
+	}
+
+	// 全国で1つしかない市区町村は、都道府県が書かれていなくても都道府県がわかる
+	count := make(map[string]int)
+	for _, pref := range t.prefectures {
+		for _, name := range t.municipalities[pref] {
+			count[normalizeAddressName(name)]++
+		}
+	}
+	for _, pref := range t.prefectures {
+		for _, name := range t.municipalities[pref] {
+			if key := normalizeAddressName(name); count[key] == 1 {
+				t.prefectureOf[key] = pref
+			}
+		}
+	}
+
+	// 照合する名前（政令指定都市は区まで含めた名前も加える）
+	matchNames := func(names []string) []string {
+		var result []string
+		for _, name := range names {
+			key := normalizeAddressName(name)
+			result = append(result, key)
+			for _, ward := range t.wards[name] {
+				result = append(result, key+normalizeAddressName(ward))
+			}
+		}
+		return result
+	}
+
+	// 正規表現が大きいと照合が遅くなるため、都道府県ごとに分ける
+	prefectures := strings.Join(t.prefectures, "|")
+	var cities []string
+	t.namePatterns = make(map[string]string)
+	t.inferPatterns = make(map[string]string)
+	t.nameRegexps = make(map[string]*regexp.Regexp)
+	t.inferRegexps = make(map[string]*regexp.Regexp)
+	for _, pref := range t.prefectures {
+		t.namePatterns[pref] = "^" + districtPattern + "(" + namePattern(matchNames(t.municipalities[pref])) + ")"
+		t.nameRegexps[pref] = regexp.MustCompile(t.namePatterns[pref])
+
+		var inferable []string
+		for _, name := range t.municipalities[pref] {
+			if t.prefectureOf[normalizeAddressName(name)] != "" {
+				inferable = append(inferable, name)
+			}
+		}
+		if len(inferable) > 0 {
+			t.inferPatterns[pref] = "^" + districtPattern + namePattern(matchNames(inferable))
+			t.inferRegexps[pref] = regexp.MustCompile(t.inferPatterns[pref])
+		}
+
+		for _, name := range t.municipalities[pref] {
+			if len(t.wards[name]) > 0 {
+				cities = append(cities, normalizeAddressName(name))
+			}
+		}
+	}
+
+	t.prefecturePattern = "^(" + prefectures + ")"
+	t.duplicatePattern = "^(?:" + prefectures + ")(" + prefectures + ")"
+	t.cityPattern = "^(" + strings.Join(cities, "|") + ")"
+
+	t.prefectureRegexp = regexp.MustCompile(t.prefecturePattern)
+	t.duplicateRegexp = regexp.MustCompile(t.duplicatePattern)
+	t.cityRegexp = regexp.MustCompile(t.cityPattern)
+	t.noiseRegexp = regexp.MustCompile(addressNoisePattern)
+	return t
+}
+
+// addOfficial は照合用の名前と正式な名前が異なる場合に対応を記録する
+func (t *addressTable) addOfficial(name string) {
+	if key := normalizeAddressName(name); key != name {
+		t.official[key] = name
+	}
+}
+
+// officialName は照合用の名前を正式な名前に戻す
+func (t *addressTable) officialName(name string) string {
+	if official, ok := t.official[name]; ok {
+		return official
+	}
+	return name
+}
+
+func normalizeAddressName(name string) string {
+	return addressNameReplacer.Replace(name)
+}
+
+func normalizeAddressNames(names []string) []string {
+	result := make([]string, len(names))
+	for i, name := range names {
+		result[i] = normalizeAddressName(name)
+	}
+	return result
+}
+
+// addressTrie は名前の共通の接頭辞をまとめた正規表現を作るための木
+// 候補が多い選択（A|B|...）より照合が速く、長い名前を優先して一致させる
+type addressTrie struct {
+	children map[rune]*addressTrie
+	order    []rune
+	end      bool
+}
+
+// namePattern は名前のいずれかに一致する正規表現（グループなし）を返す
+func namePattern(names []string) string {
+	root := &addressTrie{children: make(map[rune]*addressTrie)}
+	for _, name := range names {
+		node := root
+		for _, r := range name {
+			child, ok := node.children[r]
+			if !ok {
+				child = &addressTrie{children: make(map[rune]*addressTrie)}
+				node.children[r] = child
+				node.order = append(node.order, r)
+			}
+			node = child
+		}
+		node.end = true
+	}
+	return "(?:" + root.pattern() + ")"
+}
+
+func (node *addressTrie) pattern() string {
+	var alternatives []string
+	for _, r := range node.order {
+		alternatives = append(alternatives, regexp.QuoteMeta(string(r))+node.children[r].pattern())
+	}
+	if len(alternatives) == 0 {
+		return ""
+	}
+	pattern := strings.Join(alternatives, "|")
+	if len(alternatives) > 1 || node.end {
+		pattern = "(?:" + pattern + ")"
+	}
+	if node.end {
+		// 続きがあれば長い名前を優先する
+		pattern += "?"
+	}
+	return pattern
+}
+
+// normalize は住所から郵便番号・空白を除き、「ヶ」「ヵ」を「ケ」にそろえる
+// 都道府県が繰り返されている場合（都道府県の列と、都道府県から書かれた住所の列をつないだ場合）は1つにする
+func (t *addressTable) normalize(text string) string {
+	text = t.noiseRegexp.ReplaceAllString(normalizeAddressName(text), "")
+	return t.duplicateRegexp.ReplaceAllString(text, "$1")
+}
+
+// ParseAddress は住所を都道府県・市区町村・政令指定都市の区に分ける
+// 都道府県が書かれていない場合は、全国で1つしかない市区町村から都道府県を求める
+// 郡の名前は読み飛ばし、町名・番地などは無視する
+func ParseAddress(text string) Address {
+	addr, _ := parseAddress(text)
+	return addr
+}
+
+// parseAddress は住所を解析し、解析した部分より後ろの文字列も返す
+func parseAddress(text string) (Address, string) {
+	t := getAddressTable()
+	rest := t.normalize(text)
+
+	var addr Address
+	if m := t.prefectureRegexp.FindStringSubmatch(rest); m != nil {
+		addr.Prefecture = m[1]
+		rest = rest[len(m[0]):]
+	} else {
+		for _, pref := range t.prefectures {
+			if re, ok := t.inferRegexps[pref]; ok && re.MatchString(rest) {
+				addr.Prefecture = pref
+				break
+			}
+		}
+	}
+	if addr.Prefecture == "" {
+		return addr, rest
+	}
+
+	if m := t.nameRegexps[addr.Prefecture].FindStringSubmatch(rest); m != nil {
+		name := m[1]
+		addr.Municipality = name
+		if city := t.cityRegexp.FindStringSubmatch(name); city != nil {
+			addr.Municipality = city[1]
+			addr.Ward = t.officialName(name[len(city[1]):])
+		}
+		addr.Municipality = t.officialName(addr.Municipality)
+		rest = rest[len(m[0]):]
+	}
+
+	return addr, rest
+}
+
+// sqlStringLiteral は文字列をSQLの文字列リテラルにする
+// 住所の解析に使う大きな正規表現は、DuckDBが1度だけコンパイルできるようにバインド引数ではなくリテラルで渡す
+func sqlStringLiteral(s string) Expr {
+	return NewExpr("'" + strings.ReplaceAll(s, "'", "''") + "'")
+}
+
+// addressLevel は地域分類の単位を返す（未指定の場合は市区町村）
+func (dc *DerivedColumn) addressLevel() string {
+	if level, ok := dc.Parameters["level"].(string); ok && level != "" {
+		return level
+	}
+	return AddressLevelMunicipality
+}
+
+// addressWithPrefecture は市区町村の名前の前に都道府県を付けるかどうかを返す
+func (dc *DerivedColumn) addressWithPrefecture() bool {
+	withPrefecture, _ := dc.Parameters["with_prefecture"].(bool)
+	return withPrefecture
+}
+
+// addressLabel はパラメータのラベルを返す（未指定の場合は既定値）
+func (dc *DerivedColumn) addressLabel(key, defaultLabel string) string {
+	if label, ok := dc.Parameters[key].(string); ok && strings.TrimSpace(label) != "" {
+		return strings.TrimSpace(label)
+	}
+	return defaultLabel
+}
+
+// resolveAddressArea はグループの地域を「都道府県 + 市区町村 + 区」の名前にする
+// "東京23区" は東京都の23区のそれぞれの名前にする
+func resolveAddressArea(area string) ([]string, error) {
+	area = strings.TrimSpace(area)
+	if area == tokyoSpecialWardsArea {
+		var paths []string
+		for _, name := range getAddressTable().municipalities["東京都"] {
+			if strings.HasSuffix(name, "区") {
+				paths = append(paths, "東京都"+name)
+			}
+		}
+		return paths, nil
+	}
+
+	addr, rest := parseAddress(area)
+	if addr.Prefecture == "" || rest != "" {
+		return nil, fmt.Errorf("unknown area: %s", area)
+	}
+	return []string{addr.Path()}, nil
+}
+
+// AddressLabels は地域分類の値を表示順に返す
+// グループがある場合はグループの順、ない場合は都道府県・市区町村の一覧の順で、解析できない住所のラベルを末尾に加える
+func (dc *DerivedColumn) AddressLabels() []string {
+	var labels []string
+	unknown := dc.addressLabel("unknown_label", defaultAddressUnknownLabel)
+
+	if len(dc.Groups) > 0 {
+		for _, group := range dc.Groups {
+			labels = append(labels, group.Label)
+		}
+		return append(labels, dc.addressLabel("other_label", defaultAddressOtherLabel), unknown)
+	}
+
+	t := getAddressTable()
+	level := dc.addressLevel()
+	for _, pref := range t.prefectures {
+		if level == AddressLevelPrefecture {
+			labels = append(labels, pref)
+			continue
+		}
+		prefix := ""
+		if dc.addressWithPrefecture() {
+			prefix = pref
+		}
+		for _, name := range t.municipalities[pref] {
+			labels = append(labels, prefix+name)
+			if level == AddressLevelWard {
+				for _, ward := range t.wards[name] {
+					labels = append(labels, prefix+name+ward)
+				}
+			}
+		}
+	}
+	return append(labels, unknown)
+}
+
+// officialNameExpression は照合用の名前を正式な名前に戻すSQL式を返す
+func (t *addressTable) officialNameExpression(name Expr) Expr {
+	keys := make([]string, 0, len(t.official))
+	for key := range t.official {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	expr := name
+	for _, key := range keys {
+		expr = Exprf("replace(%s, %s, %s)", expr, sqlStringLiteral(key), sqlStringLiteral(t.official[key]))
+	}
+	return expr
+}
+
+// generateAddressRegionExpression は住所を都道府県・市区町村・区、またはグループに分類するSQL式を生成
+// 元の列（例: 都道府県と市区町村、または住所）をつないで ParseAddress と同じ正規表現で解析し、
+// 「都道府県 + 市区町村 + 区」の照合用の名前（addr_path）から値を求める
+// 式が大きいため、途中の結果はラムダ式の引数で受け渡して同じ式を繰り返さないようにする
+// 空の住所・解析できない住所は unknown_label（既定値は「不明」）
+func (dc *DerivedColumn) generateAddressRegionExpression() Expr {
+	if len(dc.SourceColumns) == 0 {
+		return NewExpr("NULL")
+	}
+	t := getAddressTable()
+
+	parts := make([]Expr, len(dc.SourceColumns))
+	for i, column := range dc.SourceColumns {
+		parts[i] = Exprf("TRIM(CAST(%s AS VARCHAR))", Ident(column))
+	}
+	text := Exprf("NULLIF(regexp_replace(regexp_replace(translate(CONCAT_WS('', %s), 'ヶヵ', 'ケケ'), %s, '', 'g'), %s, '\\1'), '')",
+		JoinExprs(parts, ", "), sqlStringLiteral(addressNoisePattern), sqlStringLiteral(t.duplicatePattern))
+
+	// 都道府県が書かれている住所は、その都道府県の市区町村を照合する
+	// 書かれていない住所は、その都道府県にしかない市区町村から都道府県を求める
+	var cases []Expr
+	for _, pref := range t.prefectures {
+		rest := Exprf("substr(addr, %s)", NewExpr(fmt.Sprint(len([]rune(pref))+1)))
+		cases = append(cases, Exprf("WHEN starts_with(addr, %s) THEN %s || COALESCE(regexp_extract(%s, %s, 1), '')",
+			sqlStringLiteral(pref), sqlStringLiteral(pref), rest, sqlStringLiteral(t.namePatterns[pref])))
+	}
+	for _, pref := range t.prefectures {
+		if pattern, ok := t.inferPatterns[pref]; ok {
+			cases = append(cases, Exprf("WHEN regexp_matches(addr, %s) THEN %s || regexp_extract(addr, %s, 1)",
+				sqlStringLiteral(pattern), sqlStringLiteral(pref), sqlStringLiteral(t.namePatterns[pref])))
+		}
+	}
+	path := buildCaseExpression(cases, NewExpr("ELSE NULL"))
+
+	return Exprf("list_transform([%s], addr -> list_transform([%s], addr_path -> %s)[1])[1]",
+		text, path, dc.addressRegionLabel())
+}
+
+// addressRegionLabel は照合用の名前 addr_path（都道府県 + 市区町村 + 区）から値を求めるSQL式を返す
+func (dc *DerivedColumn) addressRegionLabel() Expr {
+	t := getAddressTable()
+	unknown := Param(dc.addressLabel("unknown_label", defaultAddressUnknownLabel))
+	path := NewExpr("addr_path")
+
+	if len(dc.Groups) > 0 {
+		cases := []Expr{Exprf("WHEN %s IS NULL THEN %s", path, unknown)}
+		for _, group := range dc.Groups {
+			var conditions []Expr
+			for _, area := range group.Areas {
+				paths, err := resolveAddressArea(area)
+				if err != nil {
+					continue
+				}
+				for _, p := range paths {
+					conditions = append(conditions, Exprf("starts_with(%s, %s)", path, Param(normalizeAddressName(p))))
+				}
+			}
+			if len(conditions) == 0 {
+				continue
+			}
+			cases = append(cases, Exprf("WHEN %s THEN %s", JoinExprs(conditions, " OR "), Param(group.Label)))
+		}
+		return buildCaseExpression(cases, Exprf("ELSE %s", Param(dc.addressLabel("other_label", defaultAddressOtherLabel))))
+	}
+
+	prefecture := Exprf("regexp_extract(%s, %s, 1)", path, sqlStringLiteral(t.prefecturePattern))
+	if dc.addressLevel() == AddressLevelPrefecture {
+		return Exprf("COALESCE(%s, %s)", prefecture, unknown)
+	}
+
+	// 市区町村（政令指定都市は区まで）の照合用の名前。解析できない場合はNULL
+	name := Exprf("NULLIF(substr(%s, length(%s) + 1), '')", path, prefecture)
+	value := name
+	if dc.addressLevel() != AddressLevelWard {
+		value = Exprf("COALESCE(NULLIF(regexp_extract(%s, %s, 1), ''), %s)", name, sqlStringLiteral(t.cityPattern), name)
+	}
+	if dc.addressWithPrefecture() {
+		value = Exprf("%s || %s", prefecture, value)
+	}
+	return Exprf("COALESCE(%s, %s)", t.officialNameExpression(value), unknown)
+}
+
+// validateAddressRegion は住所による地域分類の定義を確認する
+func (dc *DerivedColumn) validateAddressRegion() error {
+	if len(dc.SourceColumns) == 0 {
+		return fmt.Errorf("address_region requires at least one source column")
+	}
+	for _, column := range dc.SourceColumns {
+		if strings.TrimSpace(column) == "" {
+			return fmt.Errorf("source column name is required")
+		}
+	}
+
+	switch dc.addressLevel() {
+	case AddressLevelPrefecture, AddressLevelMunicipality, AddressLevelWard:
+	default:
+		return fmt.Errorf("invalid address level: %s", dc.addressLevel())
+	}
+
+	seen := make(map[string]bool)
+	for _, group := range dc.Groups {
+		label := strings.TrimSpace(group.Label)
+		if label == "" {
+			return fmt.Errorf("group label is required")
+		}
+		if seen[label] {
+			return fmt.Errorf("duplicate group label: %s", label)
+		}
+		seen[label] = true
+
+		if len(cleanKeywords(group.Areas)) == 0 {
+			return fmt.Errorf("group %s has no areas", label)
+		}
+		for _, area := range cleanKeywords(group.Areas) {
+			if _, err := resolveAddressArea(area); err != nil {
+				return fmt.Errorf("group %s: %w", label, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package analyzer
+
+import "testing"
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want Address
+	}{
+		{
+			name: "都道府県から書かれた住所",
+			text: "東京都千代田区丸の内1-1",
+			want: Address{Prefecture: "東京都", Municipality: "千代田区"},
+		},
+		{
+			name: "政令指定都市の区",
+			text: "神奈川県横浜市港北区日吉1丁目",
+			want: Address{Prefecture: "神奈川県", Municipality: "横浜市", Ward: "港北区"},
+		},
+		{
+			name: "都道府県のない政令指定都市の区",
+			text: "札幌市中央区北1条西2丁目",
+			want: Address{Prefecture: "北海道", Municipality: "札幌市", Ward: "中央区"},
+		},
+		{
+			name: "郡は読み飛ばす",
+			text: "北海道虻田郡倶知安町南1条東",
+			want: Address{Prefecture: "北海道", Municipality: "倶知安町"},
+		},
+		{
+			name: "都道府県のない郡",
+			text: "西多摩郡瑞穂町箱根ケ崎",
+			want: Address{Prefecture: "東京都", Municipality: "瑞穂町"},
+		},
+		{
+			name: "都道府県のない市",
+			text: "八王子市元本郷町",
+			want: Address{Prefecture: "東京都", Municipality: "八王子市"},
+		},
+		{
+			name: "全国に同じ名前がある市区町村は都道府県を決めない",
+			text: "府中市",
+			want: Address{},
+		},
+		{
+			name: "郵便番号と空白",
+			text: "〒100-0005 東京都 千代田区丸の内",
+			want: Address{Prefecture: "東京都", Municipality: "千代田区"},
+		},
+		{
+			name: "都道府県の列と住所の列をつないだ住所",
+			text: "大阪府大阪府大阪市北区梅田",
+			want: Address{Prefecture: "大阪府", Municipality: "大阪市", Ward: "北区"},
+		},
+		{
+			name: "ヶとケの表記揺れは正式な名前にする",
+			text: "茨城県龍ヶ崎市",
+			want: Address{Prefecture: "茨城県", Municipality: "龍ケ崎市"},
+		},
+		{
+			name: "ヶとケの表記揺れ（正式な名前がヶ）",
+			text: "青森県西津軽郡鰺ケ沢町",
+			want: Address{Prefecture: "青森県", Municipality: "鰺ヶ沢町"},
+		},
+		{
+			name: "都道府県だけ",
+			text: "沖縄県",
+			want: Address{Prefecture: "沖縄県"},
+		},
+		{
+			name: "解析できない住所",
+			text: "海外",
+			want: Address{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseAddress(tt.text); got != tt.want {
+				t.Errorf("ParseAddress(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 年齢（age_from_birthdate）と年齢区分（age_band）の設定
+const (
+	AgeSourceAge       = "age"       // 年齢の列から区分する
+	AgeSourceBirthdate = "birthdate" // 生年月日から年齢を求めて区分する
+
+	defaultAgeBandWidth = 10
+	defaultAgeBandMin   = 10
+	defaultAgeBandMax   = 80
+	maxAgeLabel         = 130 // 年齢の表示順に登録する最大の年齢
+)
+
+// ageNumberPattern は年齢の列から数値を取り出す正規表現（「25歳」「25.5」も25とする）
+const ageNumberPattern = `^\s*([0-9]+)`
+
+// referenceDate は年齢を求める基準日を返す（未指定の場合はNULLで、SQLでは当日とする）
+// YAMLの日付（time.Time）と、ParseDate で読み取れる文字列のどちらでもよい
+func (dc *DerivedColumn) referenceDate() (*time.Time, error) {
+	switch v := dc.Parameters["reference_date"].(type) {
+	case nil:
+		return nil, nil
+	case time.Time:
+		return &v, nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil, nil
+		}
+		date, ok := ParseDate(v)
+		if !ok {
+			return nil, fmt.Errorf("invalid reference_date: %s", v)
+		}
+		return &date, nil
+	default:
+		return nil, fmt.Errorf("invalid reference_date: %v", v)
+	}
+}
+
+// referenceDateExpression は基準日のSQL式を返す（未指定・不正な場合は当日）
+func (dc *DerivedColumn) referenceDateExpression() Expr {
+	date, err := dc.referenceDate()
+	if err != nil || date == nil {
+		return NewExpr("current_date")
+	}
+	return Exprf("CAST(%s AS DATE)", Param(date.Format("2006-01-02")))
+}
+
+// ageExpression は生年月日から基準日時点の満年齢（INTEGER）を求めるSQL式を生成
+// 生年月日を読み取れない場合や、基準日より後に生まれた場合はNULL
+func (dc *DerivedColumn) ageExpression() Expr {
+	return Exprf("list_transform([%s], birth -> list_transform([%s], ref -> CASE WHEN birth <= ref THEN date_sub('year', birth, ref) END)[1])[1]",
+		dateExpression(Ident(dc.dateSourceColumn())), dc.referenceDateExpression())
+}
+
+// AgeLabels は年齢の値を表示順（0歳から順）に返す
+func (dc *DerivedColumn) AgeLabels() []string {
+	labels := make([]string, 0, maxAgeLabel+1)
+	for age := 0; age <= maxAgeLabel; age++ {
+		labels = append(labels, strconv.Itoa(age))
+	}
+	return labels
+}
+
+// generateAgeExpression は生年月日から満年齢を求めるSQL式を生成
+func (dc *DerivedColumn) generateAgeExpression() Expr {
+	return Exprf("CAST(%s AS VARCHAR)", dc.ageExpression())
+}
+
+// validateAge は年齢の計算の定義を確認する
+func (dc *DerivedColumn) validateAge() error {
+	_, err := dc.referenceDate()
+	return err
+}
+
+// ageSource は年齢区分の元の値の種類を返す（未指定の場合は年齢の列）
+func (dc *DerivedColumn) ageSource() string {
+	if source, ok := dc.Parameters["source_type"].(string); ok && source != "" {
+		return source
+	}
+	return AgeSourceAge
+}
+
+// ageBandWidth は年齢区分の幅を返す（未指定または不正な値の場合は10歳）
+func (dc *DerivedColumn) ageBandWidth() int {
+	if width, ok := numberParameter(dc.Parameters["width"]); ok && width >= 1 {
+		return int(width)
+	}
+	return defaultAgeBandWidth
+}
+
+// customAgeBandEdges はパラメータ edges で指定した区切りの年齢（昇順・重複なし）を返す
+func (dc *DerivedColumn) customAgeBandEdges() []int {
+	values, _ := dc.Parameters["edges"].([]interface{})
+	seen := make(map[int]bool)
+	var edges []int
+	for _, v := range values {
+		if f, ok := numberParameter(v); ok && !seen[int(f)] {
+			seen[int(f)] = true
+			edges = append(edges, int(f))
+		}
+	}
+	sort.Ints(edges)
+	return edges
+}
+
+// AgeBandEdges は年齢区分の区切りの年齢（昇順）を返す
+// edges の指定があればそれを使い、なければ min〜max を width 歳刻みで区切る
+func (dc *DerivedColumn) AgeBandEdges() []int {
+	if edges := dc.customAgeBandEdges(); len(edges) > 0 {
+		return edges
+	}
+
+	min, max := defaultAgeBandMin, defaultAgeBandMax
+	if v, ok := numberParameter(dc.Parameters["min"]); ok {
+		min = int(v)
+	}
+	if v, ok := numberParameter(dc.Parameters["max"]); ok {
+		max = int(v)
+	}
+	var edges []int
+	for age := min; age <= max; age += dc.ageBandWidth() {
+		edges = append(edges, age)
+	}
+	return edges
+}
+
+// AgeBandLabels は年齢区分のラベルを表示順に返す
+// 先頭は最小の区切り未満、末尾は最大の区切り以上のラベル
+// 10歳刻みで10の倍数から区切る場合は「20代」、それ以外は「20〜24歳」のようにする
+func (dc *DerivedColumn) AgeBandLabels() []string {
+	edges := dc.AgeBandEdges()
+	if len(edges) == 0 {
+		return nil
+	}
+	decades := len(dc.customAgeBandEdges()) == 0 && dc.ageBandWidth() == 10
+
+	labels := []string{fmt.Sprintf("%d歳未満", edges[0])}
+	for i := 0; i < len(edges)-1; i++ {
+		lower, upper := edges[i], edges[i+1]
+		switch {
+		case decades && lower%10 == 0:
+			labels = append(labels, fmt.Sprintf("%d代", lower))
+		case upper == lower+1:
+			labels = append(labels, fmt.Sprintf("%d歳", lower))
+		default:
+			labels = append(labels, fmt.Sprintf("%d〜%d歳", lower, upper-1))
+		}
+	}
+	return append(labels, fmt.Sprintf("%d歳以上", edges[len(edges)-1]))
+}
+
+// ageBandValueExpression は年齢区分の元の年齢（INTEGER）のSQL式を生成
+func (dc *DerivedColumn) ageBandValueExpression() Expr {
+	if dc.ageSource() == AgeSourceBirthdate {
+		return dc.ageExpression()
+	}
+	if len(dc.SourceColumns) == 0 {
+		return NewExpr("NULL")
+	}
+	text := Exprf("translate(CAST(%s AS VARCHAR), '０１２３４５６７８９', '0123456789')", Ident(dc.SourceColumns[0]))
+	return Exprf("TRY_CAST(regexp_extract(%s, %s, 1) AS INTEGER)", text, sqlStringLiteral(ageNumberPattern))
+}
+
+// generateAgeBandExpression は年齢を区分に分けるSQL式を生成
+// 区分は「下限以上・次の区切り未満」。年齢を読み取れない値はNULL
+func (dc *DerivedColumn) generateAgeBandExpression() Expr {
+	edges := dc.AgeBandEdges()
+	if len(edges) == 0 {
+		return NewExpr("NULL")
+	}
+	labels := dc.AgeBandLabels()
+
+	cases := []Expr{NewExpr("WHEN age IS NULL THEN NULL")}
+	for i, edge := range edges {
+		cases = append(cases, Exprf("WHEN age < %s THEN %s", Param(edge), Param(labels[i])))
+	}
+	caseExpr := buildCaseExpression(cases, Exprf("ELSE %s", Param(labels[len(labels)-1])))
+
+	return Exprf("list_transform([%s], age -> %s)[1]", dc.ageBandValueExpression(), caseExpr)
+}
+
+// validateAgeBand は年齢区分の定義を確認する
+func (dc *DerivedColumn) validateAgeBand() error {
+	switch dc.ageSource() {
+	case AgeSourceAge:
+		if len(dc.SourceColumns) == 0 || strings.TrimSpace(dc.SourceColumns[0]) == "" {
+			return fmt.Errorf("age_band requires an age column")
+		}
+	case AgeSourceBirthdate:
+		if err := dc.validateAge(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported source_type: %s (supported: age, birthdate)", dc.ageSource())
+	}
+	if len(dc.AgeBandEdges()) == 0 {
+		return fmt.Errorf("age_band requires edges (or min <= max)")
+	}
+	return nil
+}
@@ -12,6 +12,8 @@ type Analyzer struct {
 	db              *sql.DB
 	DBPath          string
 	Table           string
+	Joins           []TableJoin // 結合するテーブル（空の場合はTableのみ）
+	source          string      // FROM句に使うテーブル名または結合のサブクエリ
 	DerivedColumns  []DerivedColumn
 	derivedColsMap  map[string]*DerivedColumn // 名前から派生列を引くマップ
 	Filters         []Filter                  // 利用可能なフィルタ
@@ -26,6 +28,13 @@ func NewAnalyzer(dbPath, table string) (*Analyzer, error) {
 
 // NewAnalyzerWithConfigs は設定ファイルパスを指定してAnalyzerを作成
 func NewAnalyzerWithConfigs(dbPath, table, derivedColumnsPath, filtersPath, columnOrdersPath string) (*Analyzer, error) {
+	return NewAnalyzerWithSource(dbPath, DataSource{Table: table}, derivedColumnsPath, filtersPath, columnOrdersPath)
+}
+
+// NewAnalyzerWithSource は集計対象（テーブルの結合を含む）と設定ファイルパスを指定してAnalyzerを作成
+func NewAnalyzerWithSource(dbPath string, dataSource DataSource, derivedColumnsPath, filtersPath, columnOrdersPath string) (*Analyzer, error) {
+	table := dataSource.Table
+
 	// DuckDB拡張機能の自動インストールを有効化
 	db, err := sql.Open("duckdb", dbPath)
 	if err != nil {
@@ -42,9 +51,16 @@ func NewAnalyzerWithConfigs(dbPath, table, derivedColumnsPath, filtersPath, colu
 		return nil, fmt.Errorf("failed to set autoload: %w", err)
 	}
 
+	// 集計対象のFROM句を組み立て
+	source, err := buildSourceSQL(db, dataSource)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("invalid data source: %w", err)
+	}
+
 	// テーブルの存在確認
 	var count int
-	err = db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s LIMIT 1", table)).Scan(&count)
+	err = db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s LIMIT 1", source)).Scan(&count)
 	if err != nil {
 		db.Close()
 		return nil, fmt.Errorf("table %s not found or cannot be accessed: %w", table, err)
@@ -83,16 +99,20 @@ func NewAnalyzerWithConfigs(dbPath, table, derivedColumnsPath, filtersPath, colu
 		columnOrdersMap[columnOrders[i].Column] = &columnOrders[i]
 	}
 
-	return &Analyzer{
+	a := &Analyzer{
 		db:              db,
 		DBPath:          dbPath,
 		Table:           table,
+		Joins:           dataSource.Joins,
+		source:          source,
 		DerivedColumns:  derivedCols,
 		derivedColsMap:  derivedColsMap,
 		Filters:         filters,
 		ColumnOrders:    columnOrders,
 		columnOrdersMap: columnOrdersMap,
-	}, nil
+	}
+
+	return a, nil
 }
 
 // Close はデータベース接続を閉じる
@@ -106,7 +126,7 @@ func (a *Analyzer) Close() error {
 // GetTableInfo はテーブルの基本情報を取得
 func (a *Analyzer) GetTableInfo() (int, error) {
 	var count int
-	query := fmt.Sprintf("SELECT COUNT(*) FROM %s", a.Table)
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s", a.source)
 	err := a.db.QueryRow(query).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get table info: %w", err)
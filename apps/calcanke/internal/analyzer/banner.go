@@ -0,0 +1,90 @@
+package analyzer
+
+import "fmt"
+
+// BannerTableConfig はバナー表（表頭×表側の集計表）の設定
+// 表側の設問ごとに、全体と表頭の各列の値ごとの集計を1つの表にまとめる
+type BannerTableConfig struct {
+	Banners      []*Column // 表頭（性別、学年など集計表の列に並べる属性）
+	Stubs        []*Column // 表側（1問ずつ表にする設問）
+	Filter       *Filter   // 適用するフィルタ（nilの場合はフィルタなし）
+	WeightColumn *Column   // ウェイト列（nilの場合はウェイトなし）
+}
+
+// BannerBook はバナー表の集計結果（表側の設問ごとの表の集まり）
+type BannerBook struct {
+	Banners      []string // 表頭の列名
+	Tables       []BannerTable
+	WeightColumn string // ウェイト列（空の場合はウェイトなし）
+}
+
+// IsWeighted はウェイト付きの集計かどうかを返す
+func (b *BannerBook) IsWeighted() bool {
+	return b.WeightColumn != ""
+}
+
+// BannerTable は表側の1つの設問の表
+type BannerTable struct {
+	Stub    string
+	Values  []string         // 表側の値（表示順）
+	Total   *SimpletabResult // 全体の列
+	Banners []BannerColumn   // 表頭の列ごとの集計
+}
+
+// BannerColumn は表頭の1つの列の集計
+// ピボットのX値が表頭の値、Y値が表側の値で、割合は表頭の値ごとの割合（行%）
+type BannerColumn struct {
+	Column string
+	Pivot  *CrosstabPivot
+}
+
+// BannerTables は表側の設問ごとに、全体と表頭の各列とのクロス集計を実行
+// 複数回答の列は、表頭・表側とも回答ごとに分割して集計する
+func (a *Analyzer) BannerTables(config BannerTableConfig) (*BannerBook, error) {
+	if len(config.Banners) == 0 || len(config.Stubs) == 0 {
+		return nil, fmt.Errorf("banner table requires at least one banner and one stub")
+	}
+
+	book := &BannerBook{}
+	for _, banner := range config.Banners {
+		book.Banners = append(book.Banners, banner.Name)
+	}
+	if config.WeightColumn != nil {
+		book.WeightColumn = config.WeightColumn.Name
+	}
+
+	for _, stub := range config.Stubs {
+		total, err := a.SimpletabWithWeight(stub, stub.IsMulti, config.Filter, config.WeightColumn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to tabulate %s: %w", stub.Name, err)
+		}
+
+		table := BannerTable{Stub: stub.Name, Total: total}
+		for _, row := range total.Rows {
+			table.Values = append(table.Values, row.Value)
+		}
+
+		for _, banner := range config.Banners {
+			result, err := a.CrosstabWithFilter(AnalysisConfig{
+				XColumn:        banner,
+				YColumn:        stub,
+				SplitX:         banner.IsMulti,
+				SplitY:         stub.IsMulti,
+				WeightColumn:   config.WeightColumn,
+				PercentageBase: PercentageBaseRow,
+			}, config.Filter)
+			if err != nil {
+				return nil, fmt.Errorf("failed to tabulate %s by %s: %w", stub.Name, banner.Name, err)
+			}
+
+			table.Banners = append(table.Banners, BannerColumn{
+				Column: banner.Name,
+				Pivot:  result.ToPivotWithAnalyzer(a),
+			})
+		}
+
+		book.Tables = append(book.Tables, table)
+	}
+
+	return book, nil
+}
@@ -0,0 +1,307 @@
+package analyzer
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 階級分け（binning）の方法
+const (
+	BinningEdges      = "edges"       // 境界値を明示的に指定
+	BinningEqualWidth = "equal_width" // 最小値〜最大値を等間隔に分割
+	BinningQuantile   = "quantile"    // 件数がほぼ等しくなるように分位点で分割
+)
+
+// 階級分けのパラメータの既定値
+const defaultBinCount = 5
+
+// binningMethod は階級分けの方法を返す（未指定の場合は境界値の指定）
+func (dc *DerivedColumn) binningMethod() string {
+	if method, ok := dc.Parameters["method"].(string); ok && method != "" {
+		return method
+	}
+	return BinningEdges
+}
+
+// binCount は階級の数を返す（未指定または不正な値の場合は5）
+func (dc *DerivedColumn) binCount() int {
+	if n, ok := numberParameter(dc.Parameters["bins"]); ok && n >= 1 {
+		return int(n)
+	}
+	return defaultBinCount
+}
+
+// BinEdges は階級の境界値（昇順）を返す
+// データから計算した境界値があればそれを使い、なければパラメータから求める
+// 境界値が2つ未満の場合は階級分けできない
+func (dc *DerivedColumn) BinEdges() []float64 {
+	if dc.binEdges != nil {
+		return dc.binEdges
+	}
+
+	switch dc.binningMethod() {
+	case BinningEqualWidth:
+		// 最小値・最大値が指定されていればデータを見ずに分割できる
+		min, okMin := numberParameter(dc.Parameters["min"])
+		max, okMax := numberParameter(dc.Parameters["max"])
+		if okMin && okMax {
+			return equalWidthEdges(min, max, dc.binCount())
+		}
+		return nil
+	case BinningQuantile:
+		return nil
+	default:
+		values, _ := dc.Parameters["edges"].([]interface{})
+		var edges []float64
+		for _, v := range values {
+			if f, ok := numberParameter(v); ok {
+				edges = append(edges, f)
+			}
+		}
+		sort.Float64s(edges)
+		return uniqueEdges(edges)
+	}
+}
+
+// closedLastBin は最後の階級が上限を含むかどうかを返す
+// データの最小値〜最大値から求めた境界値（等間隔・分位点）は、最大値が最後の階級に入るように上限を含める
+// 境界値を指定した場合は全ての階級を「下限以上・上限未満」とし、最大の境界値以上は範囲外とする
+func (dc *DerivedColumn) closedLastBin() bool {
+	return dc.binningMethod() != BinningEdges
+}
+
+// BinLabels は階級のラベルを表示順に返す
+// 先頭は最小の境界値未満、末尾は範囲外（最大の境界値以上、または超）の値のラベル
+// パラメータ labels の数が階級の数と一致する場合はそのラベルを使う
+func (dc *DerivedColumn) BinLabels() []string {
+	edges := dc.BinEdges()
+	if len(edges) < 2 {
+		return nil
+	}
+	last := edges[len(edges)-1]
+
+	var custom []string
+	if values, ok := dc.Parameters["labels"].([]interface{}); ok && len(values) == len(edges)-1 {
+		for _, v := range values {
+			custom = append(custom, fmt.Sprint(v))
+		}
+	}
+
+	labels := []string{formatEdge(edges[0]) + "未満"}
+	for i := 0; i < len(edges)-1; i++ {
+		switch {
+		case custom != nil:
+			labels = append(labels, custom[i])
+		case i == len(edges)-2 && dc.closedLastBin():
+			labels = append(labels, formatEdge(edges[i])+"〜"+formatEdge(edges[i+1]))
+		default:
+			labels = append(labels, formatEdge(edges[i])+"〜"+formatEdge(edges[i+1])+"未満")
+		}
+	}
+	if dc.closedLastBin() {
+		return append(labels, formatEdge(last)+"超")
+	}
+	return append(labels, formatEdge(last)+"以上")
+}
+
+// generateBinningExpression は数値の列を階級に分けるSQL式を生成
+// 階級は「下限以上・上限未満」（等間隔・分位点の最後の階級のみ上限を含む）
+// 数値に変換できない値はNULL、範囲外の値は「〜未満」「〜以上」（または「〜超」）に分類する
+func (dc *DerivedColumn) generateBinningExpression() Expr {
+	edges := dc.BinEdges()
+	if len(dc.SourceColumns) == 0 || len(edges) < 2 {
+		return NewExpr("NULL")
+	}
+	labels := dc.BinLabels()
+
+	value := Exprf("TRY_CAST(%s AS DOUBLE)", Ident(dc.SourceColumns[0]))
+	cases := []Expr{
+		Exprf("WHEN %s IS NULL THEN NULL", value),
+		Exprf("WHEN %s < %s THEN %s", value, Param(edges[0]), Param(labels[0])),
+	}
+	for i := 1; i < len(edges); i++ {
+		operator := "<"
+		if i == len(edges)-1 && dc.closedLastBin() {
+			operator = "<="
+		}
+		cases = append(cases, Exprf("WHEN %s "+operator+" %s THEN %s", value, Param(edges[i]), Param(labels[i])))
+	}
+
+	return buildCaseExpression(cases, Exprf("ELSE %s", Param(labels[len(labels)-1])))
+}
+
+// binEdgesKey はデータから計算した境界値のキャッシュのキー
+// データベースファイルが更新されると（再インポートなど）別のキーになる
+type binEdgesKey struct {
+	dbPath  string
+	modTime time.Time
+	size    int64
+	source  string
+	column  string
+	method  string
+	bins    int
+}
+
+var (
+	binEdgesMu    sync.Mutex
+	binEdgesCache = make(map[binEdgesKey][]float64)
+)
+
+// resolveBinEdges はデータが必要な階級分け（等間隔・分位点）の境界値を、派生列を使うときに計算する
+// 同じデータに対する計算結果はAnalyzerをまたいでキャッシュし、リクエストのたびに全件を走査しない
+// 計算できない派生列は境界値なし（値はNULL）のままにする
+func (a *Analyzer) resolveBinEdges(dc *DerivedColumn) {
+	if dc.CalculationType != "binning" || len(dc.SourceColumns) == 0 || dc.binEdgesResolved || dc.BinEdges() != nil {
+		return
+	}
+	dc.binEdgesResolved = true
+
+	info, err := os.Stat(a.DBPath)
+	if err != nil {
+		// ファイルでないデータベースはキャッシュせずに計算する
+		dc.binEdges, _ = a.computeBinEdges(dc)
+		return
+	}
+	key := binEdgesKey{
+		dbPath:  a.DBPath,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		source:  a.source,
+		column:  dc.SourceColumns[0],
+		method:  dc.binningMethod(),
+		bins:    dc.binCount(),
+	}
+
+	binEdgesMu.Lock()
+	edges, ok := binEdgesCache[key]
+	binEdgesMu.Unlock()
+	if ok {
+		dc.binEdges = edges
+		return
+	}
+
+	edges, err = a.computeBinEdges(dc)
+	if err != nil {
+		return
+	}
+	binEdgesMu.Lock()
+	binEdgesCache[key] = edges
+	binEdgesMu.Unlock()
+	dc.binEdges = edges
+}
+
+// computeBinEdges は元の列の値から階級の境界値を計算する
+func (a *Analyzer) computeBinEdges(dc *DerivedColumn) ([]float64, error) {
+	value := Exprf("TRY_CAST(%s AS DOUBLE)", Ident(dc.SourceColumns[0]))
+	bins := dc.binCount()
+
+	var selects []Expr
+	if dc.binningMethod() == BinningQuantile {
+		for i := 0; i <= bins; i++ {
+			selects = append(selects, Exprf("QUANTILE_CONT(%s, %s)", value, Param(float64(i)/float64(bins))))
+		}
+	} else {
+		selects = []Expr{Exprf("MIN(%s)", value), Exprf("MAX(%s)", value)}
+	}
+
+	query := Exprf("SELECT %s FROM %s", JoinExprs(selects, ", "), a.tableExpression())
+	results := make([]sql.NullFloat64, len(selects))
+	dest := make([]interface{}, len(results))
+	for i := range results {
+		dest[i] = &results[i]
+	}
+	if err := a.db.QueryRow(query.SQL, query.Args...).Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to compute bin edges: %w", err)
+	}
+
+	values := make([]float64, len(results))
+	for i, r := range results {
+		if !r.Valid {
+			return nil, fmt.Errorf("column %s has no numeric values", dc.SourceColumns[0])
+		}
+		values[i] = r.Float64
+	}
+
+	if dc.binningMethod() == BinningQuantile {
+		return uniqueEdges(roundEdges(values)), nil
+	}
+	return equalWidthEdges(values[0], values[1], bins), nil
+}
+
+// equalWidthEdges は最小値〜最大値を等間隔に分割した境界値を返す
+func equalWidthEdges(min, max float64, bins int) []float64 {
+	if max < min {
+		min, max = max, min
+	}
+	edges := make([]float64, bins+1)
+	width := (max - min) / float64(bins)
+	for i := range edges {
+		edges[i] = min + width*float64(i)
+	}
+	edges[bins] = max
+	return uniqueEdges(roundEdges(edges))
+}
+
+// roundEdges は境界値をラベルに表示する桁（小数第2位）に丸める
+// 全ての値が階級に入るように、最小の境界値は切り捨て、最大の境界値は切り上げる
+func roundEdges(edges []float64) []float64 {
+	rounded := make([]float64, len(edges))
+	for i, e := range edges {
+		switch i {
+		case 0:
+			rounded[i] = math.Floor(e*100) / 100
+		case len(edges) - 1:
+			rounded[i] = math.Ceil(e*100) / 100
+		default:
+			rounded[i] = math.Round(e*100) / 100
+		}
+	}
+	return rounded
+}
+
+// uniqueEdges は昇順の境界値から重複を除く（同じ値が多い列の分位点など）
+// 全て同じ値の場合は、その値だけの階級になるよう2つの境界値を返す
+func uniqueEdges(edges []float64) []float64 {
+	if len(edges) == 0 {
+		return nil
+	}
+	result := []float64{edges[0]}
+	for _, e := range edges[1:] {
+		if e != result[len(result)-1] {
+			result = append(result, e)
+		}
+	}
+	if len(result) == 1 && len(edges) > 1 {
+		result = append(result, result[0])
+	}
+	return result
+}
+
+// formatEdge は境界値をラベル用の文字列にする（整数は小数点なし）
+func formatEdge(edge float64) string {
+	return strconv.FormatFloat(edge, 'f', -1, 64)
+}
+
+// numberParameter はパラメータの数値を取り出す
+// YAMLからは int、JSONからは float64 として読み込まれるため両方を受け付ける
+func numberParameter(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -18,6 +18,7 @@ type ColumnOrder struct {
 	Column      string   `yaml:"column" json:"column"`
 	Description string   `yaml:"description" json:"description"`
 	Values      []string `yaml:"values" json:"values"`
+	Scale       *Scale   `yaml:"scale,omitempty" json:"scale,omitempty"` // 評価尺度として集計する場合の設定（省略可）
 }
 
 // LoadColumnOrders は設定ファイルから列の値の表示順序を読み込む
@@ -73,7 +74,9 @@ func (a *Analyzer) GetValueOrder(columnName string) map[string]int {
 		return colOrder.GetOrderForColumn()
 	}
 
-	// 優先度2: 派生列のルール順序
+	// 優先度2: 派生列のルール順序（階級分けは階級の順、キーワードによるコーディングはカテゴリの順、
+	// 住所による地域分類はグループの順または都道府県・市区町村の一覧の順、学年・学校種別は学年の順、
+	// 年齢・年齢区分は年齢の順）
 	if derivedCol, exists := a.derivedColsMap[columnName]; exists {
 		if derivedCol.CalculationType == "rules" && len(derivedCol.Rules) > 0 {
 			orderMap := make(map[string]int)
@@ -82,6 +85,52 @@ func (a *Analyzer) GetValueOrder(columnName string) map[string]int {
 			}
 			return orderMap
 		}
+		if derivedCol.CalculationType == "binning" {
+			a.resolveBinEdges(derivedCol)
+			orderMap := make(map[string]int)
+			for i, label := range derivedCol.BinLabels() {
+				orderMap[label] = i
+			}
+			return orderMap
+		}
+		if derivedCol.CalculationType == "keyword_coding" {
+			orderMap := make(map[string]int)
+			for i, label := range derivedCol.CodingLabels() {
+				orderMap[label] = i
+			}
+			return orderMap
+		}
+		if derivedCol.CalculationType == "grade_from_birthdate" || derivedCol.CalculationType == "school_type_from_birthdate" {
+			labels := derivedCol.GradeLabels()
+			if derivedCol.CalculationType == "school_type_from_birthdate" {
+				labels = derivedCol.SchoolTypeLabels()
+			}
+			orderMap := make(map[string]int)
+			for i, label := range labels {
+				orderMap[label] = i
+			}
+			return orderMap
+		}
+		if derivedCol.CalculationType == "age_from_birthdate" || derivedCol.CalculationType == "age_band" {
+			labels := derivedCol.AgeLabels()
+			if derivedCol.CalculationType == "age_band" {
+				labels = derivedCol.AgeBandLabels()
+			}
+			orderMap := make(map[string]int)
+			for i, label := range labels {
+				orderMap[label] = i
+			}
+			return orderMap
+		}
+		if derivedCol.CalculationType == "address_region" {
+			orderMap := make(map[string]int)
+			for i, label := range derivedCol.AddressLabels() {
+				if _, exists := orderMap[label]; !exists {
+					orderMap[label] = i
+				}
+			}
+			return orderMap
+		}
 	}
 
 	// 優先度3: デフォルト（順序なし = 空のマップ）
@@ -9,7 +9,7 @@ import (
 // GetColumns は全列の情報を取得
 func (a *Analyzer) GetColumns() (ColumnList, error) {
 	// 1. DESCRIBE でスキーマ取得
-	query := fmt.Sprintf("DESCRIBE %s", a.Table)
+	query := fmt.Sprintf("DESCRIBE SELECT * FROM %s", a.source)
 	rows, err := a.db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to describe table: %w", err)
@@ -49,8 +49,12 @@ func (a *Analyzer) GetColumns() (ColumnList, error) {
 		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	// 派生列を追加
-	for _, derivedCol := range a.DerivedColumns {
+	// 派生列を追加（等間隔・分位点の階級分けは、ここでデータから境界値を求める。
+	// SQL式は保存時の確認の記録がない場合だけ、ここで確認する）
+	for i := range a.DerivedColumns {
+		derivedCol := &a.DerivedColumns[i]
+		a.resolveBinEdges(derivedCol)
+		a.checkUnsavedSQLExpression(derivedCol)
 		col := derivedCol.GetDerivedColumn(index)
 		columns = append(columns, col)
 		index++
@@ -78,10 +82,10 @@ func (a *Analyzer) detectMultiAnswer(columnName string) (bool, error) {
 	query := fmt.Sprintf(`
 		SELECT
 			COUNT(*) as total,
-			SUM(CASE WHEN POSITION(CHR(10) IN "%s") > 0 THEN 1 ELSE 0 END) as multi_count
+			SUM(CASE WHEN POSITION(CHR(10) IN %s) > 0 THEN 1 ELSE 0 END) as multi_count
 		FROM %s
-		WHERE "%s" IS NOT NULL
-	`, columnName, a.Table, columnName)
+		WHERE %s IS NOT NULL
+	`, QuoteIdentifier(columnName), a.source, QuoteIdentifier(columnName))
 
 	var total, multiCount int
 	err := a.db.QueryRow(query).Scan(&total, &multiCount)
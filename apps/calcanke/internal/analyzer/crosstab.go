@@ -11,189 +11,189 @@ func (a *Analyzer) Crosstab(config AnalysisConfig) (*CrosstabResult, error) {
 }
 
 // CrosstabWithFilter はフィルタを適用してクロス集計を実行
+// ZColumn は無視する（層別の集計は LayeredCrosstab を使う）
 func (a *Analyzer) CrosstabWithFilter(config AnalysisConfig, filter *Filter) (*CrosstabResult, error) {
-	// 派生列の場合は、merge タイプ以外は複数回答の分割に対応しない
+	config.ZColumn = nil
+	config.SplitZ = false
+	config = normalizeSplit(config)
+
+	rowsByLayer, err := a.queryCrosstab(config, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return newCrosstabResult(config, rowsByLayer[""]), nil
+}
+
+// normalizeSplit は分割に対応しない軸の分割指定を外す
+// 派生列の場合は、merge タイプ以外は複数回答の分割に対応しない
+func normalizeSplit(config AnalysisConfig) AnalysisConfig {
 	if config.XColumn.IsDerived && !config.XColumn.IsMulti {
 		config.SplitX = false
 	}
 	if config.YColumn.IsDerived && !config.YColumn.IsMulti {
 		config.SplitY = false
 	}
+	if config.ZColumn != nil && config.ZColumn.IsDerived && !config.ZColumn.IsMulti {
+		config.SplitZ = false
+	}
+	return config
+}
+
+// newCrosstabResult は集計した行からクロス集計結果を作成
+func newCrosstabResult(config AnalysisConfig, rows []CrosstabRow) *CrosstabResult {
+	// 総件数を計算
+	total := 0
+	weightedTotal := 0.0
+	for _, row := range rows {
+		total += row.Count
+		weightedTotal += row.WeightedCount
+	}
 
-	// SQLを動的に構築
-	var query string
+	result := &CrosstabResult{
+		XColumn:       config.XColumn.Name,
+		YColumn:       config.YColumn.Name,
+		Rows:          rows,
+		Total:         total,
+		WeightedTotal: weightedTotal,
 
-	if config.SplitX || config.SplitY {
-		// 複数回答対応のクロス集計
-		query = a.buildMultiAnswerCrosstabQuery(config, filter)
-	} else {
-		// シンプルなクロス集計
-		query = a.buildSimpleCrosstabQuery(config, filter)
+		PercentageBase: config.PercentageBase.OrDefault(),
+		Split:          config.SplitX || config.SplitY,
+	}
+	if config.WeightColumn != nil {
+		result.WeightColumn = config.WeightColumn.Name
 	}
 
-	// クエリ実行
-	rows, err := a.db.Query(query)
+	return result
+}
+
+// queryCrosstab はクロス集計のSQLを実行し、層（Z値）ごとの行を返す
+// 層がない場合は空文字の層1つにまとめる
+func (a *Analyzer) queryCrosstab(config AnalysisConfig, filter *Filter) (map[string][]CrosstabRow, error) {
+	query := a.buildCrosstabQuery(config, filter)
+
+	rows, err := a.db.Query(query.SQL, query.Args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute crosstab query: %w", err)
 	}
 	defer rows.Close()
 
 	// 結果をパース
-	var resultRows []CrosstabRow
+	rowsByLayer := make(map[string][]CrosstabRow)
 	for rows.Next() {
+		var layer string
 		var row CrosstabRow
-		err := rows.Scan(&row.XValue, &row.YValue, &row.Count, &row.Percentage)
-		if err != nil {
+		dest := []interface{}{
+			&row.XValue, &row.YValue, &row.Count,
+			&row.Percentage, &row.ColumnPercentage, &row.TotalPercentage,
+			&row.WeightedCount,
+			&row.WeightedPercentage, &row.WeightedColumnPercentage, &row.WeightedTotalPercentage,
+		}
+		if config.ZColumn != nil {
+			dest = append([]interface{}{&layer}, dest...)
+		}
+		if err := rows.Scan(dest...); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
-		resultRows = append(resultRows, row)
+		rowsByLayer[layer] = append(rowsByLayer[layer], row)
 	}
 
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	// 総件数を計算
-	total := 0
-	for _, row := range resultRows {
-		total += row.Count
-	}
-
-	result := &CrosstabResult{
-		XColumn: config.XColumn.Name,
-		YColumn: config.YColumn.Name,
-		Rows:    resultRows,
-		Total:   total,
-	}
-
-	return result, nil
+	return rowsByLayer, nil
 }
 
-// buildSimpleCrosstabQuery はシンプルなクロス集計のSQLを生成
-func (a *Analyzer) buildSimpleCrosstabQuery(config AnalysisConfig, filter *Filter) string {
-	xExpr := config.XColumn.GetSQLExpression()
-	yExpr := config.YColumn.GetSQLExpression()
-
-	// WHERE句の構築（派生列の場合は不要）
-	var whereClauses []string
-	if !config.XColumn.IsDerived {
-		whereClauses = append(whereClauses, fmt.Sprintf(`"%s" IS NOT NULL`, config.XColumn.Name))
-	}
-	if !config.YColumn.IsDerived {
-		whereClauses = append(whereClauses, fmt.Sprintf(`"%s" IS NOT NULL`, config.YColumn.Name))
-	}
-
-	// フィルタがある場合は追加
-	if filter != nil {
-		filterWhere := filter.GenerateWhereClause(a)
-		if filterWhere != "" {
-			whereClauses = append(whereClauses, filterWhere)
-		}
-	}
-
-	whereClause := ""
-	if len(whereClauses) > 0 {
-		whereClause = "WHERE " + strings.Join(whereClauses, " AND ")
-	}
-
-	return fmt.Sprintf(`
-		SELECT
-			%s as x_value,
-			%s as y_value,
-			COUNT(*) as count,
-			ROUND(COUNT(*) * 100.0 / SUM(COUNT(*)) OVER(PARTITION BY %s), 1) as percentage
-		FROM %s
-		%s
-		GROUP BY %s, %s
-		ORDER BY %s, count DESC
-	`,
-		xExpr,
-		yExpr,
-		xExpr,
-		a.Table,
-		whereClause,
-		xExpr,
-		yExpr,
-		xExpr,
-	)
+// crosstabAxis はクロス集計の軸（X・Y・Z）
+type crosstabAxis struct {
+	key    string // 軸の名前（x, y, z）
+	column *Column
+	split  bool // 複数回答として分割するか
 }
 
-// buildMultiAnswerCrosstabQuery は複数回答のクロス集計のSQLを生成
-func (a *Analyzer) buildMultiAnswerCrosstabQuery(config AnalysisConfig, filter *Filter) string {
-	// X軸のSQL式を取得
-	var xExpr string
-	if config.SplitX {
-		if config.XColumn.IsDerived && config.XColumn.IsMulti {
-			// merge派生列の場合は '|||' で分割
-			xExpr = fmt.Sprintf(`unnest(string_split(%s, '|||'))`, config.XColumn.GetSQLExpression())
-		} else if !config.XColumn.IsDerived {
-			// 通常列の場合は改行で分割
-			xExpr = fmt.Sprintf(`unnest(string_split("%s", CHR(10)))`, config.XColumn.Name)
+// buildCrosstabQuery はクロス集計のSQLを生成
+// 派生列の式にはバインド引数が含まれるため、軸の値はCTEで1度だけ評価してからGROUP BYする
+// 分割する軸は軸ごとに unnest で展開するため、複数の軸を分割しても回答の全ての組み合わせを集計する
+// 層（Z軸）がある場合は、層ごとに割合を集計して先頭の列に z_value を出力する
+// 割合は行%（X値内）・列%（Y値内）・全体%の3つの基準で集計する
+// ウェイト列が指定されている場合は、件数と合わせてウェイト付きの件数・割合も集計する
+func (a *Analyzer) buildCrosstabQuery(config AnalysisConfig, filter *Filter) Expr {
+	axes := []crosstabAxis{
+		{key: "x", column: config.XColumn, split: config.SplitX},
+		{key: "y", column: config.YColumn, split: config.SplitY},
+	}
+	if config.ZColumn != nil {
+		axes = append(axes, crosstabAxis{key: "z", column: config.ZColumn, split: config.SplitZ})
+	}
+
+	var conditions, valueConditions, rawSelects, valueSelects []Expr
+	sources := []Expr{NewExpr("source_data")}
+	for _, axis := range axes {
+		raw := axis.key + "_raw"
+		value := axis.key + "_value"
+
+		// WHERE句の構築（派生列のNULLは値を評価した後に除外する）
+		conditions = append(conditions, notNullCondition(axis.column))
+		rawSelects = append(rawSelects, Exprf("%s as "+raw, axis.column.GetSQLExpression()))
+
+		if axis.split {
+			alias := "split_" + axis.key
+			sources = append(sources, Exprf("unnest(string_split("+raw+", %s)) AS "+alias+"("+value+")", splitSeparator(axis.column)))
+			valueSelects = append(valueSelects, NewExpr(alias+"."+value))
 		} else {
-			xExpr = config.XColumn.GetSQLExpression()
+			valueSelects = append(valueSelects, NewExpr(raw+" as "+value))
+			// 派生列の式はNULLになる場合がある（分割する軸はNULLが展開されないため不要）
+			valueConditions = append(valueConditions, derivedNotNullCondition(axis.column, NewExpr(raw)))
 		}
-	} else {
-		xExpr = config.XColumn.GetSQLExpression()
 	}
+	conditions = append(conditions, filterCondition(a, filter))
 
-	// Y軸のSQL式を取得
-	var yExpr string
-	if config.SplitY {
-		if config.YColumn.IsDerived && config.YColumn.IsMulti {
-			// merge派生列の場合は '|||' で分割
-			yExpr = fmt.Sprintf(`unnest(string_split(%s, '|||'))`, config.YColumn.GetSQLExpression())
-		} else if !config.YColumn.IsDerived {
-			// 通常列の場合は改行で分割
-			yExpr = fmt.Sprintf(`unnest(string_split("%s", CHR(10)))`, config.YColumn.Name)
-		} else {
-			yExpr = config.YColumn.GetSQLExpression()
-		}
-	} else {
-		yExpr = config.YColumn.GetSQLExpression()
+	// 層ごとに集計する場合は、割合の分母とGROUP BYに z_value を加える
+	layer := ""
+	totalPartition := ""
+	if config.ZColumn != nil {
+		layer = "z_value, "
+		totalPartition = "PARTITION BY z_value"
 	}
 
-	// WHERE句の構築（派生列の場合は不要）
-	var whereClauses []string
-	if !config.XColumn.IsDerived {
-		whereClauses = append(whereClauses, fmt.Sprintf(`"%s" IS NOT NULL`, config.XColumn.Name))
-	}
-	if !config.YColumn.IsDerived {
-		whereClauses = append(whereClauses, fmt.Sprintf(`"%s" IS NOT NULL`, config.YColumn.Name))
-	}
-
-	// フィルタがある場合は追加
-	if filter != nil {
-		filterWhere := filter.GenerateWhereClause(a)
-		if filterWhere != "" {
-			whereClauses = append(whereClauses, filterWhere)
-		}
-	}
-
-	whereClause := ""
-	if len(whereClauses) > 0 {
-		whereClause = "WHERE " + strings.Join(whereClauses, " AND ")
-	}
-
-	return fmt.Sprintf(`
-		WITH split_data AS (
+	sql := `
+		WITH source_data AS (
 			SELECT
-				%s as x_value,
-				%s as y_value
+				%s,
+				%s as weight
+			FROM %s
+			%s
+		),
+		split_data AS (
+			SELECT %s, weight
 			FROM %s
 			%s
 		)
 		SELECT
-			x_value,
+			{layer}x_value,
 			y_value,
 			COUNT(*) as count,
-			ROUND(COUNT(*) * 100.0 / SUM(COUNT(*)) OVER(PARTITION BY x_value), 1) as percentage
+			ROUND(COUNT(*) * 100.0 / SUM(COUNT(*)) OVER(PARTITION BY {layer}x_value), 1) as percentage,
+			ROUND(COUNT(*) * 100.0 / SUM(COUNT(*)) OVER(PARTITION BY {layer}y_value), 1) as column_percentage,
+			ROUND(COUNT(*) * 100.0 / SUM(COUNT(*)) OVER({total}), 1) as total_percentage,
+			SUM(weight) as weighted_count,
+			COALESCE(ROUND(SUM(weight) * 100.0 / NULLIF(SUM(SUM(weight)) OVER(PARTITION BY {layer}x_value), 0), 1), 0) as weighted_percentage,
+			COALESCE(ROUND(SUM(weight) * 100.0 / NULLIF(SUM(SUM(weight)) OVER(PARTITION BY {layer}y_value), 0), 1), 0) as weighted_column_percentage,
+			COALESCE(ROUND(SUM(weight) * 100.0 / NULLIF(SUM(SUM(weight)) OVER({total}), 0), 1), 0) as weighted_total_percentage
 		FROM split_data
-		GROUP BY x_value, y_value
-		ORDER BY x_value, count DESC
-	`,
-		xExpr,
-		yExpr,
-		a.Table,
-		whereClause,
+		GROUP BY {layer}x_value, y_value
+		ORDER BY {layer}x_value, count DESC
+	`
+	sql = strings.NewReplacer("{layer}", layer, "{total}", totalPartition).Replace(sql)
+
+	return Exprf(sql,
+		JoinExprs(rawSelects, ",\n\t\t\t\t"),
+		weightExpression(config.WeightColumn),
+		a.tableExpression(),
+		whereClause(conditions),
+		JoinExprs(valueSelects, ", "),
+		JoinExprs(sources, ", "),
+		whereClause(valueConditions),
 	)
 }
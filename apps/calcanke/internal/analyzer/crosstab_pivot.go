@@ -1,6 +1,9 @@
 package analyzer
 
-import "sort"
+import (
+	"math"
+	"sort"
+)
 
 // ToPivot はクロス集計結果をピボット表形式に変換する
 func (r *CrosstabResult) ToPivot() *CrosstabPivot {
@@ -14,6 +17,12 @@ func (r *CrosstabResult) ToPivotWithAnalyzer(a *Analyzer) *CrosstabPivot {
 		YColumn: r.YColumn,
 		Matrix:  make(map[string]map[string]CrosstabCell),
 		Total:   r.Total,
+
+		WeightColumn:  r.WeightColumn,
+		WeightedTotal: r.WeightedTotal,
+
+		PercentageBase: r.PercentageBase.OrDefault(),
+		Split:          r.Split,
 	}
 
 	// X値とY値のユニークリストを作成
@@ -49,14 +58,84 @@ func (r *CrosstabResult) ToPivotWithAnalyzer(a *Analyzer) *CrosstabPivot {
 		}
 	}
 
-	// データを埋める
+	// データを埋める（割合は選択された基準のもの）
 	for _, row := range r.Rows {
 		pivot.Matrix[row.XValue][row.YValue] = CrosstabCell{
-			Count:      row.Count,
-			Percentage: row.Percentage,
-			Exists:     true,
+			Count:              row.Count,
+			Percentage:         row.PercentageFor(pivot.PercentageBase),
+			WeightedCount:      row.WeightedCount,
+			WeightedPercentage: row.WeightedPercentageFor(pivot.PercentageBase),
+			Exists:             true,
 		}
 	}
 
+	// 周辺度数（全体行・全体列）
+	pivot.calculateTotals()
+
+	// 独立性の検定と調整済み残差
+	// 複数回答を分割した表は1人が複数のセルに数えられ、観測が独立でないため検定しない
+	if !pivot.Split {
+		pivot.CalculateSignificance()
+	}
+
 	return pivot
 }
+
+// calculateTotals はX値ごと・Y値ごとの合計と総数を、割合の基準に合わせて計算する
+// 行%ではX値ごとの合計が100%、列%ではY値ごとの合計が100%となり、
+// もう一方の合計は総数に対する割合（全体の構成比）となる
+func (p *CrosstabPivot) calculateTotals() {
+	p.RowTotals = make(map[string]CrosstabCell)
+	p.ColumnTotals = make(map[string]CrosstabCell)
+
+	for _, x := range p.XValues {
+		for _, y := range p.YValues {
+			cell := p.Matrix[x][y]
+			rowTotal := p.RowTotals[x]
+			rowTotal.Count += cell.Count
+			rowTotal.WeightedCount += cell.WeightedCount
+			p.RowTotals[x] = rowTotal
+
+			columnTotal := p.ColumnTotals[y]
+			columnTotal.Count += cell.Count
+			columnTotal.WeightedCount += cell.WeightedCount
+			p.ColumnTotals[y] = columnTotal
+		}
+	}
+
+	for x, total := range p.RowTotals {
+		total.Exists = true
+		total.Percentage = percentage(float64(total.Count), float64(p.Total))
+		total.WeightedPercentage = percentage(total.WeightedCount, p.WeightedTotal)
+		if p.PercentageBase == PercentageBaseRow {
+			total.Percentage, total.WeightedPercentage = 100, 100
+		}
+		p.RowTotals[x] = total
+	}
+
+	for y, total := range p.ColumnTotals {
+		total.Exists = true
+		total.Percentage = percentage(float64(total.Count), float64(p.Total))
+		total.WeightedPercentage = percentage(total.WeightedCount, p.WeightedTotal)
+		if p.PercentageBase == PercentageBaseColumn {
+			total.Percentage, total.WeightedPercentage = 100, 100
+		}
+		p.ColumnTotals[y] = total
+	}
+
+	p.GrandTotal = CrosstabCell{
+		Count:              p.Total,
+		Percentage:         100,
+		WeightedCount:      p.WeightedTotal,
+		WeightedPercentage: 100,
+		Exists:             true,
+	}
+}
+
+// percentage は割合（0〜100、小数第1位で丸め）を計算する
+func percentage(value, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(value*1000/total) / 10
+}
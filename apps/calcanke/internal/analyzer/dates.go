@@ -0,0 +1,423 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 日付の正規化（date_normalize）と、生年月日から求める学年・学校種別の設定
+const (
+	defaultDateFormat         = "%Y-%m-%d" // 正規化した日付の書式（DuckDBのstrftime）
+	defaultBirthdateColumn    = "生年月日"
+	defaultTargetYear         = 2025
+	defaultSchoolYearCutoff   = "04-01" // この月日までに生まれた人は前の年度の学年になる（日本の学校は4月1日）
+	defaultUnparsedDatesLimit = 30      // 日付の解析結果で表示する解析できなかった値の数
+	excelEpoch                = "1899-12-30"
+)
+
+// 学年の計算で使うラベル
+const (
+	gradeBelowLabel   = "小1未満"
+	gradeNoDataLabel  = "データなし"
+	gradeInvalidLabel = "データ不正"
+)
+
+// 日付の書式の正規表現（全角の数字・記号は半角にしてから判定する）
+// 年月日の区切りは / - . 年月日 のいずれでもよく、後ろに時刻が続いてもよい
+const (
+	ymdDatePattern  = `^([0-9]{4})\s*[/.年-]\s*([0-9]{1,2})\s*[/.月-]\s*([0-9]{1,2})\s*日?(?:[\sT].*)?$`
+	eraDatePattern  = `^(明治|大正|昭和|平成|令和|[MTSHR])\s*(元|[0-9]{1,2})\s*[/.年-]\s*([0-9]{1,2})\s*[/.月-]\s*([0-9]{1,2})\s*日?(?:\s.*)?$`
+	compactPattern  = `^[0-9]{8}$`              // YYYYMMDD
+	excelDayPattern = `^[0-9]{5}(?:\.[0-9]+)?$` // Excelのシリアル値（1927年〜2173年）
+	cutoffPattern   = `^(0[1-9]|1[0-2])-(0[1-9]|[12][0-9]|3[01])$`
+)
+
+// japaneseEras は和暦の元号と元年の西暦（アルファベットは H22.4.1 のような略記）
+var japaneseEras = []struct {
+	Names     []string
+	FirstYear int
+}{
+	{[]string{"令和", "R"}, 2019},
+	{[]string{"平成", "H"}, 1989},
+	{[]string{"昭和", "S"}, 1926},
+	{[]string{"大正", "T"}, 1912},
+	{[]string{"明治", "M"}, 1868},
+}
+
+// dateTextReplacer は全角の数字・記号を半角にする（SQLのtranslateと同じ変換）
+var dateTextReplacer = strings.NewReplacer(
+	"０", "0", "１", "1", "２", "2", "３", "3", "４", "4",
+	"５", "5", "６", "6", "７", "7", "８", "8", "９", "9",
+	"／", "/", "－", "-", "．", ".", "　", " ",
+)
+
+var (
+	ymdDateRegexp = regexp.MustCompile(ymdDatePattern)
+	eraDateRegexp = regexp.MustCompile(eraDatePattern)
+	compactRegexp = regexp.MustCompile(compactPattern)
+	excelDayRegex = regexp.MustCompile(excelDayPattern)
+	cutoffRegexp  = regexp.MustCompile(cutoffPattern)
+)
+
+// schoolLevel は学校段階（小学校・中学校など）と学年の数
+type schoolLevel struct {
+	Key          string // パラメータ up_to・labels のキー
+	Prefix       string // 学年のラベルの接頭辞（小1・中1など）
+	Years        int
+	DefaultLabel string // 学校種別のラベルの既定値
+	OverLabel    string // この段階までで計算する場合の、最後の学年より上のラベル
+}
+
+var schoolLevels = []schoolLevel{
+	{Key: "elementary", Prefix: "小", Years: 6, DefaultLabel: "小学生", OverLabel: "中1以上"},
+	{Key: "junior_high", Prefix: "中", Years: 3, DefaultLabel: "中学生", OverLabel: "高1以上"},
+	{Key: "high_school", Prefix: "高", Years: 3, DefaultLabel: "高校生", OverLabel: "大1以上"},
+	{Key: "university", Prefix: "大", Years: 4, DefaultLabel: "大学生", OverLabel: "大学卒業以上"},
+}
+
+// DateParseReport は日付の解析結果
+type DateParseReport struct {
+	Column             string          `json:"column"`
+	SourceColumn       string          `json:"source_column"`
+	Values             int             `json:"values"`   // 元の列が空でない行数
+	Parsed             int             `json:"parsed"`   // 日付として解析できた行数
+	Unparsed           int             `json:"unparsed"` // 日付として解析できなかった行数
+	ParsedPercentage   float64         `json:"parsed_percentage"`
+	UnparsedPercentage float64         `json:"unparsed_percentage"`
+	MinDate            string          `json:"min_date"` // 解析できた日付の最小値（YYYY-MM-DD、なければ空）
+	MaxDate            string          `json:"max_date"` // 解析できた日付の最大値
+	UnparsedValues     []UnparsedValue `json:"unparsed_values"`
+}
+
+// UnparsedValue は日付として解析できなかった値とその件数
+type UnparsedValue struct {
+	Text  string `json:"text"`
+	Count int    `json:"count"`
+}
+
+// ParseDate は日付の文字列を解析する（派生列のSQLと同じ書式に対応）
+// YYYYMMDD、YYYY/MM/DD・YYYY-MM-DD・YYYY年MM月DD日（時刻付きも可）、和暦（平成22年4月1日・H22.4.1）、Excelのシリアル値
+func ParseDate(text string) (time.Time, bool) {
+	s := strings.TrimSpace(dateTextReplacer.Replace(text))
+
+	var year, month, day int
+	switch {
+	case compactRegexp.MatchString(s):
+		year, _ = strconv.Atoi(s[:4])
+		month, _ = strconv.Atoi(s[4:6])
+		day, _ = strconv.Atoi(s[6:])
+	case ymdDateRegexp.MatchString(s):
+		m := ymdDateRegexp.FindStringSubmatch(s)
+		year, _ = strconv.Atoi(m[1])
+		month, _ = strconv.Atoi(m[2])
+		day, _ = strconv.Atoi(m[3])
+	case excelDayRegex.MatchString(s):
+		serial, _ := strconv.ParseFloat(s, 64)
+		epoch, _ := time.Parse("2006-01-02", excelEpoch)
+		return epoch.AddDate(0, 0, int(serial)), true
+	case eraDateRegexp.MatchString(strings.ToUpper(s)):
+		m := eraDateRegexp.FindStringSubmatch(strings.ToUpper(s))
+		n := 1
+		if m[2] != "元" {
+			n, _ = strconv.Atoi(m[2])
+		}
+		year = eraFirstYear(m[1]) + n - 1
+		month, _ = strconv.Atoi(m[3])
+		day, _ = strconv.Atoi(m[4])
+	default:
+		return time.Time{}, false
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Year() != year || int(date.Month()) != month || date.Day() != day {
+		return time.Time{}, false // 2月30日のような存在しない日付
+	}
+	return date, true
+}
+
+// eraFirstYear は元号の元年の西暦を返す
+func eraFirstYear(name string) int {
+	for _, era := range japaneseEras {
+		for _, n := range era.Names {
+			if n == name {
+				return era.FirstYear
+			}
+		}
+	}
+	return 0
+}
+
+// dateExpression は値を日付（DATE）に変換するSQL式を生成（解析できない値はNULL）
+// 正規表現はバインド引数ではなくリテラルで渡し、書式ごとの判定は整形した文字列をラムダで1度だけ評価する
+func dateExpression(value Expr) Expr {
+	text := Exprf("NULLIF(TRIM(upper(translate(CAST(%s AS VARCHAR), '０１２３４５６７８９／－．　', '0123456789/-. '))), '')", value)
+
+	var eras []Expr
+	for _, era := range japaneseEras {
+		var names []Expr
+		for _, name := range era.Names {
+			names = append(names, sqlStringLiteral(name))
+		}
+		eras = append(eras, Exprf("WHEN regexp_extract(s, %s, 1) IN (%s) THEN "+strconv.Itoa(era.FirstYear),
+			sqlStringLiteral(eraDatePattern), JoinExprs(names, ", ")))
+	}
+	ymdPattern := sqlStringLiteral(ymdDatePattern)
+	eraPattern := sqlStringLiteral(eraDatePattern)
+	eraYear := Exprf("CASE %s END + CASE regexp_extract(s, %s, 2) WHEN '元' THEN 1 ELSE TRY_CAST(regexp_extract(s, %s, 2) AS INTEGER) END - 1",
+		JoinExprs(eras, " "), eraPattern, eraPattern)
+	isoDate := NewExpr("'%Y-%m-%d'")
+
+	return Exprf(`list_transform([%s], s -> CASE
+		WHEN regexp_matches(s, %s) THEN CAST(try_strptime(s, '%Y%m%d') AS DATE)
+		WHEN regexp_matches(s, %s) THEN CAST(try_strptime(regexp_replace(s, %s, '\1-\2-\3'), %s) AS DATE)
+		WHEN regexp_matches(s, %s) THEN DATE '`+excelEpoch+`' + CAST(floor(CAST(s AS DOUBLE)) AS INTEGER)
+		WHEN regexp_matches(s, %s) THEN CAST(try_strptime(CAST(%s AS VARCHAR) || regexp_replace(s, %s, '-\3-\4'), %s) AS DATE)
+	END)[1]`,
+		text,
+		sqlStringLiteral(compactPattern),
+		ymdPattern, ymdPattern, isoDate,
+		sqlStringLiteral(excelDayPattern),
+		eraPattern, eraYear, eraPattern, isoDate,
+	)
+}
+
+// dateSourceColumn は日付の元の列を返す
+// パラメータ birthdate_column > ソース列の先頭 > 「生年月日」の順に使う
+func (dc *DerivedColumn) dateSourceColumn() string {
+	if col, ok := dc.Parameters["birthdate_column"].(string); ok && strings.TrimSpace(col) != "" {
+		return strings.TrimSpace(col)
+	}
+	if len(dc.SourceColumns) > 0 && dc.SourceColumns[0] != "" {
+		return dc.SourceColumns[0]
+	}
+	return defaultBirthdateColumn
+}
+
+// hasDateSource は日付を解析する派生列かどうかを返す
+func (dc *DerivedColumn) hasDateSource() bool {
+	switch dc.CalculationType {
+	case "date_normalize", "grade_from_birthdate", "school_type_from_birthdate", "age_from_birthdate":
+		return true
+	case "age_band":
+		return dc.ageSource() == AgeSourceBirthdate
+	}
+	return false
+}
+
+// dateFormat は正規化した日付の書式を返す（未指定の場合は YYYY-MM-DD）
+func (dc *DerivedColumn) dateFormat() string {
+	if format, ok := dc.Parameters["format"].(string); ok && format != "" {
+		return format
+	}
+	return defaultDateFormat
+}
+
+// generateDateNormalizeExpression はいろいろな書式の日付を1つの書式にそろえるSQL式を生成
+// 解析できない値はNULLになる（DateParseReportで確認できる）
+func (dc *DerivedColumn) generateDateNormalizeExpression() Expr {
+	return Exprf("strftime(%s, %s)", dateExpression(Ident(dc.dateSourceColumn())), Param(dc.dateFormat()))
+}
+
+// validateDateNormalize は日付の正規化の定義を確認する
+func (dc *DerivedColumn) validateDateNormalize() error {
+	if len(dc.SourceColumns) == 0 || strings.TrimSpace(dc.SourceColumns[0]) == "" {
+		return fmt.Errorf("date_normalize requires a source column")
+	}
+	if !strings.Contains(dc.dateFormat(), "%") {
+		return fmt.Errorf("invalid date format: %s", dc.dateFormat())
+	}
+	return nil
+}
+
+// targetYear は学年を計算する年度を返す（未指定の場合は2025）
+func (dc *DerivedColumn) targetYear() int {
+	if year, ok := numberParameter(dc.Parameters["target_year"]); ok && year > 0 {
+		return int(year)
+	}
+	return defaultTargetYear
+}
+
+// schoolYearCutoff は学年の区切りの月日（MM-DD）を返す
+func (dc *DerivedColumn) schoolYearCutoff() string {
+	if cutoff, ok := dc.Parameters["cutoff"].(string); ok && cutoff != "" {
+		return cutoff
+	}
+	return defaultSchoolYearCutoff
+}
+
+// schoolLevels は学年を計算する学校段階を返す（パラメータ up_to の段階まで、未指定の場合は中学校まで）
+func (dc *DerivedColumn) schoolLevels() []schoolLevel {
+	upTo, _ := dc.Parameters["up_to"].(string)
+	if upTo == "" {
+		upTo = "junior_high"
+	}
+	for i, level := range schoolLevels {
+		if level.Key == upTo {
+			return schoolLevels[:i+1]
+		}
+	}
+	return schoolLevels[:2]
+}
+
+// gradeExpression は生年月日から「小1を1とした通算の学年」を求めるSQL式を生成（解析できない場合はNULL）
+// 区切りの月日までに生まれた人は前の年に生まれた人と同じ学年になる
+func (dc *DerivedColumn) gradeExpression() Expr {
+	birth := dateExpression(Ident(dc.dateSourceColumn()))
+	return Exprf("list_transform([%s], birth -> %s - (year(birth) - CASE WHEN strftime(birth, '%m-%d') <= %s THEN 1 ELSE 0 END) - 6)[1]",
+		birth, Param(dc.targetYear()), Param(dc.schoolYearCutoff()))
+}
+
+// hasBirthdateValue は生年月日の列が空でない条件を返す
+func (dc *DerivedColumn) hasBirthdateValue() Expr {
+	return Exprf("NULLIF(TRIM(CAST(%s AS VARCHAR)), '') IS NOT NULL", Ident(dc.dateSourceColumn()))
+}
+
+// GradeLabels は学年のラベルを表示順に返す
+func (dc *DerivedColumn) GradeLabels() []string {
+	levels := dc.schoolLevels()
+	labels := []string{gradeBelowLabel}
+	for _, level := range levels {
+		for year := 1; year <= level.Years; year++ {
+			labels = append(labels, fmt.Sprintf("%s%d", level.Prefix, year))
+		}
+	}
+	return append(labels, levels[len(levels)-1].OverLabel, gradeNoDataLabel, gradeInvalidLabel)
+}
+
+// generateGradeCalculation は生年月日から学年を計算するSQL式を生成
+// 生年月日が空の場合は「データなし」、日付として解析できない場合は「データ不正」
+func (dc *DerivedColumn) generateGradeCalculation() Expr {
+	levels := dc.schoolLevels()
+	cases := []Expr{
+		Exprf("WHEN grade IS NULL THEN %s", Param(gradeInvalidLabel)),
+		Exprf("WHEN grade < 1 THEN %s", Param(gradeBelowLabel)),
+	}
+	grade := 0
+	for _, level := range levels {
+		for year := 1; year <= level.Years; year++ {
+			grade++
+			cases = append(cases, Exprf("WHEN grade = %s THEN %s", Param(grade), Param(fmt.Sprintf("%s%d", level.Prefix, year))))
+		}
+	}
+	over := Exprf("ELSE %s", Param(levels[len(levels)-1].OverLabel))
+
+	return Exprf("CASE WHEN %s THEN list_transform([%s], grade -> %s)[1] ELSE %s END",
+		dc.hasBirthdateValue(), dc.gradeExpression(), buildCaseExpression(cases, over), Param(gradeNoDataLabel))
+}
+
+// schoolTypeLabel は学校種別のラベルを返す（パラメータ labels で変更できる）
+func (dc *DerivedColumn) schoolTypeLabel(key, defaultLabel string) string {
+	if labels, ok := dc.Parameters["labels"].(map[string]interface{}); ok {
+		if label, ok := labels[key].(string); ok && label != "" {
+			return label
+		}
+	}
+	return defaultLabel
+}
+
+// SchoolTypeLabels は学校種別のラベルを表示順に返す
+func (dc *DerivedColumn) SchoolTypeLabels() []string {
+	var labels []string
+	for _, level := range dc.schoolLevels() {
+		labels = append(labels, dc.schoolTypeLabel(level.Key, level.DefaultLabel))
+	}
+	return append(labels, dc.schoolTypeLabel("other", "その他"))
+}
+
+// generateSchoolTypeCalculation は生年月日から学校種別を計算するSQL式を生成
+// 生年月日が空の場合はNULL、日付として解析できない場合や対象の学校段階の範囲外は「その他」
+func (dc *DerivedColumn) generateSchoolTypeCalculation() Expr {
+	var cases []Expr
+	first := 1
+	for _, level := range dc.schoolLevels() {
+		last := first + level.Years - 1
+		cases = append(cases, Exprf("WHEN grade BETWEEN %s AND %s THEN %s",
+			Param(first), Param(last), Param(dc.schoolTypeLabel(level.Key, level.DefaultLabel))))
+		first = last + 1
+	}
+	other := Exprf("ELSE %s", Param(dc.schoolTypeLabel("other", "その他")))
+
+	return Exprf("CASE WHEN %s THEN list_transform([%s], grade -> %s)[1] END",
+		dc.hasBirthdateValue(), dc.gradeExpression(), buildCaseExpression(cases, other))
+}
+
+// validateSchoolGrade は学年・学校種別の計算の定義を確認する
+func (dc *DerivedColumn) validateSchoolGrade() error {
+	if !cutoffRegexp.MatchString(dc.schoolYearCutoff()) {
+		return fmt.Errorf("invalid cutoff (expected MM-DD): %s", dc.schoolYearCutoff())
+	}
+	if upTo, ok := dc.Parameters["up_to"].(string); ok && upTo != "" {
+		for _, level := range schoolLevels {
+			if level.Key == upTo {
+				return nil
+			}
+		}
+		return fmt.Errorf("unsupported up_to: %s (supported: elementary, junior_high, high_school, university)", upTo)
+	}
+	return nil
+}
+
+// DateParseReport は日付を解析する派生列（日付の正規化・学年・学校種別・年齢）について、
+// 元の列が空でない行のうち日付として解析できた行数・できなかった行数と、解析できなかった値を集計する
+// limit は解析できなかった値を件数の多い順に返す数（0以下の場合は30）
+func (a *Analyzer) DateParseReport(name string, filter *Filter, limit int) (*DateParseReport, error) {
+	dc, exists := a.derivedColsMap[name]
+	if !exists || !dc.hasDateSource() {
+		return nil, fmt.Errorf("date derived column not found: %s", name)
+	}
+	if limit <= 0 {
+		limit = defaultUnparsedDatesLimit
+	}
+
+	source := Ident(dc.dateSourceColumn())
+	base := Exprf("WITH base AS (SELECT NULLIF(TRIM(CAST(%s AS VARCHAR)), '') AS raw, %s AS parsed FROM %s %s)",
+		source, dateExpression(source), a.tableExpression(), whereClause([]Expr{filterCondition(a, filter)}))
+
+	query := Exprf(`%s
+		SELECT COUNT(*), COUNT(parsed),
+			COALESCE(CAST(MIN(parsed) AS VARCHAR), ''), COALESCE(CAST(MAX(parsed) AS VARCHAR), '')
+		FROM base
+		WHERE raw IS NOT NULL`, base)
+
+	result := &DateParseReport{
+		Column:       dc.Name,
+		SourceColumn: dc.dateSourceColumn(),
+	}
+	if err := a.db.QueryRow(query.SQL, query.Args...).Scan(&result.Values, &result.Parsed, &result.MinDate, &result.MaxDate); err != nil {
+		return nil, fmt.Errorf("failed to execute date parse query: %w", err)
+	}
+	result.Unparsed = result.Values - result.Parsed
+	total := float64(result.Values)
+	result.ParsedPercentage = percentage(float64(result.Parsed), total)
+	result.UnparsedPercentage = percentage(float64(result.Unparsed), total)
+
+	unparsedQuery := Exprf(`%s
+		SELECT raw, COUNT(*) as count
+		FROM base
+		WHERE raw IS NOT NULL AND parsed IS NULL
+		GROUP BY raw
+		ORDER BY count DESC, raw
+		LIMIT %s`, base, Param(limit))
+
+	rows, err := a.db.Query(unparsedQuery.SQL, unparsedQuery.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute unparsed dates query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value UnparsedValue
+		if err := rows.Scan(&value.Text, &value.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		result.UnparsedValues = append(result.UnparsedValues, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return result, nil
+}
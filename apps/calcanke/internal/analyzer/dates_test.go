@@ -0,0 +1,80 @@
+package analyzer
+
+import (
+	"database/sql"
+	"testing"
+)
+
+// ParseDate と派生列のSQL（dateExpression）が同じ値を同じ日付に解析することを確かめる
+func TestParseDateMatchesDateExpression(t *testing.T) {
+	tests := []struct {
+		text string
+		want string // 解析できない場合は空
+	}{
+		// 西暦
+		{"2010/04/01", "2010-04-01"},
+		{"2010-4-1", "2010-04-01"},
+		{"2010.04.01", "2010-04-01"},
+		{"2010年4月1日", "2010-04-01"},
+		{"2010/04/01 12:34:56", "2010-04-01"},
+		{"2010-04-01T12:34:56", "2010-04-01"},
+		{" 2010/04/01 ", "2010-04-01"},
+		// 全角の数字・記号
+		{"２０１０／０４／０１", "2010-04-01"},
+		{"２０１０年４月１日", "2010-04-01"},
+		{"２０１０－０４－０１", "2010-04-01"},
+		// YYYYMMDD
+		{"20100401", "2010-04-01"},
+		{"２０１００４０１", "2010-04-01"},
+		// 和暦（元年・略記を含む）
+		{"平成22年4月1日", "2010-04-01"},
+		{"平成元年1月8日", "1989-01-08"},
+		{"令和元年5月1日", "2019-05-01"},
+		{"昭和64年1月7日", "1989-01-07"},
+		{"H22.4.1", "2010-04-01"},
+		{"h22.4.1", "2010-04-01"},
+		{"R2/3/4", "2020-03-04"},
+		// Excelのシリアル値
+		{"40269", "2010-04-01"},
+		{"40269.5", "2010-04-01"},
+		{"43831", "2020-01-01"},
+		// 存在しない日付
+		{"2010/02/30", ""},
+		{"20100230", ""},
+		{"2010/13/01", ""},
+		{"平成22年2月30日", ""},
+		// 日付ではない値
+		{"", ""},
+		{"不明", ""},
+		{"2010/04", ""},
+		{"1234", ""},
+		{"123456789", ""},
+	}
+
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got := ""
+			if date, ok := ParseDate(tt.text); ok {
+				got = date.Format("2006-01-02")
+			}
+			if got != tt.want {
+				t.Errorf("ParseDate(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+
+			query := Exprf("SELECT CAST(%s AS VARCHAR)", dateExpression(Param(tt.text)))
+			var sqlDate sql.NullString
+			if err := db.QueryRow(query.SQL, query.Args...).Scan(&sqlDate); err != nil {
+				t.Fatalf("dateExpression(%q): %v", tt.text, err)
+			}
+			if sqlDate.String != tt.want {
+				t.Errorf("dateExpression(%q) = %q, want %q", tt.text, sqlDate.String, tt.want)
+			}
+		})
+	}
+}
@@ -3,7 +3,6 @@ package analyzer
 import (
 	"fmt"
 	"os"
-	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -18,9 +17,20 @@ type DerivedColumn struct {
 	Name            string                 `yaml:"name" json:"name"`
 	Description     string                 `yaml:"description" json:"description"`
 	SourceColumns   []string               `yaml:"source_columns" json:"source_columns"`
-	CalculationType string                 `yaml:"calculation_type" json:"calculation_type"` // "rules" または "grade_from_birthdate"
-	Parameters      map[string]interface{} `yaml:"parameters" json:"parameters"`             // 計算パラメータ
-	Rules           []Rule                 `yaml:"rules" json:"rules"`                       // calculation_type="rules"の場合
+	CalculationType string                 `yaml:"calculation_type" json:"calculation_type"`         // "rules" または "grade_from_birthdate"
+	Parameters      map[string]interface{} `yaml:"parameters" json:"parameters"`                     // 計算パラメータ
+	Rules           []Rule                 `yaml:"rules" json:"rules"`                               // calculation_type="rules"の場合
+	Categories      []CodingCategory       `yaml:"categories,omitempty" json:"categories,omitempty"` // calculation_type="keyword_coding"の場合
+	Groups          []AddressGroup         `yaml:"groups,omitempty" json:"groups,omitempty"`         // calculation_type="address_region"の場合
+	Expression      string                 `yaml:"expression,omitempty" json:"expression,omitempty"` // calculation_type="sql_expression"の場合
+
+	// CheckedExpression はデータに対する確認を通った式（calculation_type="sql_expression"の場合）
+	// 保存時に確認した式を記録し、Expression と一致すれば読み込むたびに確認し直さない（APIからは指定できない）
+	CheckedExpression string `yaml:"checked_expression,omitempty" json:"-"`
+
+	binEdges         []float64 // データから計算した階級の境界値（calculation_type="binning"の場合）
+	binEdgesResolved bool      // 境界値の計算を済ませたか（計算できなかった場合も再計算しない）
+	expressionErr    error     // 使うときに確認して通らなかったSQL式のエラー（calculation_type="sql_expression"の場合）
 }
 
 // Rule は分類ルール
@@ -75,16 +85,54 @@ func SaveDerivedColumns(configPath string, columns []DerivedColumn) error {
 	return nil
 }
 
-// GenerateCaseExpression は派生列のSQL CASE式を生成
-func (dc *DerivedColumn) GenerateCaseExpression() string {
+// Validate は派生列の定義を保存する前に確認する
+func (dc *DerivedColumn) Validate() error {
+	if dc.Name == "" {
+		return fmt.Errorf("derived column name is required")
+	}
+	switch dc.CalculationType {
+	case "keyword_coding":
+		return dc.validateKeywordCoding()
+	case "address_region":
+		return dc.validateAddressRegion()
+	case "date_normalize":
+		return dc.validateDateNormalize()
+	case "grade_from_birthdate", "school_type_from_birthdate":
+		return dc.validateSchoolGrade()
+	case "age_from_birthdate":
+		return dc.validateAge()
+	case "age_band":
+		return dc.validateAgeBand()
+	case "sql_expression":
+		return dc.validateSQLExpression()
+	}
+	return nil
+}
+
+// GenerateCaseExpression は派生列のSQL CASE式（バインド引数付き）を生成
+func (dc *DerivedColumn) GenerateCaseExpression() Expr {
 	// calculation_typeに応じて処理を分岐
 	switch dc.CalculationType {
 	case "grade_from_birthdate":
 		return dc.generateGradeCalculation()
 	case "school_type_from_birthdate":
 		return dc.generateSchoolTypeCalculation()
+	case "date_normalize":
+		return dc.generateDateNormalizeExpression()
+	case "age_from_birthdate":
+		return dc.generateAgeExpression()
+	case "age_band":
+		return dc.generateAgeBandExpression()
 	case "merge":
 		return dc.generateMergeExpression()
+	case "binning":
+		return dc.generateBinningExpression()
+	case "keyword_coding":
+		return dc.generateKeywordCodingExpression()
+	case "address_region":
+		return dc.generateAddressRegionExpression()
+	case "sql_expression":
+		return dc.generateSQLExpression()
 	case "rules", "":
 		// デフォルトはルールベース
 		return dc.generateRuleBasedExpression()
@@ -94,13 +142,13 @@ func (dc *DerivedColumn) GenerateCaseExpression() string {
 }
 
 // generateRuleBasedExpression はルールベースのCASE式を生成
-func (dc *DerivedColumn) generateRuleBasedExpression() string {
+func (dc *DerivedColumn) generateRuleBasedExpression() Expr {
 	// ルールが空の場合はNULLを返す
 	if len(dc.Rules) == 0 {
-		return "NULL"
+		return NewExpr("NULL")
 	}
 
-	var cases []string
+	var cases []Expr
 
 	for _, rule := range dc.Rules {
 		// デフォルトルールはELSEとして最後に処理
@@ -109,249 +157,91 @@ func (dc *DerivedColumn) generateRuleBasedExpression() string {
 		}
 
 		// 条件を生成
-		var conditions []string
+		var conditions []Expr
 		for _, cond := range rule.Conditions {
-			condSQL := generateConditionSQL(cond)
-			if condSQL != "" {
-				conditions = append(conditions, condSQL)
+			condExpr := generateConditionSQL(cond)
+			if !condExpr.IsEmpty() {
+				conditions = append(conditions, condExpr)
 			}
 		}
 
 		// 条件が複数ある場合はANDで結合
-		var whenClause string
 		if len(conditions) > 0 {
-			whenClause = strings.Join(conditions, " AND ")
-			cases = append(cases, fmt.Sprintf("WHEN %s THEN '%s'", whenClause, rule.Label))
+			whenClause := JoinExprs(conditions, " AND ")
+			cases = append(cases, Exprf("WHEN %s THEN %s", whenClause, Param(rule.Label)))
 		}
 	}
 
 	// デフォルトルールを探す
-	var elseClause string
+	var elseClause Expr
 	for _, rule := range dc.Rules {
 		if rule.IsDefault {
-			elseClause = fmt.Sprintf("ELSE '%s'", rule.Label)
+			elseClause = Exprf("ELSE %s", Param(rule.Label))
 			break
 		}
 	}
 
 	// WHEN句がない場合もNULLを返す
-	if len(cases) == 0 && elseClause == "" {
-		return "NULL"
+	if len(cases) == 0 && elseClause.IsEmpty() {
+		return NewExpr("NULL")
 	}
 
 	// CASE式を組み立て
-	caseExpr := "CASE\n  " + strings.Join(cases, "\n  ") + "\n  " + elseClause + "\nEND"
-	return caseExpr
+	return buildCaseExpression(cases, elseClause)
 }
 
-// generateConditionSQL は条件からSQL文を生成
-func generateConditionSQL(cond Condition) string {
+// buildCaseExpression はWHEN句とELSE句からCASE式を組み立てる
+func buildCaseExpression(cases []Expr, elseClause Expr) Expr {
+	return Exprf("CASE\n  %s\n  %s\nEND", JoinExprs(cases, "\n  "), elseClause)
+}
+
+// generateConditionSQL は条件からSQL式（バインド引数付き）を生成
+func generateConditionSQL(cond Condition) Expr {
+	column := Ident(cond.Column)
+	trimmed := Exprf("TRIM(%s)", column)
+
 	switch cond.Operator {
 	case "equals":
-		return fmt.Sprintf(`TRIM("%s") = '%s'`, cond.Column, cond.Value)
+		return Exprf("%s = %s", trimmed, Param(cond.Value))
 
 	case "starts_with":
-		return fmt.Sprintf(`TRIM("%s") LIKE '%s%%'`, cond.Column, cond.Value)
+		return Exprf("starts_with(%s, %s)", trimmed, Param(cond.Value))
 
 	case "starts_with_any":
-		var conditions []string
+		var conditions []Expr
 		for _, val := range cond.Values {
-			conditions = append(conditions, fmt.Sprintf(`TRIM("%s") LIKE '%s%%'`, cond.Column, val))
+			conditions = append(conditions, Exprf("starts_with(%s, %s)", trimmed, Param(val)))
+		}
+		if len(conditions) == 0 {
+			return Expr{}
 		}
-		return "(" + strings.Join(conditions, " OR ") + ")"
+		return Exprf("(%s)", JoinExprs(conditions, " OR "))
 
 	case "contains":
-		return fmt.Sprintf(`TRIM("%s") LIKE '%%%s%%'`, cond.Column, cond.Value)
+		return Exprf("contains(%s, %s)", trimmed, Param(cond.Value))
 
 	case "between":
 		// Values[0]: 開始値, Values[1]: 終了値
 		if len(cond.Values) >= 2 {
-			return fmt.Sprintf(`"%s" >= '%s' AND "%s" <= '%s'`,
-				cond.Column, cond.Values[0], cond.Column, cond.Values[1])
+			return Exprf("(%s >= %s AND %s <= %s)",
+				column, Param(cond.Values[0]), column, Param(cond.Values[1]))
 		}
-		return ""
+		return Expr{}
 
 	case "in":
 		// 複数値のいずれかに一致（OR条件）
 		if len(cond.Values) > 0 {
-			quotedValues := make([]string, len(cond.Values))
-			for i, val := range cond.Values {
-				quotedValues[i] = fmt.Sprintf("'%s'", val)
-			}
-			return fmt.Sprintf(`"%s" IN (%s)`, cond.Column, strings.Join(quotedValues, ", "))
+			return Exprf("%s IN %s", column, ParamList(cond.Values))
 		}
-		return ""
+		return Expr{}
 
 	default:
-		return ""
+		return Expr{}
 	}
 }
 
-// generateGradeCalculation は生年月日から学年を計算するSQL式を生成
-func (dc *DerivedColumn) generateGradeCalculation() string {
-	// パラメータから対象年度を取得（デフォルトは2025）
-	targetYear := 2025
-	if year, ok := dc.Parameters["target_year"]; ok {
-		if y, ok := year.(int); ok {
-			targetYear = y
-		}
-	}
-
-	// 生年月日列名を取得
-	birthdateColumn := "生年月日"
-	if col, ok := dc.Parameters["birthdate_column"]; ok {
-		if c, ok := col.(string); ok {
-			birthdateColumn = c
-		}
-	}
-
-	// 各学年の生年月日範囲を計算
-	// 2025年度の小1 = 2018/04/02 〜 2019/04/01
-	// YYYYMMDD形式で比較
-	type GradeRange struct {
-		Label     string
-		StartDate string // YYYYMMDD形式（この日以降）
-		EndDate   string // YYYYMMDD形式（この日以前）
-	}
-
-	var ranges []GradeRange
-
-	// 小1〜小6
-	for grade := 1; grade <= 6; grade++ {
-		birthYear := targetYear - grade - 6 // 小1は7歳になる年度
-		startDate := fmt.Sprintf("%04d0402", birthYear)
-		endDate := fmt.Sprintf("%04d0401", birthYear+1)
-		ranges = append(ranges, GradeRange{
-			Label:     fmt.Sprintf("小%d", grade),
-			StartDate: startDate,
-			EndDate:   endDate,
-		})
-	}
-
-	// 中1〜中3
-	for grade := 1; grade <= 3; grade++ {
-		birthYear := targetYear - grade - 12 // 中1は13歳になる年度
-		startDate := fmt.Sprintf("%04d0402", birthYear)
-		endDate := fmt.Sprintf("%04d0401", birthYear+1)
-		ranges = append(ranges, GradeRange{
-			Label:     fmt.Sprintf("中%d", grade),
-			StartDate: startDate,
-			EndDate:   endDate,
-		})
-	}
-
-	// CASE式を構築
-	var cases []string
-
-	for _, r := range ranges {
-		cases = append(cases, fmt.Sprintf(
-			"WHEN \"%s\" >= '%s' AND \"%s\" <= '%s' THEN '%s'",
-			birthdateColumn, r.StartDate, birthdateColumn, r.EndDate, r.Label,
-		))
-	}
-
-	// 小1未満（最も新しい小1の範囲より後）
-	youngest := ranges[0] // 小1
-	cases = append(cases, fmt.Sprintf(
-		"WHEN \"%s\" > '%s' THEN '小1未満'",
-		birthdateColumn, youngest.EndDate,
-	))
-
-	// 高1以上（最も古い中3の範囲より前）
-	oldest := ranges[len(ranges)-1] // 中3
-	cases = append(cases, fmt.Sprintf(
-		"WHEN \"%s\" < '%s' THEN '高1以上'",
-		birthdateColumn, oldest.StartDate,
-	))
-
-	// NULL対応
-	cases = append(cases, fmt.Sprintf(
-		"WHEN \"%s\" IS NULL THEN 'データなし'",
-		birthdateColumn,
-	))
-
-	// CASE式を組み立て
-	caseExpr := "CASE\n  " + strings.Join(cases, "\n  ") + "\n  ELSE 'データ不正'\nEND"
-	return caseExpr
-}
-
-// generateSchoolTypeCalculation は生年月日から学校種別を計算するSQL式を生成
-func (dc *DerivedColumn) generateSchoolTypeCalculation() string {
-	// パラメータから対象年度を取得（デフォルトは2025）
-	targetYear := 2025
-	if year, ok := dc.Parameters["target_year"]; ok {
-		if y, ok := year.(int); ok {
-			targetYear = y
-		}
-	}
-
-	// 生年月日列名を取得
-	birthdateColumn := "生年月日"
-	if col, ok := dc.Parameters["birthdate_column"]; ok {
-		if c, ok := col.(string); ok {
-			birthdateColumn = c
-		}
-	}
-
-	// カスタムラベルを取得（オプション）
-	elementaryLabel := "小学生"
-	juniorHighLabel := "中学生"
-	otherLabel := "その他"
-
-	if labels, ok := dc.Parameters["labels"]; ok {
-		if labelMap, ok := labels.(map[string]interface{}); ok {
-			if el, ok := labelMap["elementary"].(string); ok {
-				elementaryLabel = el
-			}
-			if jh, ok := labelMap["junior_high"].(string); ok {
-				juniorHighLabel = jh
-			}
-			if ot, ok := labelMap["other"].(string); ok {
-				otherLabel = ot
-			}
-		}
-	}
-
-	// 小学校の範囲を計算（小1〜小6）
-	// 小1は7歳になる年度、小6は12歳になる年度
-	// 小1: targetYear - 1 - 6 = targetYear - 7 歳の生年月日
-	// 小6: targetYear - 6 - 6 = targetYear - 12 歳の生年月日
-	elemStart := fmt.Sprintf("%04d0402", targetYear-12) // 小6の開始日（最も古い）
-	elemEnd := fmt.Sprintf("%04d0401", targetYear-6)    // 小1の終了日（最も新しい）
-
-	// 中学校の範囲を計算（中1〜中3）
-	// 中1は13歳になる年度、中3は15歳になる年度
-	juniorStart := fmt.Sprintf("%04d0402", targetYear-15) // 中3の開始日（最も古い）
-	juniorEnd := fmt.Sprintf("%04d0401", targetYear-12)   // 中1の終了日（最も新しい）
-
-	// CASE式を構築
-	var cases []string
-
-	// 小学生
-	cases = append(cases, fmt.Sprintf(
-		"WHEN \"%s\" >= '%s' AND \"%s\" <= '%s' THEN '%s'",
-		birthdateColumn, elemStart, birthdateColumn, elemEnd, elementaryLabel,
-	))
-
-	// 中学生
-	cases = append(cases, fmt.Sprintf(
-		"WHEN \"%s\" >= '%s' AND \"%s\" <= '%s' THEN '%s'",
-		birthdateColumn, juniorStart, birthdateColumn, juniorEnd, juniorHighLabel,
-	))
-
-	// NULL対応
-	cases = append(cases, fmt.Sprintf(
-		"WHEN \"%s\" IS NULL THEN NULL",
-		birthdateColumn,
-	))
-
-	// CASE式を組み立て
-	caseExpr := fmt.Sprintf("CASE\n  %s\n  ELSE '%s'\nEND", strings.Join(cases, "\n  "), otherLabel)
-	return caseExpr
-}
-
 // generateMergeExpression は複数列を結合するSQL式を生成
-func (dc *DerivedColumn) generateMergeExpression() string {
+func (dc *DerivedColumn) generateMergeExpression() Expr {
 	// パラメータからセパレータを取得（デフォルトは"|||"）
 	separator := "|||"
 	if sep, ok := dc.Parameters["separator"]; ok {
@@ -362,28 +252,26 @@ func (dc *DerivedColumn) generateMergeExpression() string {
 
 	// ソース列が指定されていない場合はエラー
 	if len(dc.SourceColumns) == 0 {
-		return "NULL"
+		return NewExpr("NULL")
 	}
 
 	// 各列をNULLIF(TRIM(列), '')でラップして空文字を除外
-	var columns []string
+	var columns []Expr
 	for _, colName := range dc.SourceColumns {
-		columns = append(columns, fmt.Sprintf(`NULLIF(TRIM("%s"), '')`, colName))
+		columns = append(columns, Exprf("NULLIF(TRIM(%s), '')", Ident(colName)))
 	}
 
 	// CONCAT_WSで結合（NULLは自動的にスキップされる）
 	// 結果が空文字列の場合はNULLに変換
-	expr := fmt.Sprintf("NULLIF(TRIM(CONCAT_WS('%s', %s)), '')",
-		separator,
-		strings.Join(columns, ", "))
-
-	return expr
+	return Exprf("NULLIF(TRIM(CONCAT_WS(%s, %s)), '')", Param(separator), JoinExprs(columns, ", "))
 }
 
 // GetDerivedColumn は派生列を仮想的なColumnとして返す
 func (dc *DerivedColumn) GetDerivedColumn(index int) Column {
-	// mergeタイプの場合は複数回答として扱う
-	isMulti := dc.CalculationType == "merge"
+	// merge・keyword_codingタイプの場合は複数回答として扱う
+	isMulti := dc.CalculationType == "merge" || dc.CalculationType == "keyword_coding"
+
+	expr := dc.GenerateCaseExpression()
 
 	return Column{
 		Index:     index,
@@ -391,6 +279,7 @@ func (dc *DerivedColumn) GetDerivedColumn(index int) Column {
 		Type:      "VARCHAR (派生列)",
 		IsMulti:   isMulti,
 		IsDerived: true,
-		SQLExpr:   dc.GenerateCaseExpression(),
+		SQLExpr:   expr.SQL,
+		SQLArgs:   expr.Args,
 	}
 }
@@ -3,6 +3,8 @@ package analyzer
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -20,13 +22,35 @@ type Filter struct {
 	Conditions  []FilterCondition `yaml:"conditions" json:"conditions"`
 }
 
-// FilterCondition はフィルタ条件
+// FilterCondition はフィルタ条件（条件の木の1つのノード）
+// 列の条件（Column と include_values・exclude_values、または Operator）か、
+// 条件のグループ（And・Or・Not のいずれか1つ）のどちらかを指定する
 type FilterCondition struct {
-	Column        string   `yaml:"column" json:"column"`
-	IncludeValues []string `yaml:"include_values" json:"include_values"` // この値のみ含む
-	ExcludeValues []string `yaml:"exclude_values" json:"exclude_values"` // この値を除外
+	Column        string   `yaml:"column,omitempty" json:"column,omitempty"`
+	IncludeValues []string `yaml:"include_values,omitempty" json:"include_values,omitempty"` // この値のみ含む
+	ExcludeValues []string `yaml:"exclude_values,omitempty" json:"exclude_values,omitempty"` // この値を除外
+	Operator      string   `yaml:"operator,omitempty" json:"operator,omitempty"`             // 範囲・部分一致などの比較（FilterOp*）
+	Value         string   `yaml:"value,omitempty" json:"value,omitempty"`                   // gt・gte・lt・lte・regex の値
+	Values        []string `yaml:"values,omitempty" json:"values,omitempty"`                 // between の [下限, 上限]、contains の語、has_any の回答
+
+	And []FilterCondition `yaml:"and,omitempty" json:"and,omitempty"` // すべての条件に当てはまる
+	Or  []FilterCondition `yaml:"or,omitempty" json:"or,omitempty"`   // いずれかの条件に当てはまる
+	Not *FilterCondition  `yaml:"not,omitempty" json:"not,omitempty"` // 条件に当てはまらない
 }
 
+// フィルタ条件の比較（operator）
+const (
+	FilterOpGreater      = "gt"       // より大きい（数値・日付）
+	FilterOpGreaterEqual = "gte"      // 以上（数値・日付）
+	FilterOpLess         = "lt"       // より小さい（数値・日付）
+	FilterOpLessEqual    = "lte"      // 以下（数値・日付）
+	FilterOpBetween      = "between"  // 下限以上・上限以下（数値・日付）
+	FilterOpContains     = "contains" // いずれかの語を含む
+	FilterOpRegex        = "regex"    // 正規表現に一致する
+	FilterOpEmpty        = "is_empty" // 空欄
+	FilterOpHasAny       = "has_any"  // 複数回答を分割した回答のいずれかを選んだ
+)
+
 // LoadFilters は設定ファイルからフィルタを読み込む
 func LoadFilters(configPath string) ([]Filter, error) {
 	data, err := os.ReadFile(configPath)
@@ -65,64 +89,327 @@ func SaveFilters(configPath string, filters []Filter) error {
 	return nil
 }
 
-// GenerateWhereClause はフィルタからSQL WHERE句を生成
-func (f *Filter) GenerateWhereClause(analyzer *Analyzer) string {
-	var whereClauses []string
-
-	for _, cond := range f.Conditions {
-		// 列が派生列かどうか確認
-		column := findColumnByName(analyzer, cond.Column)
-		if column == nil {
-			continue
+// Validate はフィルタの定義を保存する前に確認する
+func (f *Filter) Validate() error {
+	if f.Name == "" {
+		return fmt.Errorf("filter name is required")
+	}
+	for i := range f.Conditions {
+		if err := f.Conditions[i].Validate(); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		colExpr := column.GetSQLExpression()
+// Validate はフィルタ条件（グループの場合は中の条件も）を確認する
+func (cond *FilterCondition) Validate() error {
+	groups := 0
+	if len(cond.And) > 0 {
+		groups++
+	}
+	if len(cond.Or) > 0 {
+		groups++
+	}
+	if cond.Not != nil {
+		groups++
+	}
 
-		// include_values がある場合
-		if len(cond.IncludeValues) > 0 {
-			quotedValues := make([]string, len(cond.IncludeValues))
-			for i, val := range cond.IncludeValues {
-				quotedValues[i] = fmt.Sprintf("'%s'", val)
+	if groups > 0 {
+		if groups > 1 || cond.Column != "" {
+			return fmt.Errorf("filter condition must be one of and, or, not or a column condition")
+		}
+		for i := range cond.And {
+			if err := cond.And[i].Validate(); err != nil {
+				return err
 			}
-			whereClauses = append(whereClauses, fmt.Sprintf(
-				"%s IN (%s)",
-				colExpr,
-				strings.Join(quotedValues, ", "),
-			))
-		}
-
-		// exclude_values がある場合
-		if len(cond.ExcludeValues) > 0 {
-			quotedValues := make([]string, len(cond.ExcludeValues))
-			for i, val := range cond.ExcludeValues {
-				quotedValues[i] = fmt.Sprintf("'%s'", val)
+		}
+		for i := range cond.Or {
+			if err := cond.Or[i].Validate(); err != nil {
+				return err
 			}
-			whereClauses = append(whereClauses, fmt.Sprintf(
-				"%s NOT IN (%s)",
-				colExpr,
-				strings.Join(quotedValues, ", "),
-			))
 		}
+		if cond.Not != nil {
+			return cond.Not.Validate()
+		}
+		return nil
 	}
 
-	if len(whereClauses) == 0 {
-		return ""
+	if cond.Column == "" {
+		return fmt.Errorf("filter condition requires a column")
 	}
 
-	return strings.Join(whereClauses, " AND ")
+	switch cond.Operator {
+	case "":
+		if len(cond.IncludeValues) == 0 && len(cond.ExcludeValues) == 0 {
+			return fmt.Errorf("filter condition on %s requires include_values, exclude_values or an operator", cond.Column)
+		}
+	case FilterOpGreater, FilterOpGreaterEqual, FilterOpLess, FilterOpLessEqual:
+		if _, err := filterRangeKind([]string{cond.Value}); err != nil {
+			return fmt.Errorf("filter condition on %s: %w", cond.Column, err)
+		}
+	case FilterOpBetween:
+		if len(cond.Values) != 2 {
+			return fmt.Errorf("filter condition on %s: between requires values [min, max]", cond.Column)
+		}
+		if _, err := filterRangeKind(cond.Values); err != nil {
+			return fmt.Errorf("filter condition on %s: %w", cond.Column, err)
+		}
+	case FilterOpContains, FilterOpHasAny:
+		if len(cond.Values) == 0 {
+			return fmt.Errorf("filter condition on %s: %s requires values", cond.Column, cond.Operator)
+		}
+	case FilterOpRegex:
+		if cond.Value == "" {
+			return fmt.Errorf("filter condition on %s: regex requires a value", cond.Column)
+		}
+		if _, err := regexp.Compile(cond.Value); err != nil {
+			return fmt.Errorf("filter condition on %s: invalid regex: %w", cond.Column, err)
+		}
+	case FilterOpEmpty:
+	default:
+		return fmt.Errorf("unsupported filter operator: %s (supported: gt, gte, lt, lte, between, contains, regex, is_empty, has_any)", cond.Operator)
+	}
+	return nil
 }
 
-// findColumnByName は列名からColumnを検索
-func findColumnByName(analyzer *Analyzer, name string) *Column {
+// GenerateWhereClause はフィルタからSQL WHERE句の条件式（バインド引数付き）を生成
+// conditions はすべて満たす行（AND）を残す。存在しない列の条件は無視する
+func (f *Filter) GenerateWhereClause(analyzer *Analyzer) Expr {
 	columns, err := analyzer.GetColumns()
 	if err != nil {
-		return nil
+		return Expr{}
 	}
-
+	columnsByName := make(map[string]*Column, len(columns))
 	for i := range columns {
-		if columns[i].Name == name {
-			return &columns[i]
+		columnsByName[columns[i].Name] = &columns[i]
+	}
+
+	return joinFilterConditions(f.Conditions, columnsByName, " AND ")
+}
+
+// joinFilterConditions は条件の式を区切り（AND・OR）で結合する（条件がなければ空）
+func joinFilterConditions(conditions []FilterCondition, columns map[string]*Column, sep string) Expr {
+	var exprs []Expr
+	for i := range conditions {
+		if expr := conditions[i].expression(columns); !expr.IsEmpty() {
+			exprs = append(exprs, expr)
 		}
 	}
-	return nil
+
+	switch len(exprs) {
+	case 0:
+		return Expr{}
+	case 1:
+		return exprs[0]
+	default:
+		return Exprf("(%s)", JoinExprs(exprs, sep))
+	}
+}
+
+// expression はフィルタ条件の式を生成する（条件がない場合は空）
+// 列の値がNULLで比較できない条件は「当てはまらない」とし、NOTでは当てはまる側にする
+func (cond *FilterCondition) expression(columns map[string]*Column) Expr {
+	switch {
+	case len(cond.And) > 0:
+		return joinFilterConditions(cond.And, columns, " AND ")
+	case len(cond.Or) > 0:
+		return joinFilterConditions(cond.Or, columns, " OR ")
+	case cond.Not != nil:
+		inner := cond.Not.expression(columns)
+		if inner.IsEmpty() {
+			return Expr{}
+		}
+		return Exprf("NOT %s", inner)
+	}
+
+	column, ok := columns[cond.Column]
+	if !ok {
+		return Expr{}
+	}
+	colExpr := column.GetSQLExpression()
+
+	var exprs []Expr
+	if len(cond.IncludeValues) > 0 {
+		exprs = append(exprs, Exprf("%s IN %s", colExpr, ParamList(cond.IncludeValues)))
+	}
+	if len(cond.ExcludeValues) > 0 {
+		exprs = append(exprs, Exprf("%s NOT IN %s", colExpr, ParamList(cond.ExcludeValues)))
+	}
+	if cond.Operator != "" {
+		if expr := cond.operatorExpression(column); !expr.IsEmpty() {
+			exprs = append(exprs, expr)
+		}
+	}
+	if len(exprs) == 0 {
+		return Expr{}
+	}
+
+	return Exprf("COALESCE(%s, FALSE)", JoinExprs(exprs, " AND "))
+}
+
+// operatorExpression は範囲・部分一致などの比較の式を生成する（値が不正な場合は空）
+func (cond *FilterCondition) operatorExpression(column *Column) Expr {
+	colExpr := column.GetSQLExpression()
+	text := Exprf("CAST(%s AS VARCHAR)", colExpr)
+
+	switch cond.Operator {
+	case FilterOpGreater, FilterOpGreaterEqual, FilterOpLess, FilterOpLessEqual:
+		comparisons := map[string]string{
+			FilterOpGreater:      ">",
+			FilterOpGreaterEqual: ">=",
+			FilterOpLess:         "<",
+			FilterOpLessEqual:    "<=",
+		}
+		value, bound, ok := filterRangeOperands(colExpr, []string{cond.Value})
+		if !ok {
+			return Expr{}
+		}
+		return Exprf("%s "+comparisons[cond.Operator]+" %s", value, bound[0])
+
+	case FilterOpBetween:
+		if len(cond.Values) != 2 {
+			return Expr{}
+		}
+		value, bounds, ok := filterRangeOperands(colExpr, cond.Values)
+		if !ok {
+			return Expr{}
+		}
+		return Exprf("%s BETWEEN %s AND %s", value, bounds[0], bounds[1])
+
+	case FilterOpContains:
+		var matches []Expr
+		for _, v := range cond.Values {
+			matches = append(matches, Exprf("contains(%s, %s)", text, Param(v)))
+		}
+		if len(matches) == 0 {
+			return Expr{}
+		}
+		return Exprf("(%s)", JoinExprs(matches, " OR "))
+
+	case FilterOpRegex:
+		return Exprf("regexp_matches(%s, %s)", text, Param(cond.Value))
+
+	case FilterOpEmpty:
+		return Exprf("(%s IS NULL OR TRIM(%s) = '')", colExpr, text)
+
+	case FilterOpHasAny:
+		if len(cond.Values) == 0 {
+			return Expr{}
+		}
+		values := make([]Expr, len(cond.Values))
+		for i, v := range cond.Values {
+			values[i] = Param(v)
+		}
+		return Exprf("list_has_any(string_split(%s, %s), [%s])", text, splitSeparator(column), JoinExprs(values, ", "))
+	}
+	return Expr{}
+}
+
+// DescribeFilterConditions はフィルタ条件（ANDで結合）を表示用の文にする
+func DescribeFilterConditions(conditions []FilterCondition) string {
+	return joinFilterConditionStrings(conditions, "、")
+}
+
+// String はフィルタ条件を表示用の文にする（例: 学年 = 小5・小6、NOT (エリア分類 = その他)）
+func (cond *FilterCondition) String() string {
+	switch {
+	case len(cond.And) > 0:
+		return "(" + joinFilterConditionStrings(cond.And, " かつ ") + ")"
+	case len(cond.Or) > 0:
+		return "(" + joinFilterConditionStrings(cond.Or, " または ") + ")"
+	case cond.Not != nil:
+		return "NOT " + cond.Not.String()
+	}
+
+	var parts []string
+	if len(cond.IncludeValues) > 0 {
+		parts = append(parts, fmt.Sprintf("%s = %s", cond.Column, strings.Join(cond.IncludeValues, "・")))
+	}
+	if len(cond.ExcludeValues) > 0 {
+		parts = append(parts, fmt.Sprintf("%s ≠ %s", cond.Column, strings.Join(cond.ExcludeValues, "・")))
+	}
+	switch cond.Operator {
+	case FilterOpGreater:
+		parts = append(parts, fmt.Sprintf("%s > %s", cond.Column, cond.Value))
+	case FilterOpGreaterEqual:
+		parts = append(parts, fmt.Sprintf("%s ≧ %s", cond.Column, cond.Value))
+	case FilterOpLess:
+		parts = append(parts, fmt.Sprintf("%s < %s", cond.Column, cond.Value))
+	case FilterOpLessEqual:
+		parts = append(parts, fmt.Sprintf("%s ≦ %s", cond.Column, cond.Value))
+	case FilterOpBetween:
+		parts = append(parts, fmt.Sprintf("%s %s", cond.Column, strings.Join(cond.Values, "〜")))
+	case FilterOpContains:
+		parts = append(parts, fmt.Sprintf("%s に「%s」を含む", cond.Column, strings.Join(cond.Values, "」「")))
+	case FilterOpRegex:
+		parts = append(parts, fmt.Sprintf("%s ~ /%s/", cond.Column, cond.Value))
+	case FilterOpEmpty:
+		parts = append(parts, fmt.Sprintf("%s が空欄", cond.Column))
+	case FilterOpHasAny:
+		parts = append(parts, fmt.Sprintf("%s ∋ %s", cond.Column, strings.Join(cond.Values, "・")))
+	}
+	return strings.Join(parts, " かつ ")
+}
+
+// joinFilterConditionStrings はフィルタ条件の表示用の文を区切りで結合する
+func joinFilterConditionStrings(conditions []FilterCondition, sep string) string {
+	parts := make([]string, len(conditions))
+	for i := range conditions {
+		parts[i] = conditions[i].String()
+	}
+	return strings.Join(parts, sep)
+}
+
+// 範囲の比較の値の種類
+const (
+	filterRangeNumber = "number"
+	filterRangeDate   = "date"
+)
+
+// filterRangeKind は範囲の比較の値がすべて数値か、すべて日付かを判定する
+func filterRangeKind(values []string) (string, error) {
+	numbers, dates := true, true
+	for _, v := range values {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err != nil {
+			numbers = false
+		}
+		if _, ok := ParseDate(v); !ok {
+			dates = false
+		}
+	}
+	switch {
+	case len(values) == 0:
+		return "", fmt.Errorf("range requires a value")
+	case numbers:
+		return filterRangeNumber, nil
+	case dates:
+		return filterRangeDate, nil
+	default:
+		return "", fmt.Errorf("range values must be numbers or dates: %s", strings.Join(values, ", "))
+	}
+}
+
+// filterRangeOperands は範囲の比較の列の値と境界の値の式を返す
+// 数値は列の値をDOUBLEに、日付は列の値を date_normalize と同じ規則でDATEにして比べる
+func filterRangeOperands(colExpr Expr, values []string) (Expr, []Expr, bool) {
+	kind, err := filterRangeKind(values)
+	if err != nil {
+		return Expr{}, nil, false
+	}
+
+	var bounds []Expr
+	if kind == filterRangeNumber {
+		for _, v := range values {
+			f, _ := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			bounds = append(bounds, Param(f))
+		}
+		return Exprf("TRY_CAST(%s AS DOUBLE)", colExpr), bounds, true
+	}
+
+	for _, v := range values {
+		date, _ := ParseDate(v)
+		bounds = append(bounds, Exprf("CAST(%s AS DATE)", Param(date.Format("2006-01-02"))))
+	}
+	return dateExpression(colExpr), bounds, true
 }
@@ -0,0 +1,107 @@
+package analyzer
+
+import "fmt"
+
+// FilterComparisonResult はフィルタ比較の結果
+// 同じ列の単純集計をフィルタごとの列に並べ、割合は列ごとの回答者数（n）を基準にする
+type FilterComparisonResult struct {
+	Column       string
+	Filter       *Filter                  // すべての列に共通して適用したフィルタ（nilの場合はなし）
+	Values       []string                 // 値（表示順、いずれかの列に現れた値）
+	Columns      []FilterComparisonColumn // フィルタごとの列（指定した順）
+	WeightColumn string                   // ウェイト列（空の場合はウェイトなし）
+	Split        bool                     // 複数回答を分割して集計したか
+}
+
+// FilterComparisonColumn はフィルタ比較の1つの列（1つのフィルタでの単純集計）
+type FilterComparisonColumn struct {
+	Name        string // フィルタ名（フィルタなしの列は「全体」）
+	Description string
+	Result      *SimpletabResult
+}
+
+// filterComparisonTotalName はフィルタなしの列の名前
+const filterComparisonTotalName = "全体"
+
+// IsWeighted はウェイト付きの集計かどうかを返す
+func (r *FilterComparisonResult) IsWeighted() bool {
+	return r.WeightColumn != ""
+}
+
+// IsSplit は複数回答を分割した集計かどうかを返す
+func (r *FilterComparisonResult) IsSplit() bool {
+	return r.Split
+}
+
+// Cell は値の行を返す（その列に現れない値は0件の行）
+func (c FilterComparisonColumn) Cell(value string) SimpletabRow {
+	for _, row := range c.Result.Rows {
+		if row.Value == value {
+			return row
+		}
+	}
+	return SimpletabRow{Value: value}
+}
+
+// CompareFilters はフィルタごとに同じ列の単純集計を実行し、1つの表にまとめる
+// filters の nil はフィルタなし（全体）の列。base を指定した場合は、各列のフィルタとの両方の条件で集計する
+// 値は列の値の表示順序に従い、順序がない場合は最初の列の順（件数順）に、後の列にだけ現れる値を続ける
+func (a *Analyzer) CompareFilters(column *Column, split bool, filters []*Filter, base *Filter, weight *Column) (*FilterComparisonResult, error) {
+	if len(filters) < 2 {
+		return nil, fmt.Errorf("filter comparison requires at least two filters")
+	}
+
+	result := &FilterComparisonResult{Column: column.Name, Filter: base}
+	if weight != nil {
+		result.WeightColumn = weight.Name
+	}
+
+	seen := make(map[string]bool)
+	for _, filter := range filters {
+		tab, err := a.SimpletabWithWeight(column, split, intersectFilters(base, filter), weight)
+		if err != nil {
+			name := filterComparisonTotalName
+			if filter != nil {
+				name = filter.Name
+			}
+			return nil, fmt.Errorf("failed to tabulate %s: %w", name, err)
+		}
+		result.Split = tab.Split
+
+		col := FilterComparisonColumn{Name: filterComparisonTotalName, Result: tab}
+		if filter != nil {
+			col.Name = filter.Name
+			col.Description = filter.Description
+		}
+		result.Columns = append(result.Columns, col)
+
+		for _, row := range tab.Rows {
+			if !seen[row.Value] {
+				seen[row.Value] = true
+				result.Values = append(result.Values, row.Value)
+			}
+		}
+	}
+
+	if orderMap := a.GetValueOrder(column.Name); len(orderMap) > 0 {
+		sortByOrder(result.Values, orderMap)
+	}
+
+	return result, nil
+}
+
+// intersectFilters は両方のフィルタの条件を満たす行に絞り込むフィルタを返す（どちらかがnilの場合はもう一方）
+// 名前と説明は filter のものを使う
+func intersectFilters(base, filter *Filter) *Filter {
+	if base == nil {
+		return filter
+	}
+	if filter == nil {
+		return base
+	}
+	return &Filter{
+		Name:        filter.Name,
+		Description: filter.Description,
+		Conditions:  append(append([]FilterCondition{}, base.Conditions...), filter.Conditions...),
+	}
+}
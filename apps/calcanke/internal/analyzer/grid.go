@@ -0,0 +1,216 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+)
+
+// GridConfig はマトリクス設問の集計設定
+type GridConfig struct {
+	Group        QuestionGroup
+	BannerColumn *Column // クロス集計するバナーの列（nilの場合は全体のみ）
+	SplitBanner  bool    // バナーの列を複数回答として分割するか
+	Filter       *Filter
+	WeightColumn *Column
+}
+
+// GridCell はマトリクス設問の表の1セル（項目×選択肢）
+// 割合はその行（項目、バナーの値）の回答数に対する割合
+type GridCell struct {
+	Count              int
+	Percentage         float64
+	WeightedCount      float64 // ウェイト付きの件数（ウェイトなしの場合はCountと同じ）
+	WeightedPercentage float64
+}
+
+// GridRow はマトリクス設問の表の1行
+type GridRow struct {
+	Item          string     // 項目の表示名
+	Column        string     // 項目の列名
+	Banner        string     // バナーの値（項目全体の行は空）
+	Total         int        // 項目（バナーの値）の回答数
+	WeightedTotal float64    // ウェイト付きの回答数
+	Cells         []GridCell // 選択肢（GridResult.Values）の順
+}
+
+// IsItemTotal は項目全体の行（バナーの値ごとでない行）かどうかを返す
+func (r GridRow) IsItemTotal() bool {
+	return r.Banner == ""
+}
+
+// GridResult はマトリクス設問の集計結果（行: 項目、列: 選択肢）
+// バナーを指定した場合は、項目ごとに全体の行とバナーの値ごとの行を続ける
+type GridResult struct {
+	Group        string
+	Description  string
+	BannerColumn string   // バナーの列（全体のみの場合は空）
+	WeightColumn string   // ウェイト列（空ならウェイトなし）
+	Values       []string // 選択肢（表示順）
+	Rows         []GridRow
+}
+
+// IsGrouped はバナーでクロス集計した結果かどうかを返す
+func (r *GridResult) IsGrouped() bool {
+	return r.BannerColumn != ""
+}
+
+// IsWeighted はウェイト付きの結果かどうかを返す
+func (r *GridResult) IsWeighted() bool {
+	return r.WeightColumn != ""
+}
+
+// gridCounts は1行分の選択肢ごとの件数
+type gridCounts struct {
+	counts   map[string]int
+	weighted map[string]float64
+}
+
+// Grid はマトリクス設問の項目ごとに選択肢の件数と割合を集計する
+// BannerColumn を指定した場合は、項目ごとにバナーの値ごとの行も集計する
+func (a *Analyzer) Grid(config GridConfig) (*GridResult, error) {
+	group := config.Group
+	if len(group.Columns) == 0 {
+		return nil, fmt.Errorf("question group %s has no columns", group.Name)
+	}
+
+	columns, err := a.GetColumns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GridResult{
+		Group:       group.Name,
+		Description: group.Description,
+	}
+	if config.BannerColumn != nil {
+		result.BannerColumn = config.BannerColumn.Name
+	}
+	if config.WeightColumn != nil {
+		result.WeightColumn = config.WeightColumn.Name
+	}
+
+	// 項目ごとに全体とバナーの値ごとの件数を集計
+	totals := make([]gridCounts, len(group.Columns))
+	byBanner := make([]map[string]gridCounts, len(group.Columns))
+	seenValues := make(map[string]bool)
+	seenBanners := make(map[string]bool)
+	var banners []string
+
+	for i, name := range group.Columns {
+		column := columns.FindByName(name)
+		if column == nil {
+			return nil, fmt.Errorf("column %s in question group %s not found", name, group.Name)
+		}
+
+		simple, err := a.SimpletabWithWeight(column, false, config.Filter, config.WeightColumn)
+		if err != nil {
+			return nil, err
+		}
+		totals[i] = newGridCounts()
+		for _, row := range simple.Rows {
+			totals[i].add(row.Value, row.Count, row.WeightedCount)
+			seenValues[row.Value] = true
+		}
+
+		if config.BannerColumn == nil {
+			continue
+		}
+
+		crosstab, err := a.CrosstabWithFilter(AnalysisConfig{
+			XColumn:      config.BannerColumn,
+			YColumn:      column,
+			SplitX:       config.SplitBanner,
+			WeightColumn: config.WeightColumn,
+		}, config.Filter)
+		if err != nil {
+			return nil, err
+		}
+		byBanner[i] = make(map[string]gridCounts)
+		for _, row := range crosstab.Rows {
+			counts, exists := byBanner[i][row.XValue]
+			if !exists {
+				counts = newGridCounts()
+				byBanner[i][row.XValue] = counts
+			}
+			counts.add(row.YValue, row.Count, row.WeightedCount)
+			seenValues[row.YValue] = true
+			if !seenBanners[row.XValue] {
+				seenBanners[row.XValue] = true
+				banners = append(banners, row.XValue)
+			}
+		}
+	}
+
+	result.Values = a.gridValues(group, seenValues)
+	if config.BannerColumn != nil {
+		sortByOrder(banners, a.GetValueOrder(config.BannerColumn.Name))
+	}
+
+	labels := group.ItemLabels()
+	for i, name := range group.Columns {
+		result.Rows = append(result.Rows, result.newRow(labels[i], name, "", totals[i]))
+		for _, banner := range banners {
+			counts, exists := byBanner[i][banner]
+			if !exists {
+				counts = newGridCounts()
+			}
+			result.Rows = append(result.Rows, result.newRow(labels[i], name, banner, counts))
+		}
+	}
+
+	return result, nil
+}
+
+// gridValues は表の列にする選択肢を表示順に返す
+// 設問グループの values（なければ先頭の列の表示順）の値を先に並べ、それ以外の回答の値を続ける
+func (a *Analyzer) gridValues(group QuestionGroup, seen map[string]bool) []string {
+	values := append([]string{}, group.Values...)
+	if len(values) == 0 {
+		if order, exists := a.columnOrdersMap[group.Columns[0]]; exists {
+			values = append(values, order.Values...)
+		}
+	}
+
+	known := make(map[string]bool)
+	for _, v := range values {
+		known[v] = true
+	}
+	var others []string
+	for v := range seen {
+		if !known[v] {
+			others = append(others, v)
+		}
+	}
+	sort.Strings(others)
+
+	return append(values, others...)
+}
+
+// newRow は選択肢ごとの件数から表の1行を作成
+func (r *GridResult) newRow(item, column, banner string, counts gridCounts) GridRow {
+	row := GridRow{Item: item, Column: column, Banner: banner}
+	for _, v := range r.Values {
+		row.Total += counts.counts[v]
+		row.WeightedTotal += counts.weighted[v]
+	}
+	for _, v := range r.Values {
+		row.Cells = append(row.Cells, GridCell{
+			Count:              counts.counts[v],
+			Percentage:         percentage(float64(counts.counts[v]), float64(row.Total)),
+			WeightedCount:      counts.weighted[v],
+			WeightedPercentage: percentage(counts.weighted[v], row.WeightedTotal),
+		})
+	}
+	return row
+}
+
+// newGridCounts は空の件数を作成
+func newGridCounts() gridCounts {
+	return gridCounts{counts: make(map[string]int), weighted: make(map[string]float64)}
+}
+
+// add は選択肢の件数を加算する
+func (c gridCounts) add(value string, count int, weighted float64) {
+	c.counts[value] += count
+	c.weighted[value] += weighted
+}
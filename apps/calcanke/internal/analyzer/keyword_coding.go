@@ -0,0 +1,271 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// キーワードによるコーディング（keyword_coding）の設定
+const (
+	codingSeparator            = "|||" // 複数のカテゴリを結合するセパレータ（複数回答の派生列と同じ）
+	defaultUncodedAnswersLimit = 30    // カバー率で表示するコードなしの回答の数
+)
+
+// CodingCategory は自由回答をコーディングするカテゴリ（calculation_type="keyword_coding"の場合）
+// any・all・regex のいずれかに当てはまる回答をこのカテゴリに分類する
+// キーワードは英字の大文字・小文字を区別しない
+type CodingCategory struct {
+	Label string   `yaml:"label" json:"label"`
+	Any   []string `yaml:"any,omitempty" json:"any,omitempty"`     // いずれかのキーワードを含む
+	All   []string `yaml:"all,omitempty" json:"all,omitempty"`     // 全てのキーワードを含む
+	Regex []string `yaml:"regex,omitempty" json:"regex,omitempty"` // いずれかの正規表現に一致する
+}
+
+// CodingCoverage はキーワードによるコーディングのカバー率
+type CodingCoverage struct {
+	Column            string                `json:"column"`
+	SourceColumn      string                `json:"source_column"`
+	Respondents       int                   `json:"respondents"` // 自由回答が空でない回答者数
+	Coded             int                   `json:"coded"`       // いずれかのカテゴリに分類された回答者数
+	Uncoded           int                   `json:"uncoded"`     // どのカテゴリにも分類されなかった回答者数
+	CodedPercentage   float64               `json:"coded_percentage"`
+	UncodedPercentage float64               `json:"uncoded_percentage"`
+	Categories        []CodingCategoryCount `json:"categories"`
+	UncodedAnswers    []UncodedAnswer       `json:"uncoded_answers"` // コードなしの回答（件数の多い順）
+}
+
+// CodingCategoryCount はカテゴリごとの回答者数
+type CodingCategoryCount struct {
+	Label      string  `json:"label"`
+	Count      int     `json:"count"`
+	Percentage float64 `json:"percentage"` // 自由回答が空でない回答者数に対する割合
+}
+
+// UncodedAnswer はどのカテゴリにも分類されなかった回答とその件数
+type UncodedAnswer struct {
+	Text  string `json:"text"`
+	Count int    `json:"count"`
+}
+
+// uncodedLabel はどのカテゴリにも分類されなかった回答のラベルを返す（未指定の場合は空でNULLとする）
+func (dc *DerivedColumn) uncodedLabel() string {
+	if label, ok := dc.Parameters["uncoded_label"].(string); ok {
+		return strings.TrimSpace(label)
+	}
+	return ""
+}
+
+// CodingLabels はカテゴリのラベルを定義順に返す（コードなしのラベルがあれば末尾に加える）
+func (dc *DerivedColumn) CodingLabels() []string {
+	var labels []string
+	for _, category := range dc.Categories {
+		labels = append(labels, category.Label)
+	}
+	if uncoded := dc.uncodedLabel(); uncoded != "" {
+		labels = append(labels, uncoded)
+	}
+	return labels
+}
+
+// codingTextExpression はコーディングする自由回答の列を文字列にする式を返す（空白だけの回答はNULL）
+func (dc *DerivedColumn) codingTextExpression() Expr {
+	return Exprf("NULLIF(TRIM(CAST(%s AS VARCHAR)), '')", Ident(dc.SourceColumns[0]))
+}
+
+// condition は回答がカテゴリに当てはまる条件を返す（キーワードがない場合は空）
+func (cc CodingCategory) condition(text Expr) Expr {
+	lowered := Exprf("lower(%s)", text)
+	var alternatives []Expr
+
+	for _, keyword := range cleanKeywords(cc.Any) {
+		alternatives = append(alternatives, Exprf("contains(%s, %s)", lowered, Param(strings.ToLower(keyword))))
+	}
+
+	var all []Expr
+	for _, keyword := range cleanKeywords(cc.All) {
+		all = append(all, Exprf("contains(%s, %s)", lowered, Param(strings.ToLower(keyword))))
+	}
+	if len(all) > 0 {
+		alternatives = append(alternatives, Exprf("(%s)", JoinExprs(all, " AND ")))
+	}
+
+	for _, pattern := range cleanKeywords(cc.Regex) {
+		alternatives = append(alternatives, Exprf("regexp_matches(%s, %s)", text, Param(pattern)))
+	}
+
+	if len(alternatives) == 0 {
+		return Expr{}
+	}
+	return Exprf("(%s)", JoinExprs(alternatives, " OR "))
+}
+
+// cleanKeywords は前後の空白を除き、空のキーワードを除く
+func cleanKeywords(keywords []string) []string {
+	var result []string
+	for _, k := range keywords {
+		if k = strings.TrimSpace(k); k != "" {
+			result = append(result, k)
+		}
+	}
+	return result
+}
+
+// codingConditions はキーワードのあるカテゴリのラベルと条件を返す
+func (dc *DerivedColumn) codingConditions(text Expr) ([]string, []Expr) {
+	var labels []string
+	var conditions []Expr
+	for _, category := range dc.Categories {
+		condition := category.condition(text)
+		if condition.IsEmpty() {
+			continue
+		}
+		labels = append(labels, category.Label)
+		conditions = append(conditions, condition)
+	}
+	return labels, conditions
+}
+
+// generateKeywordCodingExpression は自由回答をキーワードでカテゴリに分類するSQL式を生成
+// 当てはまるカテゴリが複数ある場合は "|||" で結合する（複数回答の派生列として扱う）
+// 空の回答はNULL、どのカテゴリにも当てはまらない回答は uncoded_label（未指定の場合はNULL）
+func (dc *DerivedColumn) generateKeywordCodingExpression() Expr {
+	if len(dc.SourceColumns) == 0 {
+		return NewExpr("NULL")
+	}
+	text := dc.codingTextExpression()
+	labels, conditions := dc.codingConditions(text)
+	if len(conditions) == 0 {
+		return NewExpr("NULL")
+	}
+
+	codes := make([]Expr, len(conditions))
+	for i, condition := range conditions {
+		codes[i] = Exprf("CASE WHEN %s THEN %s END", condition, Param(labels[i]))
+	}
+	coded := Exprf("NULLIF(CONCAT_WS(%s, %s), '')", Param(codingSeparator), JoinExprs(codes, ", "))
+	if uncoded := dc.uncodedLabel(); uncoded != "" {
+		coded = Exprf("COALESCE(%s, %s)", coded, Param(uncoded))
+	}
+
+	return Exprf("CASE WHEN %s IS NULL THEN NULL ELSE %s END", text, coded)
+}
+
+// validateKeywordCoding はキーワードによるコーディングの定義を確認する
+func (dc *DerivedColumn) validateKeywordCoding() error {
+	if len(dc.SourceColumns) != 1 || strings.TrimSpace(dc.SourceColumns[0]) == "" {
+		return fmt.Errorf("keyword_coding requires exactly one source column")
+	}
+	if len(dc.Categories) == 0 {
+		return fmt.Errorf("keyword_coding requires at least one category")
+	}
+
+	seen := make(map[string]bool)
+	for _, category := range dc.Categories {
+		label := strings.TrimSpace(category.Label)
+		if label == "" {
+			return fmt.Errorf("category label is required")
+		}
+		if strings.Contains(label, codingSeparator) {
+			return fmt.Errorf("category label must not contain %q: %s", codingSeparator, label)
+		}
+		if seen[label] {
+			return fmt.Errorf("duplicate category label: %s", label)
+		}
+		seen[label] = true
+
+		if len(cleanKeywords(category.Any))+len(cleanKeywords(category.All))+len(cleanKeywords(category.Regex)) == 0 {
+			return fmt.Errorf("category %s has no keywords", label)
+		}
+		// DuckDBの正規表現もRE2のため、Goの regexp で構文を確認できる
+		for _, pattern := range cleanKeywords(category.Regex) {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("invalid regex in category %s: %w", label, err)
+			}
+		}
+	}
+	return nil
+}
+
+// KeywordCodingCoverage はキーワードによるコーディングの派生列について、
+// 自由回答が空でない回答者のうちカテゴリに分類された人数・分類されなかった人数と、分類されなかった回答を集計する
+// limit はコードなしの回答を件数の多い順に返す数（0以下の場合は30）
+func (a *Analyzer) KeywordCodingCoverage(name string, filter *Filter, limit int) (*CodingCoverage, error) {
+	dc, exists := a.derivedColsMap[name]
+	if !exists || dc.CalculationType != "keyword_coding" {
+		return nil, fmt.Errorf("keyword_coding derived column not found: %s", name)
+	}
+	if err := dc.validateKeywordCoding(); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultUncodedAnswersLimit
+	}
+
+	answer := NewExpr("answer")
+	labels, conditions := dc.codingConditions(answer)
+	coded := Exprf("(%s)", JoinExprs(conditions, " OR "))
+	base := Exprf("WITH base AS (SELECT %s AS answer FROM %s %s)",
+		dc.codingTextExpression(), a.tableExpression(), whereClause([]Expr{filterCondition(a, filter)}))
+
+	counts := []Expr{NewExpr("COUNT(*)"), Exprf("COUNT(*) FILTER (WHERE %s)", coded)}
+	for _, condition := range conditions {
+		counts = append(counts, Exprf("COUNT(*) FILTER (WHERE %s)", condition))
+	}
+	query := Exprf("%s SELECT %s FROM base WHERE answer IS NOT NULL", base, JoinExprs(counts, ", "))
+
+	values := make([]int, len(counts))
+	dest := make([]interface{}, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := a.db.QueryRow(query.SQL, query.Args...).Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to execute coverage query: %w", err)
+	}
+
+	result := &CodingCoverage{
+		Column:       dc.Name,
+		SourceColumn: dc.SourceColumns[0],
+		Respondents:  values[0],
+		Coded:        values[1],
+		Uncoded:      values[0] - values[1],
+	}
+	total := float64(result.Respondents)
+	result.CodedPercentage = percentage(float64(result.Coded), total)
+	result.UncodedPercentage = percentage(float64(result.Uncoded), total)
+	for i, label := range labels {
+		count := values[i+2]
+		result.Categories = append(result.Categories, CodingCategoryCount{
+			Label:      label,
+			Count:      count,
+			Percentage: percentage(float64(count), total),
+		})
+	}
+
+	uncodedQuery := Exprf(`%s
+		SELECT answer, COUNT(*) as count
+		FROM base
+		WHERE answer IS NOT NULL AND NOT %s
+		GROUP BY answer
+		ORDER BY count DESC, answer
+		LIMIT %s`, base, coded, Param(limit))
+
+	rows, err := a.db.Query(uncodedQuery.SQL, uncodedQuery.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute uncoded answers query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var answer UncodedAnswer
+		if err := rows.Scan(&answer.Text, &answer.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		result.UncodedAnswers = append(result.UncodedAnswers, answer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return result, nil
+}
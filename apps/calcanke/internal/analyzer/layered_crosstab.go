@@ -0,0 +1,61 @@
+package analyzer
+
+import "fmt"
+
+// LayeredCrosstabResult は層別（3重）クロス集計の結果
+// 層で分けない全体の表と、層（Z軸）の値ごとの表を持つ
+type LayeredCrosstabResult struct {
+	XColumn string
+	YColumn string
+	ZColumn string
+	Overall *CrosstabPivot  // 全体（層で分けない表）
+	Layers  []CrosstabLayer // 層の値ごとの表（値の表示順）
+}
+
+// CrosstabLayer は層別クロス集計の1つの層
+type CrosstabLayer struct {
+	Value string
+	Pivot *CrosstabPivot
+}
+
+// LayeredCrosstab は層（ZColumn）の値ごとにクロス集計を実行
+// 複数回答の分割はX・Y・Zのどの軸にも指定でき、フィルタは全体と各層に適用する
+func (a *Analyzer) LayeredCrosstab(config AnalysisConfig, filter *Filter) (*LayeredCrosstabResult, error) {
+	if config.ZColumn == nil {
+		return nil, fmt.Errorf("layered crosstab requires a layer column")
+	}
+	config = normalizeSplit(config)
+
+	// 全体の表
+	overall, err := a.CrosstabWithFilter(config, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// 層ごとの表
+	rowsByLayer, err := a.queryCrosstab(config, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, 0, len(rowsByLayer))
+	for value := range rowsByLayer {
+		values = append(values, value)
+	}
+	sortByOrder(values, a.GetValueOrder(config.ZColumn.Name))
+
+	result := &LayeredCrosstabResult{
+		XColumn: config.XColumn.Name,
+		YColumn: config.YColumn.Name,
+		ZColumn: config.ZColumn.Name,
+		Overall: overall.ToPivotWithAnalyzer(a),
+	}
+	for _, value := range values {
+		result.Layers = append(result.Layers, CrosstabLayer{
+			Value: value,
+			Pivot: newCrosstabResult(config, rowsByLayer[value]).ToPivotWithAnalyzer(a),
+		})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,244 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// 複数回答の分析の種類
+const (
+	MultiAnswerSelectionCount = "selection_count" // 1人あたりの選択数の分布
+	MultiAnswerCooccurrence   = "cooccurrence"    // 選択肢の同時選択（共起）
+)
+
+// SelectionCountRow は選択数の分布の1行
+type SelectionCountRow struct {
+	Selections         int // 選択した選択肢の数
+	Count              int // 回答者数
+	Percentage         float64
+	WeightedCount      float64 // ウェイト付きの回答者数（ウェイトなしの場合はCountと同じ）
+	WeightedPercentage float64
+}
+
+// SelectionCountResult は複数回答の列の選択数の分布
+type SelectionCountResult struct {
+	Column              string
+	WeightColumn        string // ウェイト列（空の場合はウェイトなし）
+	Rows                []SelectionCountRow
+	Respondents         int     // 回答者数
+	WeightedRespondents float64 // ウェイト付きの回答者数
+	Mean                float64 // 平均選択数
+	WeightedMean        float64 // ウェイト付きの平均選択数
+}
+
+// IsWeighted はウェイト付きの集計かどうかを返す
+func (r *SelectionCountResult) IsWeighted() bool {
+	return r.WeightColumn != ""
+}
+
+// CooccurrenceCell は同時選択の表の1セル
+type CooccurrenceCell struct {
+	Count              int     // 両方の選択肢を選んだ回答者数（対角は選択肢を選んだ回答者数）
+	Percentage         float64 // 行の選択肢を選んだ回答者に対する割合（対角は回答者全体に対する割合）
+	WeightedCount      float64
+	WeightedPercentage float64
+}
+
+// CooccurrenceResult は複数回答の選択肢の同時選択（共起）の表
+type CooccurrenceResult struct {
+	Column              string
+	WeightColumn        string   // ウェイト列（空の場合はウェイトなし）
+	Options             []string // 選択肢（表示順）
+	Matrix              map[string]map[string]CooccurrenceCell
+	Respondents         int
+	WeightedRespondents float64
+}
+
+// IsWeighted はウェイト付きの集計かどうかを返す
+func (r *CooccurrenceResult) IsWeighted() bool {
+	return r.WeightColumn != ""
+}
+
+// Cell は行・列の選択肢のセルを返す
+func (r *CooccurrenceResult) Cell(row, column string) CooccurrenceCell {
+	return r.Matrix[row][column]
+}
+
+// selectedItemsExpression は複数回答の値を空でない選択肢のリストにする式を返す
+func selectedItemsExpression(column *Column) Expr {
+	return Exprf("list_filter(string_split(%s, %s), x -> trim(x) <> '')", column.GetSQLExpression(), splitSeparator(column))
+}
+
+// SelectionCount は複数回答の列について、1人あたりの選択数の分布を集計する
+// 列に回答がない（NULLの）回答者は含めない
+func (a *Analyzer) SelectionCount(column *Column, filter *Filter, weight *Column) (*SelectionCountResult, error) {
+	where := whereClause([]Expr{
+		Exprf("%s IS NOT NULL", column.GetSQLExpression()),
+		filterCondition(a, filter),
+	})
+	query := Exprf(`
+		WITH base AS (
+			SELECT
+				len(%s) as selections,
+				%s as weight
+			FROM %s
+			%s
+		)
+		SELECT selections, COUNT(*) as count, SUM(weight) as weighted_count
+		FROM base
+		GROUP BY selections
+		ORDER BY selections
+	`,
+		selectedItemsExpression(column),
+		weightExpression(weight),
+		a.tableExpression(),
+		where,
+	)
+
+	rows, err := a.db.Query(query.SQL, query.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute selection count query: %w", err)
+	}
+	defer rows.Close()
+
+	result := &SelectionCountResult{Column: column.Name}
+	if weight != nil {
+		result.WeightColumn = weight.Name
+	}
+
+	var sum, weightedSum float64
+	for rows.Next() {
+		var row SelectionCountRow
+		if err := rows.Scan(&row.Selections, &row.Count, &row.WeightedCount); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		result.Rows = append(result.Rows, row)
+		result.Respondents += row.Count
+		result.WeightedRespondents += row.WeightedCount
+		sum += float64(row.Selections * row.Count)
+		weightedSum += float64(row.Selections) * row.WeightedCount
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	for i := range result.Rows {
+		row := &result.Rows[i]
+		row.Percentage = percentage(float64(row.Count), float64(result.Respondents))
+		row.WeightedPercentage = percentage(row.WeightedCount, result.WeightedRespondents)
+	}
+	if result.Respondents > 0 {
+		result.Mean = math.Round(sum*100/float64(result.Respondents)) / 100
+	}
+	if result.WeightedRespondents > 0 {
+		result.WeightedMean = math.Round(weightedSum*100/result.WeightedRespondents) / 100
+	}
+
+	return result, nil
+}
+
+// Cooccurrence は複数回答の列について、選択肢の組み合わせごとに両方を選んだ回答者数を集計する
+// 同じ回答者が同じ選択肢を重複して選んでいる場合は1回と数える
+func (a *Analyzer) Cooccurrence(column *Column, filter *Filter, weight *Column) (*CooccurrenceResult, error) {
+	respondents, weightedRespondents, err := a.countRespondents(column, filter, weight)
+	if err != nil {
+		return nil, err
+	}
+
+	where := whereClause([]Expr{
+		Exprf("%s IS NOT NULL", column.GetSQLExpression()),
+		filterCondition(a, filter),
+	})
+	query := Exprf(`
+		WITH base AS (
+			SELECT
+				row_number() OVER () as respondent,
+				%s as items,
+				%s as weight
+			FROM %s
+			%s
+		),
+		selected AS (
+			SELECT DISTINCT respondent, weight, item
+			FROM base, unnest(items) AS t(item)
+		)
+		SELECT
+			s1.item as row_item,
+			s2.item as column_item,
+			COUNT(*) as count,
+			SUM(s1.weight) as weighted_count
+		FROM selected s1
+		JOIN selected s2 ON s1.respondent = s2.respondent
+		GROUP BY s1.item, s2.item
+	`,
+		selectedItemsExpression(column),
+		weightExpression(weight),
+		a.tableExpression(),
+		where,
+	)
+
+	rows, err := a.db.Query(query.SQL, query.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute cooccurrence query: %w", err)
+	}
+	defer rows.Close()
+
+	result := &CooccurrenceResult{
+		Column:              column.Name,
+		Matrix:              make(map[string]map[string]CooccurrenceCell),
+		Respondents:         respondents,
+		WeightedRespondents: weightedRespondents,
+	}
+	if weight != nil {
+		result.WeightColumn = weight.Name
+	}
+
+	for rows.Next() {
+		var rowItem, columnItem string
+		var cell CooccurrenceCell
+		if err := rows.Scan(&rowItem, &columnItem, &cell.Count, &cell.WeightedCount); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if result.Matrix[rowItem] == nil {
+			result.Matrix[rowItem] = make(map[string]CooccurrenceCell)
+			result.Options = append(result.Options, rowItem)
+		}
+		result.Matrix[rowItem][columnItem] = cell
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	// 割合: 対角は回答者全体、それ以外は行の選択肢を選んだ回答者に対する割合
+	for _, rowItem := range result.Options {
+		selected := result.Matrix[rowItem][rowItem]
+		for columnItem, cell := range result.Matrix[rowItem] {
+			if columnItem == rowItem {
+				cell.Percentage = percentage(float64(cell.Count), float64(respondents))
+				cell.WeightedPercentage = percentage(cell.WeightedCount, weightedRespondents)
+			} else {
+				cell.Percentage = percentage(float64(cell.Count), float64(selected.Count))
+				cell.WeightedPercentage = percentage(cell.WeightedCount, selected.WeightedCount)
+			}
+			result.Matrix[rowItem][columnItem] = cell
+		}
+	}
+
+	// 選択肢の表示順（順序の設定がなければ選んだ回答者の多い順）
+	orderMap := a.GetValueOrder(column.Name)
+	if len(orderMap) > 0 {
+		sortByOrder(result.Options, orderMap)
+	} else {
+		sort.SliceStable(result.Options, func(i, j int) bool {
+			ci := result.Matrix[result.Options[i]][result.Options[i]].Count
+			cj := result.Matrix[result.Options[j]][result.Options[j]].Count
+			if ci != cj {
+				return ci > cj
+			}
+			return result.Options[i] < result.Options[j]
+		})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,160 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expr はSQL式（またはSQL文）とバインド引数の組
+// SQL中の ? プレースホルダーと Args は出現順に対応する
+type Expr struct {
+	SQL  string
+	Args []interface{}
+}
+
+// NewExpr はSQLとバインド引数からExprを作成
+func NewExpr(sql string, args ...interface{}) Expr {
+	return Expr{SQL: sql, Args: args}
+}
+
+// Exprf はフォーマット中の %s を順にExprで置き換え、バインド引数も同じ順で連結する
+// %s 以外の書式指定子は解釈しない（LIKEの '%' などはそのまま残る）
+func Exprf(format string, exprs ...Expr) Expr {
+	parts := strings.Split(format, "%s")
+	if len(parts)-1 != len(exprs) {
+		panic(fmt.Sprintf("Exprf: %d placeholders but %d expressions", len(parts)-1, len(exprs)))
+	}
+
+	var sb strings.Builder
+	var args []interface{}
+	for i, part := range parts {
+		sb.WriteString(part)
+		if i < len(exprs) {
+			sb.WriteString(exprs[i].SQL)
+			args = append(args, exprs[i].Args...)
+		}
+	}
+
+	return Expr{SQL: sb.String(), Args: args}
+}
+
+// JoinExprs はExprを区切り文字で連結する
+func JoinExprs(exprs []Expr, sep string) Expr {
+	sqls := make([]string, len(exprs))
+	var args []interface{}
+	for i, e := range exprs {
+		sqls[i] = e.SQL
+		args = append(args, e.Args...)
+	}
+	return Expr{SQL: strings.Join(sqls, sep), Args: args}
+}
+
+// IsEmpty はSQLが空かどうかを返す
+func (e Expr) IsEmpty() bool {
+	return strings.TrimSpace(e.SQL) == ""
+}
+
+// String はデバッグ表示用にSQLとバインド引数を返す
+func (e Expr) String() string {
+	if len(e.Args) == 0 {
+		return e.SQL
+	}
+	return fmt.Sprintf("%s -- args: %q", e.SQL, e.Args)
+}
+
+// QuoteIdentifier は列名・テーブル名をダブルクォートで囲む（" は "" にエスケープ）
+func QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Ident は識別子のExprを作成
+func Ident(name string) Expr {
+	return Expr{SQL: QuoteIdentifier(name)}
+}
+
+// Param は1つの値をバインド引数とするExprを作成
+func Param(value interface{}) Expr {
+	return Expr{SQL: "?", Args: []interface{}{value}}
+}
+
+// ParamList は複数の値を (?, ?, ...) 形式のExprにする
+func ParamList(values []string) Expr {
+	placeholders := make([]string, len(values))
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		placeholders[i] = "?"
+		args[i] = v
+	}
+	return Expr{SQL: "(" + strings.Join(placeholders, ", ") + ")", Args: args}
+}
+
+// whereClause は条件をANDで結合したWHERE句を作成（条件がなければ空）
+func whereClause(conditions []Expr) Expr {
+	var nonEmpty []Expr
+	for _, cond := range conditions {
+		if !cond.IsEmpty() {
+			nonEmpty = append(nonEmpty, cond)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return Expr{}
+	}
+	return Exprf("WHERE %s", JoinExprs(nonEmpty, " AND "))
+}
+
+// tableExpression は集計対象テーブル（または結合）のFROM句用の式を返す
+func (a *Analyzer) tableExpression() Expr {
+	return NewExpr(a.source)
+}
+
+// notNullCondition は通常列のNULL除外条件を返す（派生列の場合は空）
+func notNullCondition(column *Column) Expr {
+	if column.IsDerived {
+		return Expr{}
+	}
+	return Exprf("%s IS NOT NULL", Ident(column.Name))
+}
+
+// derivedNotNullCondition は派生列の値のNULL除外条件を返す（通常列の場合は空）
+// 派生列は元データの列がNULLでなくても式の値がNULLになる（日付を解析できない行など）ため、
+// 軸・グループ・バナーの値は評価した値（CTEの列など）で除外する
+func derivedNotNullCondition(column *Column, value Expr) Expr {
+	if !column.IsDerived {
+		return Expr{}
+	}
+	return Exprf("%s IS NOT NULL", value)
+}
+
+// filterCondition はフィルタの条件式を返す（フィルタがなければ空）
+func filterCondition(a *Analyzer, filter *Filter) Expr {
+	if filter == nil {
+		return Expr{}
+	}
+	return filter.GenerateWhereClause(a)
+}
+
+// weightExpression はウェイトの式を返す
+// ウェイト列がない場合は1、数値に変換できない値やNULLは0として扱う
+func weightExpression(weight *Column) Expr {
+	if weight == nil {
+		return NewExpr("CAST(1 AS DOUBLE)")
+	}
+	return Exprf("COALESCE(TRY_CAST(%s AS DOUBLE), 0)", weight.GetSQLExpression())
+}
+
+// splitValueExpression は複数回答の列を回答ごとの行に展開する式を返す
+func splitValueExpression(column *Column) Expr {
+	if column.IsDerived && !column.IsMulti {
+		return column.GetSQLExpression()
+	}
+	return Exprf("unnest(string_split(%s, %s))", column.GetSQLExpression(), splitSeparator(column))
+}
+
+// splitSeparator は複数回答の列の区切り文字の式を返す
+// merge派生列は '|||'、通常列は改行で区切る
+func splitSeparator(column *Column) Expr {
+	if column.IsDerived {
+		return NewExpr("'|||'")
+	}
+	return NewExpr("CHR(10)")
+}
@@ -0,0 +1,122 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// QuestionGroupConfig はマトリクス設問（設問グループ）の設定全体
+type QuestionGroupConfig struct {
+	QuestionGroups []QuestionGroup `yaml:"question_groups"`
+}
+
+// QuestionGroup は同じ選択肢を共有する複数の列をまとめたマトリクス設問
+// 例: Q5_品質, Q5_価格, Q5_サポート を「Q5 評価」として1つの表にまとめる
+type QuestionGroup struct {
+	Name        string   `yaml:"name" json:"name"`
+	Description string   `yaml:"description" json:"description"`
+	Columns     []string `yaml:"columns" json:"columns"`                   // 項目の列（表示順）
+	Labels      []string `yaml:"labels,omitempty" json:"labels,omitempty"` // 項目の表示名（省略時は列名の共通の接頭辞を除いた名前）
+	Values      []string `yaml:"values,omitempty" json:"values,omitempty"` // 選択肢の表示順（省略時は先頭の列の表示順）
+}
+
+// LoadQuestionGroups は設定ファイルからマトリクス設問を読み込む
+func LoadQuestionGroups(configPath string) ([]QuestionGroup, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config QuestionGroupConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse yaml: %w", err)
+	}
+
+	return config.QuestionGroups, nil
+}
+
+// SaveQuestionGroups はマトリクス設問を設定ファイルに書き込む
+func SaveQuestionGroups(configPath string, groups []QuestionGroup) error {
+	config := QuestionGroupConfig{
+		QuestionGroups: groups,
+	}
+
+	data, err := yaml.Marshal(&config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal yaml: %w", err)
+	}
+
+	// ヘッダーコメントを追加
+	header := "# マトリクス設問（設問グループ）の定義\n# 同じ選択肢を共有する複数の列を1つの表（項目×選択肢）にまとめて集計します\n\n"
+	data = append([]byte(header), data...)
+
+	if err := os.WriteFile(configPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	return nil
+}
+
+// FindQuestionGroup は名前からマトリクス設問を探す（見つからない場合はnil）
+func FindQuestionGroup(groups []QuestionGroup, name string) *QuestionGroup {
+	for i := range groups {
+		if groups[i].Name == name {
+			return &groups[i]
+		}
+	}
+	return nil
+}
+
+// ItemLabels は項目の表示名を列の順に返す
+// labels の数が列の数と一致しない場合は、列名から共通の接頭辞（Q5_ など）を除いた名前を使う
+func (g *QuestionGroup) ItemLabels() []string {
+	if len(g.Labels) == len(g.Columns) {
+		return g.Labels
+	}
+
+	prefix := commonItemPrefix(g.Columns)
+	labels := make([]string, len(g.Columns))
+	for i, column := range g.Columns {
+		labels[i] = strings.TrimPrefix(column, prefix)
+	}
+	return labels
+}
+
+// commonItemPrefix は列名に共通する接頭辞を区切り文字（_ - . : 空白）まで含めて返す
+// 列が1つだけの場合や、除くと空になる列がある場合は空文字を返す
+func commonItemPrefix(columns []string) string {
+	if len(columns) < 2 {
+		return ""
+	}
+
+	prefix := []rune(columns[0])
+	for _, column := range columns[1:] {
+		runes := []rune(column)
+		n := 0
+		for n < len(prefix) && n < len(runes) && prefix[n] == runes[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+
+	// 区切り文字で終わる位置まで戻す
+	end := -1
+	for i, r := range prefix {
+		if strings.ContainsRune("_-.: 　", r) {
+			end = i
+		}
+	}
+	if end < 0 {
+		return ""
+	}
+	result := string(prefix[:end+1])
+	for _, column := range columns {
+		if column == result {
+			return ""
+		}
+	}
+	return result
+}
@@ -0,0 +1,193 @@
+package analyzer
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+)
+
+// RecordsConfig は回答者（元データの行）の一覧の設定
+type RecordsConfig struct {
+	Columns    []*Column      // 出力する列（空の場合は派生列を含む全ての列）
+	Filter     *Filter        // 適用するフィルタ（nilの場合はフィルタなし）
+	Cell       *CellSelection // 集計表のセルの回答者に絞る（nilの場合は絞らない）
+	Searches   []RecordSearch // 列ごとの検索（すべてに当てはまる行に絞る）
+	Sort       *Column        // 並べ替える列（nilの場合は元データの順）
+	Descending bool           // 降順に並べ替えるか
+	Limit      int            // 返す行数（0以下の場合は全ての行）
+	Offset     int
+}
+
+// RecordSearch は列の値の検索（部分一致、英字の大文字・小文字は区別しない）
+type RecordSearch struct {
+	Column *Column
+	Text   string
+}
+
+// CellSelection は集計表のセル（軸の値の組み合わせ）
+// 値を指定しない軸（全体の行・列）は値を問わないが、集計と同じく値がない回答者は除く
+// 層（Z）の値を指定しない場合は、層で分けない全体の表のセルとして層の列を使わない
+type CellSelection struct {
+	Config AnalysisConfig // 軸の列と複数回答の分割（単純集計はXColumnのみ）
+	XValue *string
+	YValue *string
+	ZValue *string
+}
+
+// RecordsResult は回答者の一覧（1ページ分）
+// 値はすべて文字列にし、NULLはnil
+type RecordsResult struct {
+	Columns []string    `json:"columns"`
+	Rows    [][]*string `json:"rows"`
+	Total   int         `json:"total"` // 条件に当てはまる行数
+	Limit   int         `json:"limit"`
+	Offset  int         `json:"offset"`
+}
+
+// cellAxis はセルの1つの軸（列・複数回答の分割・値）
+type cellAxis struct {
+	column *Column
+	split  bool
+	value  *string // nilの場合は値を問わない
+}
+
+// conditions はセルの回答者に絞る条件を生成
+// 複数回答を分割した軸はその選択肢を選んだ回答者、分割しない軸は値が一致する回答者
+func (s *CellSelection) conditions() []Expr {
+	axes := []cellAxis{
+		{s.Config.XColumn, s.Config.SplitX, s.XValue},
+		{s.Config.YColumn, s.Config.SplitY, s.YValue},
+	}
+	if s.ZValue != nil {
+		axes = append(axes, cellAxis{s.Config.ZColumn, s.Config.SplitZ, s.ZValue})
+	}
+
+	var conditions []Expr
+	for _, axis := range axes {
+		if axis.column == nil {
+			continue
+		}
+		colExpr := axis.column.GetSQLExpression()
+		// 派生列は merge タイプ以外は分割しない（集計と同じ）
+		split := axis.split && !(axis.column.IsDerived && !axis.column.IsMulti)
+
+		conditions = append(conditions, notNullCondition(axis.column))
+		switch {
+		case axis.value == nil:
+			conditions = append(conditions, derivedNotNullCondition(axis.column, colExpr))
+		case split:
+			conditions = append(conditions, Exprf("list_contains(string_split(%s, %s), %s)",
+				colExpr, splitSeparator(axis.column), Param(*axis.value)))
+		default:
+			conditions = append(conditions, Exprf("CAST(%s AS VARCHAR) = %s", colExpr, Param(*axis.value)))
+		}
+	}
+	return conditions
+}
+
+// recordOrder は行の順序を一意に決める並べ替えの式を返す
+// テーブル1つの場合は元データの順（rowid）
+// 結合したデータ（サブクエリ）は rowid がなく行の順序が保証されないため、出力する列の値の順（列の位置で指定）
+func (a *Analyzer) recordOrder(columns int) []Expr {
+	if len(a.Joins) == 0 {
+		return []Expr{NewExpr("rowid")}
+	}
+	orders := make([]Expr, columns)
+	for i := range orders {
+		orders[i] = NewExpr(strconv.Itoa(i + 1))
+	}
+	return orders
+}
+
+// condition は検索の条件を生成（検索する文字列が空の場合は空）
+func (s RecordSearch) condition() Expr {
+	if s.Text == "" {
+		return Expr{}
+	}
+	return Exprf("contains(lower(CAST(%s AS VARCHAR)), lower(%s))", s.Column.GetSQLExpression(), Param(s.Text))
+}
+
+// Records はフィルタ・セル・検索の条件に当てはまる回答者の行を返す
+// 並べ替える列がない場合は元データの順、並べ替える場合は値が同じ行を元データの順に並べる
+// 行の順序は常にORDER BYで決め、ページを送っても行が重複・欠落しないようにする
+func (a *Analyzer) Records(config RecordsConfig) (*RecordsResult, error) {
+	columns := config.Columns
+	if len(columns) == 0 {
+		all, err := a.GetColumns()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get columns: %w", err)
+		}
+		for i := range all {
+			columns = append(columns, &all[i])
+		}
+	}
+
+	conditions := []Expr{filterCondition(a, config.Filter)}
+	if config.Cell != nil {
+		conditions = append(conditions, config.Cell.conditions()...)
+	}
+	for _, search := range config.Searches {
+		conditions = append(conditions, search.condition())
+	}
+	where := whereClause(conditions)
+
+	result := &RecordsResult{Limit: config.Limit, Offset: config.Offset}
+
+	countQuery := Exprf("SELECT COUNT(*) FROM %s %s", a.tableExpression(), where)
+	if err := a.db.QueryRow(countQuery.SQL, countQuery.Args...).Scan(&result.Total); err != nil {
+		return nil, fmt.Errorf("failed to count records: %w", err)
+	}
+
+	selects := make([]Expr, len(columns))
+	for i, column := range columns {
+		result.Columns = append(result.Columns, column.Name)
+		selects[i] = Exprf("CAST(%s AS VARCHAR)", column.GetSQLExpression())
+	}
+
+	var orders []Expr
+	if config.Sort != nil {
+		direction := "ASC"
+		if config.Descending {
+			direction = "DESC"
+		}
+		orders = append(orders, Exprf("%s "+direction+" NULLS LAST", config.Sort.GetSQLExpression()))
+	}
+	orders = append(orders, a.recordOrder(len(columns))...)
+
+	query := Exprf("SELECT %s FROM %s %s ORDER BY %s", JoinExprs(selects, ", "), a.tableExpression(), where, JoinExprs(orders, ", "))
+	if config.Limit > 0 {
+		query = Exprf("%s LIMIT %s OFFSET %s", query, Param(config.Limit), Param(config.Offset))
+	}
+
+	rows, err := a.db.Query(query.SQL, query.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute records query: %w", err)
+	}
+	defer rows.Close()
+
+	result.Rows = [][]*string{}
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		row := make([]*string, len(columns))
+		for i, v := range values {
+			if v.Valid {
+				s := v.String
+				row[i] = &s
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,400 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 評価尺度の集計方法
+const (
+	ScaleLikert = "likert" // 満足度などの段階評価（Top/Bottom Box・平均点）
+	ScaleNPS    = "nps"    // 0〜10の推奨度（NPS）
+)
+
+// 評価尺度の既定値
+const (
+	defaultBoxSize      = 2
+	defaultPromoterMin  = 9
+	defaultDetractorMax = 6
+	defaultLikertMin    = 1
+	defaultLikertMax    = 5
+)
+
+// Scale は評価尺度の設問の集計設定（column_orders.yaml の列ごとの scale）
+// 段階評価の得点は scores、なければ列の表示順（先頭が最高点）、どちらもなければ数値の回答（min〜max）を使う
+type Scale struct {
+	Scores       map[string]float64 `yaml:"scores,omitempty" json:"scores,omitempty"`               // 値→得点
+	TopBox       int                `yaml:"top_box,omitempty" json:"top_box,omitempty"`             // 上位何段階をTop Boxとするか（デフォルト: 2）
+	BottomBox    int                `yaml:"bottom_box,omitempty" json:"bottom_box,omitempty"`       // 下位何段階をBottom Boxとするか（デフォルト: 2）
+	Min          *int               `yaml:"min,omitempty" json:"min,omitempty"`                     // 数値の回答の最小の段階（デフォルト: 1、0も指定可）
+	Max          *int               `yaml:"max,omitempty" json:"max,omitempty"`                     // 数値の回答の最大の段階（デフォルト: 5）
+	PromoterMin  float64            `yaml:"promoter_min,omitempty" json:"promoter_min,omitempty"`   // NPSの推奨者の下限（デフォルト: 9）
+	DetractorMax float64            `yaml:"detractor_max,omitempty" json:"detractor_max,omitempty"` // NPSの批判者の上限（デフォルト: 6）
+}
+
+// ScalePoint は段階評価の1段階
+type ScalePoint struct {
+	Value string
+	Score float64
+}
+
+// ScaleMetrics は評価尺度の集計値（割合は0〜100）
+type ScaleMetrics struct {
+	Base       float64 // 集計対象（得点のある回答）の件数
+	TopBox     float64 // Top Box の割合
+	BottomBox  float64 // Bottom Box の割合
+	Mean       float64 // 平均点
+	Promoters  float64 // 推奨者の割合（NPS）
+	Passives   float64 // 中立者の割合（NPS）
+	Detractors float64 // 批判者の割合（NPS）
+	NPS        float64 // 推奨者% − 批判者%
+}
+
+// ScaleSummary は全体または1グループの評価尺度の集計結果
+type ScaleSummary struct {
+	Group    string // グループの値（全体の場合は空）
+	N        int    // 集計対象の件数
+	Excluded int    // 得点のない回答（「わからない」など）の件数
+	Metrics  ScaleMetrics
+	Weighted ScaleMetrics // ウェイト付きの集計値（ウェイトなしの場合は Metrics と同じ）
+}
+
+// ScaleSummaryConfig は評価尺度の集計設定
+type ScaleSummaryConfig struct {
+	Column       *Column // 評価尺度の列
+	GroupColumn  *Column // グループ分けする列（バナー、nilの場合は全体のみ）
+	SplitGroup   bool    // グループ分けする列を複数回答として分割するか
+	ScaleType    string  // ScaleLikert または ScaleNPS
+	Filter       *Filter
+	WeightColumn *Column
+}
+
+// ScaleSummaryResult は評価尺度の集計結果
+type ScaleSummaryResult struct {
+	Column        string
+	GroupColumn   string // グループ分けした列（全体のみの場合は空）
+	WeightColumn  string // ウェイト列（空ならウェイトなし）
+	ScaleType     string
+	Points        []ScalePoint // 段階評価の段階（得点の高い順、NPSの場合は空）
+	TopBoxSize    int
+	BottomBoxSize int
+	PromoterMin   float64
+	DetractorMax  float64
+	Overall       ScaleSummary
+	Groups        []ScaleSummary // グループの値ごとの集計（値の表示順）
+}
+
+// IsNPS はNPSの集計結果かどうかを返す
+func (r *ScaleSummaryResult) IsNPS() bool {
+	return r.ScaleType == ScaleNPS
+}
+
+// IsGrouped はグループ分けした結果かどうかを返す
+func (r *ScaleSummaryResult) IsGrouped() bool {
+	return r.GroupColumn != ""
+}
+
+// IsWeighted はウェイト付きの結果かどうかを返す
+func (r *ScaleSummaryResult) IsWeighted() bool {
+	return r.WeightColumn != ""
+}
+
+// TopBoxLabel は Top Box の見出し（例: Top2）を返す
+func (r *ScaleSummaryResult) TopBoxLabel() string {
+	return fmt.Sprintf("Top%d", r.TopBoxSize)
+}
+
+// BottomBoxLabel は Bottom Box の見出し（例: Bottom2）を返す
+func (r *ScaleSummaryResult) BottomBoxLabel() string {
+	return fmt.Sprintf("Bottom%d", r.BottomBoxSize)
+}
+
+// TopBoxValues は Top Box に含まれる値を返す
+func (r *ScaleSummaryResult) TopBoxValues() []string {
+	return r.boxValues(r.TopBoxSize, true)
+}
+
+// BottomBoxValues は Bottom Box に含まれる値を返す
+func (r *ScaleSummaryResult) BottomBoxValues() []string {
+	return r.boxValues(r.BottomBoxSize, false)
+}
+
+// boxValues は得点の上位（または下位）size段階に含まれる値を返す
+func (r *ScaleSummaryResult) boxValues(size int, top bool) []string {
+	var values []string
+	for _, p := range r.Points {
+		if r.inBox(p.Score, size, top) {
+			values = append(values, p.Value)
+		}
+	}
+	return values
+}
+
+// inBox は得点が上位（または下位）size段階に含まれるかを返す
+// 同じ得点の値は同じ段階として数える
+func (r *ScaleSummaryResult) inBox(score float64, size int, top bool) bool {
+	var scores []float64
+	for _, p := range r.Points {
+		if len(scores) == 0 || scores[len(scores)-1] != p.Score {
+			scores = append(scores, p.Score)
+		}
+	}
+	if len(scores) == 0 || size <= 0 {
+		return false
+	}
+	if size > len(scores) {
+		size = len(scores)
+	}
+	if top {
+		return score >= scores[size-1]
+	}
+	return score <= scores[len(scores)-size]
+}
+
+// GetScale は列の評価尺度の設定を返す（設定がない場合は既定値）
+func (a *Analyzer) GetScale(columnName string) Scale {
+	if order, exists := a.columnOrdersMap[columnName]; exists && order.Scale != nil {
+		return *order.Scale
+	}
+	return Scale{}
+}
+
+// ScaleSummary は評価尺度の列を集計する
+// 段階評価は Top/Bottom Box の割合と平均点、NPSは推奨者・中立者・批判者の割合とNPSを求める
+// GroupColumn を指定した場合は、全体に加えてグループの値ごとにも集計する
+func (a *Analyzer) ScaleSummary(config ScaleSummaryConfig) (*ScaleSummaryResult, error) {
+	if config.Column == nil {
+		return nil, fmt.Errorf("scale summary requires a column")
+	}
+
+	result, err := a.newScaleSummaryResult(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// 全体（値ごとの件数から集計）
+	overall, err := a.SimpletabWithWeight(config.Column, false, config.Filter, config.WeightColumn)
+	if err != nil {
+		return nil, err
+	}
+	var counts []scaleCount
+	for _, row := range overall.Rows {
+		counts = append(counts, scaleCount{value: row.Value, count: row.Count, weighted: row.WeightedCount})
+	}
+	result.Overall = result.summarize("", counts)
+
+	if config.GroupColumn == nil {
+		return result, nil
+	}
+	result.GroupColumn = config.GroupColumn.Name
+
+	// グループごと（グループ×値のクロス集計の件数から集計）
+	crosstab, err := a.CrosstabWithFilter(AnalysisConfig{
+		XColumn:      config.GroupColumn,
+		YColumn:      config.Column,
+		SplitX:       config.SplitGroup,
+		WeightColumn: config.WeightColumn,
+	}, config.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	countsByGroup := make(map[string][]scaleCount)
+	var groups []string
+	for _, row := range crosstab.Rows {
+		if _, exists := countsByGroup[row.XValue]; !exists {
+			groups = append(groups, row.XValue)
+		}
+		countsByGroup[row.XValue] = append(countsByGroup[row.XValue], scaleCount{value: row.YValue, count: row.Count, weighted: row.WeightedCount})
+	}
+	sortByOrder(groups, a.GetValueOrder(config.GroupColumn.Name))
+	for _, group := range groups {
+		result.Groups = append(result.Groups, result.summarize(group, countsByGroup[group]))
+	}
+
+	return result, nil
+}
+
+// newScaleSummaryResult は設定から評価尺度の段階と閾値を決めた集計結果を作成
+func (a *Analyzer) newScaleSummaryResult(config ScaleSummaryConfig) (*ScaleSummaryResult, error) {
+	scale := a.GetScale(config.Column.Name)
+
+	result := &ScaleSummaryResult{
+		Column:        config.Column.Name,
+		ScaleType:     config.ScaleType,
+		TopBoxSize:    positiveOr(scale.TopBox, defaultBoxSize),
+		BottomBoxSize: positiveOr(scale.BottomBox, defaultBoxSize),
+		PromoterMin:   scale.PromoterMin,
+		DetractorMax:  scale.DetractorMax,
+	}
+	if config.WeightColumn != nil {
+		result.WeightColumn = config.WeightColumn.Name
+	}
+
+	switch config.ScaleType {
+	case ScaleNPS:
+		if result.PromoterMin == 0 {
+			result.PromoterMin = defaultPromoterMin
+		}
+		if result.DetractorMax == 0 {
+			result.DetractorMax = defaultDetractorMax
+		}
+		if result.DetractorMax >= result.PromoterMin {
+			return nil, fmt.Errorf("detractor_max (%g) must be less than promoter_min (%g)", result.DetractorMax, result.PromoterMin)
+		}
+	case ScaleLikert:
+		result.Points = a.likertPoints(config.Column.Name, scale)
+	default:
+		return nil, fmt.Errorf("unsupported scale type: %s", config.ScaleType)
+	}
+
+	return result, nil
+}
+
+// likertPoints は段階評価の段階を得点の高い順に返す
+// 得点の指定（scores）、列の表示順（先頭が最高点）、数値の段階（min〜max）の順に使う
+func (a *Analyzer) likertPoints(columnName string, scale Scale) []ScalePoint {
+	var points []ScalePoint
+	switch {
+	case len(scale.Scores) > 0:
+		for value, score := range scale.Scores {
+			points = append(points, ScalePoint{Value: value, Score: score})
+		}
+	case a.columnOrdersMap[columnName] != nil && len(a.columnOrdersMap[columnName].Values) > 0:
+		values := a.columnOrdersMap[columnName].Values
+		for i, value := range values {
+			points = append(points, ScalePoint{Value: value, Score: float64(len(values) - i)})
+		}
+	default:
+		min := intOr(scale.Min, defaultLikertMin)
+		max := intOr(scale.Max, defaultLikertMax)
+		for score := max; score >= min; score-- {
+			points = append(points, ScalePoint{Value: strconv.Itoa(score), Score: float64(score)})
+		}
+	}
+
+	sort.SliceStable(points, func(i, j int) bool {
+		if points[i].Score != points[j].Score {
+			return points[i].Score > points[j].Score
+		}
+		return points[i].Value < points[j].Value
+	})
+	return points
+}
+
+// scaleCount は評価尺度の1つの値の件数
+type scaleCount struct {
+	value    string
+	count    int
+	weighted float64
+}
+
+// summarize は値ごとの件数から評価尺度の集計値を求める
+// 得点のない値（段階にない回答、NPSでは数値でない回答）は集計対象から除いて Excluded に数える
+func (r *ScaleSummaryResult) summarize(group string, counts []scaleCount) ScaleSummary {
+	summary := ScaleSummary{Group: group}
+
+	var scored []scoredCount
+	for _, c := range counts {
+		score, ok := r.score(c.value)
+		if !ok {
+			summary.Excluded += c.count
+			continue
+		}
+		summary.N += c.count
+		scored = append(scored, scoredCount{score: score, count: float64(c.count), weighted: c.weighted})
+	}
+
+	summary.Metrics = r.metrics(scored, func(c scoredCount) float64 { return c.count })
+	summary.Weighted = r.metrics(scored, func(c scoredCount) float64 { return c.weighted })
+	return summary
+}
+
+// scoredCount は得点と件数の組
+type scoredCount struct {
+	score    float64
+	count    float64
+	weighted float64
+}
+
+// metrics は得点ごとの件数（weight で件数またはウェイト付き件数を選ぶ）から集計値を求める
+func (r *ScaleSummaryResult) metrics(scored []scoredCount, weight func(scoredCount) float64) ScaleMetrics {
+	var m ScaleMetrics
+	var top, bottom, sum, promoters, passives, detractors float64
+	for _, c := range scored {
+		w := weight(c)
+		m.Base += w
+		sum += c.score * w
+		if r.IsNPS() {
+			switch {
+			case c.score >= r.PromoterMin:
+				promoters += w
+			case c.score <= r.DetractorMax:
+				detractors += w
+			default:
+				passives += w
+			}
+			continue
+		}
+		if r.inBox(c.score, r.TopBoxSize, true) {
+			top += w
+		}
+		if r.inBox(c.score, r.BottomBoxSize, false) {
+			bottom += w
+		}
+	}
+	if m.Base == 0 {
+		return m
+	}
+
+	m.Mean = math.Round(sum*100/m.Base) / 100
+	m.TopBox = percentage(top, m.Base)
+	m.BottomBox = percentage(bottom, m.Base)
+	m.Promoters = percentage(promoters, m.Base)
+	m.Passives = percentage(passives, m.Base)
+	m.Detractors = percentage(detractors, m.Base)
+	m.NPS = percentage(promoters-detractors, m.Base)
+	return m
+}
+
+// score は回答の値の得点を返す（得点がない場合は false）
+func (r *ScaleSummaryResult) score(value string) (float64, bool) {
+	if r.IsNPS() {
+		score, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		return score, err == nil
+	}
+
+	for _, p := range r.Points {
+		if p.Value == value {
+			return p.Score, true
+		}
+	}
+	// 数値の段階の場合は "5" と "5.0" のような表記の違いを許容する
+	if score, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+		for _, p := range r.Points {
+			if pointScore, err := strconv.ParseFloat(p.Value, 64); err == nil && pointScore == score {
+				return p.Score, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// intOr は値が指定されていればその値（0を含む）、なければ既定値を返す
+func intOr(value *int, defaultValue int) int {
+	if value != nil {
+		return *value
+	}
+	return defaultValue
+}
+
+// positiveOr は値が正ならその値、そうでなければ既定値を返す
+func positiveOr(value, defaultValue int) int {
+	if value > 0 {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,178 @@
+package analyzer
+
+import "math"
+
+// 調整済み残差の有意水準の境界値（両側検定）
+const (
+	residualThreshold95 = 1.959964 // 5%水準
+	residualThreshold99 = 2.575829 // 1%水準
+)
+
+// 有意差のマーカー（全体より高い/低い）
+const (
+	MarkerHigh99 = "▲▲"
+	MarkerHigh95 = "▲"
+	MarkerLow99  = "▼▼"
+	MarkerLow95  = "▼"
+)
+
+// SignificanceTest はクロス表全体の独立性の検定結果
+type SignificanceTest struct {
+	ChiSquare float64 // カイ二乗値
+	DF        int     // 自由度
+	PValue    float64 // p値
+	CramersV  float64 // クラメールの連関係数
+	N         int     // 検定に使った件数
+}
+
+// IsSignificant は指定した有意水準（0.05など）で有意かどうかを返す
+func (t *SignificanceTest) IsSignificant(alpha float64) bool {
+	return t.PValue < alpha
+}
+
+// ResidualMarker は調整済み残差から有意差のマーカーを返す（有意でなければ空）
+func ResidualMarker(residual float64) string {
+	switch {
+	case residual >= residualThreshold99:
+		return MarkerHigh99
+	case residual >= residualThreshold95:
+		return MarkerHigh95
+	case residual <= -residualThreshold99:
+		return MarkerLow99
+	case residual <= -residualThreshold95:
+		return MarkerLow95
+	default:
+		return ""
+	}
+}
+
+// CalculateSignificance はピボットの件数からカイ二乗検定・クラメールのV・
+// 各セルの調整済み残差を計算し、Significance と各セルの AdjustedResidual/Marker を設定する
+// ウェイト付きの集計でも、検定にはウェイトなしの件数（n）を使う
+// 2行2列未満の表や件数が0の場合は計算しない（Significance は nil のまま）
+func (p *CrosstabPivot) CalculateSignificance() {
+	p.Significance = nil
+
+	rowTotals := make(map[string]int)
+	colTotals := make(map[string]int)
+	n := 0
+	for _, x := range p.XValues {
+		for _, y := range p.YValues {
+			count := p.Matrix[x][y].Count
+			rowTotals[x] += count
+			colTotals[y] += count
+			n += count
+		}
+	}
+
+	// 合計が0の行・列は検定から除く
+	var rows, cols []string
+	for _, x := range p.XValues {
+		if rowTotals[x] > 0 {
+			rows = append(rows, x)
+		}
+	}
+	for _, y := range p.YValues {
+		if colTotals[y] > 0 {
+			cols = append(cols, y)
+		}
+	}
+	if len(rows) < 2 || len(cols) < 2 || n == 0 {
+		return
+	}
+
+	total := float64(n)
+	chiSquare := 0.0
+	for _, x := range rows {
+		for _, y := range cols {
+			cell := p.Matrix[x][y]
+			rowRatio := float64(rowTotals[x]) / total
+			colRatio := float64(colTotals[y]) / total
+			expected := rowRatio * colRatio * total
+			diff := float64(cell.Count) - expected
+			chiSquare += diff * diff / expected
+
+			// 調整済み残差 = (観測度数 - 期待度数) / √(期待度数 × (1 - 行比率) × (1 - 列比率))
+			variance := expected * (1 - rowRatio) * (1 - colRatio)
+			if variance > 0 {
+				cell.AdjustedResidual = diff / math.Sqrt(variance)
+				cell.Marker = ResidualMarker(cell.AdjustedResidual)
+			}
+			p.Matrix[x][y] = cell
+		}
+	}
+
+	df := (len(rows) - 1) * (len(cols) - 1)
+	minDim := len(rows)
+	if len(cols) < minDim {
+		minDim = len(cols)
+	}
+
+	p.Significance = &SignificanceTest{
+		ChiSquare: chiSquare,
+		DF:        df,
+		PValue:    chiSquarePValue(chiSquare, df),
+		CramersV:  math.Sqrt(chiSquare / (total * float64(minDim-1))),
+		N:         n,
+	}
+}
+
+// chiSquarePValue はカイ二乗分布の上側確率（p値）を返す
+func chiSquarePValue(chiSquare float64, df int) float64 {
+	if chiSquare <= 0 || df <= 0 {
+		return 1
+	}
+	return upperIncompleteGamma(float64(df)/2, chiSquare/2)
+}
+
+// upperIncompleteGamma は正則化された上側不完全ガンマ関数 Q(a, x) を計算する
+// x < a+1 では級数展開、それ以外では連分数展開を使う
+func upperIncompleteGamma(a, x float64) float64 {
+	const (
+		maxIterations = 500
+		epsilon       = 1e-14
+		tiny          = 1e-300
+	)
+
+	lgamma, _ := math.Lgamma(a)
+	logPrefix := a*math.Log(x) - x - lgamma
+
+	if x < a+1 {
+		// 下側 P(a, x) を級数で求めて 1 から引く
+		sum := 1.0 / a
+		term := sum
+		for i := 1; i < maxIterations; i++ {
+			term *= x / (a + float64(i))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*epsilon {
+				break
+			}
+		}
+		return math.Max(0, 1-sum*math.Exp(logPrefix))
+	}
+
+	// 修正Lentz法による連分数
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for i := 1; i < maxIterations; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return math.Exp(logPrefix) * h
+}
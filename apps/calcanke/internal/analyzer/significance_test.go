@@ -0,0 +1,161 @@
+package analyzer
+
+import (
+	"math"
+	"testing"
+)
+
+func TestChiSquarePValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		chiSquare float64
+		df        int
+		want      float64
+		tolerance float64
+	}{
+		// カイ二乗分布表の5%点・1%点
+		{name: "df=1 の5%点", chiSquare: 3.841, df: 1, want: 0.05, tolerance: 1e-4},
+		{name: "df=4 の5%点", chiSquare: 9.488, df: 4, want: 0.05, tolerance: 1e-4},
+		{name: "df=1 の1%点", chiSquare: 6.635, df: 1, want: 0.01, tolerance: 1e-4},
+		{name: "df=10 の5%点（連分数側）", chiSquare: 18.307, df: 10, want: 0.05, tolerance: 1e-4},
+		// df=2 では p = exp(-x/2) になる
+		{name: "df=2 の解析解", chiSquare: 3, df: 2, want: math.Exp(-1.5), tolerance: 1e-12},
+		// 計算できない場合は p=1（有意ではない）とする
+		{name: "自由度0", chiSquare: 5, df: 0, want: 1},
+		{name: "自由度が負", chiSquare: 5, df: -1, want: 1},
+		{name: "カイ二乗値0", chiSquare: 0, df: 3, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := chiSquarePValue(tt.chiSquare, tt.df)
+			if math.Abs(got-tt.want) > tt.tolerance {
+				t.Errorf("chiSquarePValue(%v, %d) = %v, want %v", tt.chiSquare, tt.df, got, tt.want)
+			}
+		})
+	}
+}
+
+// newTestPivot は件数の表（[行][列]）からピボットを作成する
+func newTestPivot(counts [][]int) *CrosstabPivot {
+	p := &CrosstabPivot{Matrix: make(map[string]map[string]CrosstabCell)}
+	for j := range counts[0] {
+		p.YValues = append(p.YValues, string(rune('a'+j)))
+	}
+	for i, row := range counts {
+		x := string(rune('A' + i))
+		p.XValues = append(p.XValues, x)
+		p.Matrix[x] = make(map[string]CrosstabCell)
+		for j, count := range row {
+			p.Matrix[x][p.YValues[j]] = CrosstabCell{Count: count, Exists: count > 0}
+		}
+	}
+	return p
+}
+
+func TestCalculateSignificance(t *testing.T) {
+	tests := []struct {
+		name      string
+		counts    [][]int
+		wantNil   bool
+		chiSquare float64
+		df        int
+		cramersV  float64
+		n         int
+	}{
+		{
+			// 期待度数は 12, 18, 28, 42
+			name:      "2行2列",
+			counts:    [][]int{{10, 20}, {30, 40}},
+			chiSquare: 4.0/12 + 4.0/18 + 4.0/28 + 4.0/42,
+			df:        1,
+			cramersV:  math.Sqrt((4.0/12 + 4.0/18 + 4.0/28 + 4.0/42) / 100),
+			n:         100,
+		},
+		{
+			// 合計が0の行・列は期待度数が0になるため検定から除く
+			name:      "合計0の行と列を含む",
+			counts:    [][]int{{10, 0, 20}, {0, 0, 0}, {30, 0, 40}},
+			chiSquare: 4.0/12 + 4.0/18 + 4.0/28 + 4.0/42,
+			df:        1,
+			cramersV:  math.Sqrt((4.0/12 + 4.0/18 + 4.0/28 + 4.0/42) / 100),
+			n:         100,
+		},
+		{
+			name:    "合計0の行を除くと1行しか残らない",
+			counts:  [][]int{{10, 20}, {0, 0}},
+			wantNil: true,
+		},
+		{
+			name:    "1列だけの表",
+			counts:  [][]int{{10}, {20}},
+			wantNil: true,
+		},
+		{
+			name:    "件数0",
+			counts:  [][]int{{0, 0}, {0, 0}},
+			wantNil: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPivot(tt.counts)
+			p.CalculateSignificance()
+
+			if tt.wantNil {
+				if p.Significance != nil {
+					t.Fatalf("Significance = %+v, want nil", p.Significance)
+				}
+				return
+			}
+			s := p.Significance
+			if s == nil {
+				t.Fatal("Significance = nil")
+			}
+			if math.Abs(s.ChiSquare-tt.chiSquare) > 1e-9 {
+				t.Errorf("ChiSquare = %v, want %v", s.ChiSquare, tt.chiSquare)
+			}
+			if s.DF != tt.df {
+				t.Errorf("DF = %d, want %d", s.DF, tt.df)
+			}
+			if math.Abs(s.CramersV-tt.cramersV) > 1e-9 {
+				t.Errorf("CramersV = %v, want %v", s.CramersV, tt.cramersV)
+			}
+			if s.N != tt.n {
+				t.Errorf("N = %d, want %d", s.N, tt.n)
+			}
+			if math.IsNaN(s.PValue) || s.PValue < 0 || s.PValue > 1 {
+				t.Errorf("PValue = %v, want a probability", s.PValue)
+			}
+			for _, x := range p.XValues {
+				for _, y := range p.YValues {
+					if r := p.Matrix[x][y].AdjustedResidual; math.IsNaN(r) || math.IsInf(r, 0) {
+						t.Errorf("AdjustedResidual[%s][%s] = %v", x, y, r)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestResidualMarker(t *testing.T) {
+	tests := []struct {
+		residual float64
+		want     string
+	}{
+		{2.6, MarkerHigh99},
+		{2.0, MarkerHigh95},
+		{1.9, ""},
+		{0, ""},
+		{-1.9, ""},
+		{-2.0, MarkerLow95},
+		{-2.6, MarkerLow99},
+	}
+
+	for _, tt := range tests {
+		if got := ResidualMarker(tt.residual); got != tt.want {
+			t.Errorf("ResidualMarker(%v) = %q, want %q", tt.residual, got, tt.want)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package analyzer
 
 import (
 	"fmt"
-	"strings"
 )
 
 // Simpletab は単純集計を実行
@@ -12,24 +11,30 @@ func (a *Analyzer) Simpletab(column *Column, split bool) (*SimpletabResult, erro
 
 // SimpletabWithFilter はフィルタを適用して単純集計を実行
 func (a *Analyzer) SimpletabWithFilter(column *Column, split bool, filter *Filter) (*SimpletabResult, error) {
+	return a.SimpletabWithWeight(column, split, filter, nil)
+}
+
+// SimpletabWithWeight はフィルタとウェイトを適用して単純集計を実行
+// weight が nil の場合はウェイトなし（ウェイト付きの件数はCountと同じ）
+func (a *Analyzer) SimpletabWithWeight(column *Column, split bool, filter *Filter, weight *Column) (*SimpletabResult, error) {
 	// 派生列の場合は、merge タイプ以外は複数回答の分割に対応しない
 	if column.IsDerived && !column.IsMulti {
 		split = false
 	}
 
 	// SQLを動的に構築
-	var query string
+	var query Expr
 
 	if split {
 		// 複数回答対応の単純集計
-		query = a.buildMultiAnswerSimpletabQuery(column, filter)
+		query = a.buildMultiAnswerSimpletabQuery(column, filter, weight)
 	} else {
 		// シンプルな単純集計
-		query = a.buildSimpleSimpletabQuery(column, filter)
+		query = a.buildSimpleSimpletabQuery(column, filter, weight)
 	}
 
 	// クエリ実行
-	rows, err := a.db.Query(query)
+	rows, err := a.db.Query(query.SQL, query.Args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute simpletab query: %w", err)
 	}
@@ -38,14 +43,16 @@ func (a *Analyzer) SimpletabWithFilter(column *Column, split bool, filter *Filte
 	// 結果をパース
 	var resultRows []SimpletabRow
 	total := 0
+	weightedTotal := 0.0
 	for rows.Next() {
 		var row SimpletabRow
-		err := rows.Scan(&row.Value, &row.Count, &row.Percentage)
+		err := rows.Scan(&row.Value, &row.Count, &row.Percentage, &row.WeightedCount, &row.WeightedPercentage)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 		resultRows = append(resultRows, row)
 		total += row.Count
+		weightedTotal += row.WeightedCount
 	}
 
 	if err = rows.Err(); err != nil {
@@ -53,9 +60,29 @@ func (a *Analyzer) SimpletabWithFilter(column *Column, split bool, filter *Filte
 	}
 
 	result := &SimpletabResult{
-		Column: column.Name,
-		Rows:   resultRows,
-		Total:  total,
+		Column:              column.Name,
+		Rows:                resultRows,
+		Total:               total,
+		WeightedTotal:       weightedTotal,
+		Split:               split,
+		Respondents:         total,
+		WeightedRespondents: weightedTotal,
+	}
+	if weight != nil {
+		result.WeightColumn = weight.Name
+	}
+
+	// 複数回答の場合は回答者数を数えて、回答者数ベースの割合を求める
+	if split {
+		result.Respondents, result.WeightedRespondents, err = a.countRespondents(column, filter, weight)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i := range result.Rows {
+		row := &result.Rows[i]
+		row.RespondentPercentage = percentage(float64(row.Count), float64(result.Respondents))
+		row.WeightedRespondentPercentage = percentage(row.WeightedCount, result.WeightedRespondents)
 	}
 
 	// カスタム順序でソート
@@ -64,98 +91,65 @@ func (a *Analyzer) SimpletabWithFilter(column *Column, split bool, filter *Filte
 	return result, nil
 }
 
-// buildSimpleSimpletabQuery はシンプルな単純集計のSQLを生成
-func (a *Analyzer) buildSimpleSimpletabQuery(column *Column, filter *Filter) string {
-	colExpr := column.GetSQLExpression()
-
-	// WHERE句の構築
-	var whereClauses []string
-
-	// 派生列でない場合はNULL除外
-	if !column.IsDerived {
-		whereClauses = append(whereClauses, fmt.Sprintf(`"%s" IS NOT NULL`, column.Name))
-	}
-
-	// フィルタがある場合は追加
-	if filter != nil {
-		filterWhere := filter.GenerateWhereClause(a)
-		if filterWhere != "" {
-			whereClauses = append(whereClauses, filterWhere)
-		}
-	}
-
-	whereClause := ""
-	if len(whereClauses) > 0 {
-		whereClause = "WHERE " + strings.Join(whereClauses, " AND ")
+// countRespondents は列に回答がある回答者の数（とウェイト付きの数）を数える
+// 派生列は式の値がNULLの行を回答なしとする
+func (a *Analyzer) countRespondents(column *Column, filter *Filter, weight *Column) (int, float64, error) {
+	where := whereClause([]Expr{
+		Exprf("%s IS NOT NULL", column.GetSQLExpression()),
+		filterCondition(a, filter),
+	})
+	query := Exprf("SELECT COUNT(*), COALESCE(SUM(%s), 0) FROM %s %s", weightExpression(weight), a.tableExpression(), where)
+
+	var respondents int
+	var weighted float64
+	if err := a.db.QueryRow(query.SQL, query.Args...).Scan(&respondents, &weighted); err != nil {
+		return 0, 0, fmt.Errorf("failed to count respondents: %w", err)
 	}
+	return respondents, weighted, nil
+}
 
-	return fmt.Sprintf(`
-		SELECT
-			%s as value,
-			COUNT(*) as count,
-			ROUND(COUNT(*) * 100.0 / SUM(COUNT(*)) OVER(), 1) as percentage
-		FROM %s
-		%s
-		GROUP BY %s
-		ORDER BY count DESC
-	`,
-		colExpr,
-		a.Table,
-		whereClause,
-		colExpr,
-	)
+// buildSimpleSimpletabQuery はシンプルな単純集計のSQLを生成
+func (a *Analyzer) buildSimpleSimpletabQuery(column *Column, filter *Filter, weight *Column) Expr {
+	return a.buildSimpletabQuery(column.GetSQLExpression(), column, filter, weight)
 }
 
 // buildMultiAnswerSimpletabQuery は複数回答の単純集計のSQLを生成
-func (a *Analyzer) buildMultiAnswerSimpletabQuery(column *Column, filter *Filter) string {
-	// WHERE句の構築
-	var whereClauses []string
-
-	// 派生列でない場合はNULL除外
-	if !column.IsDerived {
-		whereClauses = append(whereClauses, fmt.Sprintf(`"%s" IS NOT NULL`, column.Name))
-	}
-
-	// フィルタがある場合は追加
-	if filter != nil {
-		filterWhere := filter.GenerateWhereClause(a)
-		if filterWhere != "" {
-			whereClauses = append(whereClauses, filterWhere)
-		}
-	}
-
-	whereClause := ""
-	if len(whereClauses) > 0 {
-		whereClause = "WHERE " + strings.Join(whereClauses, " AND ")
-	}
-
-	// 列式の取得
-	var valueExpr string
-	if column.IsDerived && column.IsMulti {
-		// merge派生列の場合は '|||' で分割
-		valueExpr = fmt.Sprintf(`unnest(string_split(%s, '|||'))`, column.GetSQLExpression())
-	} else {
-		// 通常列の場合は改行で分割
-		valueExpr = fmt.Sprintf(`unnest(string_split("%s", CHR(10)))`, column.Name)
-	}
+func (a *Analyzer) buildMultiAnswerSimpletabQuery(column *Column, filter *Filter, weight *Column) Expr {
+	return a.buildSimpletabQuery(splitValueExpression(column), column, filter, weight)
+}
 
-	return fmt.Sprintf(`
-		WITH split_data AS (
+// buildSimpletabQuery は値の式を集計する単純集計のSQLを生成
+// 派生列の式にはバインド引数が含まれるため、CTEで1度だけ評価してからGROUP BYする
+// 複数回答を分割した場合も、回答ごとの行に回答者のウェイトがそのまま付く
+// 派生列の式がNULLになる行（日付を解析できない行など）は、通常列のNULLと同じく集計から除く
+func (a *Analyzer) buildSimpletabQuery(valueExpr Expr, column *Column, filter *Filter, weight *Column) Expr {
+	where := whereClause([]Expr{
+		notNullCondition(column),
+		filterCondition(a, filter),
+	})
+
+	return Exprf(`
+		WITH base AS (
 			SELECT
-				%s as value
+				%s as value,
+				%s as weight
 			FROM %s
 			%s
 		)
 		SELECT
 			value,
 			COUNT(*) as count,
-			ROUND(COUNT(*) * 100.0 / SUM(COUNT(*)) OVER(), 1) as percentage
-		FROM split_data
+			ROUND(COUNT(*) * 100.0 / SUM(COUNT(*)) OVER(), 1) as percentage,
+			SUM(weight) as weighted_count,
+			COALESCE(ROUND(SUM(weight) * 100.0 / NULLIF(SUM(SUM(weight)) OVER(), 0), 1), 0) as weighted_percentage
+		FROM base
+		WHERE value IS NOT NULL
 		GROUP BY value
 		ORDER BY count DESC
 	`,
 		valueExpr,
-		a.Table,
-		whereClause,
+		weightExpression(weight),
+		a.tableExpression(),
+		where,
 	)
 }
@@ -0,0 +1,180 @@
+package analyzer
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DataSource は集計対象のテーブルと、キー列で結合するテーブルの定義
+type DataSource struct {
+	Table string      `yaml:"table" json:"table"`
+	Joins []TableJoin `yaml:"joins" json:"joins"`
+}
+
+// TableJoin は基準テーブルに結合するテーブルの定義
+type TableJoin struct {
+	Table   string `yaml:"table" json:"table"`
+	Key     string `yaml:"key" json:"key"`           // 基準テーブル側のキー列
+	JoinKey string `yaml:"join_key" json:"join_key"` // 結合テーブル側のキー列（省略時はKeyと同じ）
+	Type    string `yaml:"type" json:"type"`         // left（デフォルト）, inner
+}
+
+// LoadDataSource は設定ファイルから集計対象を読み込む
+func LoadDataSource(configPath string) (*DataSource, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var source DataSource
+	if err := yaml.Unmarshal(data, &source); err != nil {
+		return nil, fmt.Errorf("failed to parse yaml: %w", err)
+	}
+
+	return &source, nil
+}
+
+// SaveDataSource は集計対象を設定ファイルに書き込む
+func SaveDataSource(configPath string, source DataSource) error {
+	data, err := yaml.Marshal(&source)
+	if err != nil {
+		return fmt.Errorf("failed to marshal yaml: %w", err)
+	}
+
+	// ヘッダーコメントを追加
+	header := "# 集計対象テーブルの定義\n# joins を指定すると、キー列で結合したテーブルを1つの表として集計できます\n\n"
+	data = append([]byte(header), data...)
+
+	if err := os.WriteFile(configPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	return nil
+}
+
+// joinKey は結合テーブル側のキー列名を返す
+func (j TableJoin) joinKey() string {
+	if j.JoinKey != "" {
+		return j.JoinKey
+	}
+	return j.Key
+}
+
+// joinType はSQLの結合種別を返す
+func (j TableJoin) joinType() (string, error) {
+	switch strings.ToLower(j.Type) {
+	case "", "left":
+		return "LEFT JOIN", nil
+	case "inner":
+		return "INNER JOIN", nil
+	default:
+		return "", fmt.Errorf("unsupported join type: %s", j.Type)
+	}
+}
+
+// tableColumnNames はテーブルの列名を定義順に取得
+func tableColumnNames(db *sql.DB, table string) ([]string, error) {
+	rows, err := db.Query("DESCRIBE " + QuoteIdentifier(table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var columnName, columnType string
+		var null, key, defaultVal, extra sql.NullString
+		if err := rows.Scan(&columnName, &columnType, &null, &key, &defaultVal, &extra); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		names = append(names, columnName)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return names, nil
+}
+
+// TableColumnNames はデータベース内の任意のテーブルの列名を取得（結合キーの選択用）
+func (a *Analyzer) TableColumnNames(table string) ([]string, error) {
+	return tableColumnNames(a.db, table)
+}
+
+// buildSourceSQL は集計対象のFROM句に使うSQLを組み立てる
+// 結合がない場合はテーブル名、ある場合は列名を解決したサブクエリを返す
+// 結合テーブルの列名が既存の列と重複する場合は「テーブル名.列名」とする
+func buildSourceSQL(db *sql.DB, source DataSource) (string, error) {
+	if len(source.Joins) == 0 {
+		return QuoteIdentifier(source.Table), nil
+	}
+
+	baseColumns, err := tableColumnNames(db, source.Table)
+	if err != nil {
+		return "", err
+	}
+
+	used := make(map[string]bool)
+	hasBaseColumn := make(map[string]bool)
+	var selects []string
+	for _, col := range baseColumns {
+		selects = append(selects, "t0."+QuoteIdentifier(col))
+		used[col] = true
+		hasBaseColumn[col] = true
+	}
+
+	from := fmt.Sprintf("%s AS t0", QuoteIdentifier(source.Table))
+	for i, join := range source.Joins {
+		if join.Table == "" || join.Key == "" {
+			return "", fmt.Errorf("join requires table and key")
+		}
+		if !hasBaseColumn[join.Key] {
+			return "", fmt.Errorf("key column %s not found in table %s", join.Key, source.Table)
+		}
+
+		joinType, err := join.joinType()
+		if err != nil {
+			return "", err
+		}
+
+		joinColumns, err := tableColumnNames(db, join.Table)
+		if err != nil {
+			return "", err
+		}
+
+		alias := fmt.Sprintf("t%d", i+1)
+		foundKey := false
+		for _, col := range joinColumns {
+			if col == join.joinKey() {
+				foundKey = true
+				continue
+			}
+			name := col
+			if used[name] {
+				name = join.Table + "." + col
+			}
+			used[name] = true
+			selects = append(selects, fmt.Sprintf("%s.%s AS %s", alias, QuoteIdentifier(col), QuoteIdentifier(name)))
+		}
+		if !foundKey {
+			return "", fmt.Errorf("key column %s not found in table %s", join.joinKey(), join.Table)
+		}
+
+		// 型の違い（数値と文字列など）を吸収するため文字列として比較
+		from += fmt.Sprintf(" %s %s AS %s ON CAST(t0.%s AS VARCHAR) = CAST(%s.%s AS VARCHAR)",
+			joinType,
+			QuoteIdentifier(join.Table),
+			alias,
+			QuoteIdentifier(join.Key),
+			alias,
+			QuoteIdentifier(join.joinKey()),
+		)
+	}
+
+	return fmt.Sprintf("(SELECT %s FROM %s) AS source", strings.Join(selects, ", "), from), nil
+}
@@ -0,0 +1,223 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// sqlExpressionDeniedFunctions はSQL式の派生列で使えない関数（設定値や環境変数を読む関数）
+var sqlExpressionDeniedFunctions = map[string]bool{
+	"current_setting": true,
+	"getenv":          true,
+}
+
+// sqlExpressionDeniedClasses はSQL式の派生列で使えない式の種類
+// サブクエリ・ウィンドウ関数・バインド引数・列の展開（*）は1行の値から求める式にならない
+var sqlExpressionDeniedClasses = map[string]string{
+	"SUBQUERY":  "subqueries are not allowed",
+	"WINDOW":    "window functions are not allowed",
+	"PARAMETER": "parameters are not allowed",
+	"STAR":      "* is not allowed",
+}
+
+// generateSQLExpression はSQL式の派生列のSQL式を生成
+// 他の派生列と同じく値は文字列にする。確認を通っていない式（確認できなかった式など）はNULL
+func (dc *DerivedColumn) generateSQLExpression() Expr {
+	if strings.TrimSpace(dc.Expression) == "" || dc.CheckedExpression != dc.Expression {
+		return NewExpr("NULL")
+	}
+	return Exprf("CAST(%s AS VARCHAR)", sqlExpressionValue(dc.Expression))
+}
+
+// sqlExpressionValue は式を括弧で囲む（末尾の行コメントで閉じ括弧が消えないよう改行を入れる）
+func sqlExpressionValue(expression string) Expr {
+	return Exprf("(%s\n)", NewExpr(expression))
+}
+
+// validateSQLExpression はSQL式の派生列の定義を確認する（式の中身はValidateSQLExpressionで確認する）
+func (dc *DerivedColumn) validateSQLExpression() error {
+	if strings.TrimSpace(dc.Expression) == "" {
+		return fmt.Errorf("sql_expression requires an expression")
+	}
+	return nil
+}
+
+// ValidateSQLExpression はSQL式の派生列の式をデータに対して確認する
+// 1つの値を返す式であること、読み取りのみの関数（集計・テーブル関数・乱数などを除く）だけを使うこと、
+// サブクエリなどで他のテーブルを参照しないことを確認し、最後に EXPLAIN で列名や型を確認する
+func (a *Analyzer) ValidateSQLExpression(dc *DerivedColumn) error {
+	if err := dc.validateSQLExpression(); err != nil {
+		return err
+	}
+
+	node, err := a.parseSQLExpression(dc.Expression)
+	if err != nil {
+		return err
+	}
+
+	functions := make(map[string]bool)
+	if err := collectSQLExpressionFunctions(node, functions); err != nil {
+		return err
+	}
+	if err := a.checkSQLExpressionFunctions(functions); err != nil {
+		return err
+	}
+
+	query := Exprf("EXPLAIN SELECT %s AS value FROM %s LIMIT 0", sqlExpressionValue(dc.Expression), a.tableExpression())
+	rows, err := a.db.Query(query.SQL, query.Args...)
+	if err != nil {
+		return fmt.Errorf("invalid SQL expression: %w", err)
+	}
+	return rows.Close()
+}
+
+// parseSQLExpression は式を「SELECT 式」として構文解析し、式の構文木（JSON）を返す
+// SELECT 文が1つで、FROM・WHERE・GROUP BY などを含まないことを確認する
+func (a *Analyzer) parseSQLExpression(expression string) (interface{}, error) {
+	query := Exprf("SELECT CAST(json_serialize_sql(%s) AS VARCHAR)", sqlStringLiteral("SELECT "+expression))
+	var serialized string
+	if err := a.db.QueryRow(query.SQL, query.Args...).Scan(&serialized); err != nil {
+		return nil, fmt.Errorf("failed to parse SQL expression: %w", err)
+	}
+
+	var parsed struct {
+		Error        bool   `json:"error"`
+		ErrorMessage string `json:"error_message"`
+		Statements   []struct {
+			Node struct {
+				Type             string                      `json:"type"`
+				Modifiers        []interface{}               `json:"modifiers"`
+				CTEMap           struct{ Map []interface{} } `json:"cte_map"`
+				SelectList       []interface{}               `json:"select_list"`
+				FromTable        struct{ Type string }       `json:"from_table"`
+				WhereClause      interface{}                 `json:"where_clause"`
+				GroupExpressions []interface{}               `json:"group_expressions"`
+				Having           interface{}                 `json:"having"`
+				Sample           interface{}                 `json:"sample"`
+				Qualify          interface{}                 `json:"qualify"`
+			} `json:"node"`
+		} `json:"statements"`
+	}
+	if err := json.Unmarshal([]byte(serialized), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse SQL expression: %w", err)
+	}
+	if parsed.Error {
+		return nil, fmt.Errorf("invalid SQL expression: %s", parsed.ErrorMessage)
+	}
+	if len(parsed.Statements) != 1 {
+		return nil, fmt.Errorf("invalid SQL expression: must be a single expression")
+	}
+
+	node := parsed.Statements[0].Node
+	switch {
+	case node.Type != "SELECT_NODE" || len(node.SelectList) != 1:
+		return nil, fmt.Errorf("invalid SQL expression: must be a single expression")
+	case node.FromTable.Type != "EMPTY":
+		return nil, fmt.Errorf("invalid SQL expression: FROM is not allowed")
+	case node.WhereClause != nil || len(node.GroupExpressions) > 0 || node.Having != nil || node.Qualify != nil ||
+		node.Sample != nil || len(node.Modifiers) > 0 || len(node.CTEMap.Map) > 0:
+		return nil, fmt.Errorf("invalid SQL expression: clauses such as WHERE, GROUP BY, ORDER BY and LIMIT are not allowed")
+	}
+	return node.SelectList[0], nil
+}
+
+// collectSQLExpressionFunctions は式の構文木をたどり、使っている関数名を集める
+// 使えない種類の式（サブクエリなど）があればエラー
+func collectSQLExpressionFunctions(node interface{}, functions map[string]bool) error {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if class, ok := v["class"].(string); ok {
+			if reason, denied := sqlExpressionDeniedClasses[class]; denied {
+				return fmt.Errorf("invalid SQL expression: %s", reason)
+			}
+			if class == "FUNCTION" {
+				if catalog, _ := v["catalog"].(string); catalog != "" {
+					return fmt.Errorf("invalid SQL expression: function catalog is not allowed: %s", catalog)
+				}
+				name, _ := v["function_name"].(string)
+				functions[strings.ToLower(name)] = true
+			}
+		}
+		for _, child := range v {
+			if err := collectSQLExpressionFunctions(child, functions); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if err := collectSQLExpressionFunctions(child, functions); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkSQLExpressionFunctions は関数が組み込みのスカラー関数（またはマクロ）で、
+// 呼び出すたびに結果が変わる関数（乱数・シーケンスなど）ではないことを確認する
+func (a *Analyzer) checkSQLExpressionFunctions(functions map[string]bool) error {
+	if len(functions) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(functions))
+	for name := range functions {
+		if sqlExpressionDeniedFunctions[name] {
+			return fmt.Errorf("invalid SQL expression: function is not allowed: %s", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	query := Exprf(`SELECT DISTINCT lower(function_name) FROM duckdb_functions()
+		WHERE lower(function_name) IN %s
+		  AND internal
+		  AND function_type IN ('scalar', 'macro')
+		  AND COALESCE(stability, '') <> 'VOLATILE'`, ParamList(names))
+	rows, err := a.db.Query(query.SQL, query.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to check functions: %w", err)
+	}
+	defer rows.Close()
+
+	allowed := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return fmt.Errorf("failed to check functions: %w", err)
+		}
+		allowed[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to check functions: %w", err)
+	}
+
+	for _, name := range names {
+		if !allowed[name] {
+			return fmt.Errorf("invalid SQL expression: function is not allowed: %s", name)
+		}
+	}
+	return nil
+}
+
+// CheckSQLExpression はSQL式の派生列の式をデータに対して確認し、結果を CheckedExpression に記録する
+// 確認できなかった場合は記録を消してエラーを返す（その式の値はNULLになる）
+func (a *Analyzer) CheckSQLExpression(dc *DerivedColumn) error {
+	dc.CheckedExpression = ""
+	if err := a.ValidateSQLExpression(dc); err != nil {
+		return err
+	}
+	dc.CheckedExpression = dc.Expression
+	return nil
+}
+
+// checkUnsavedSQLExpression は保存時の確認の記録がないSQL式（設定ファイルを直接編集した場合など）を、使うときに確認する
+// 保存時と同じ確認を通った式だけを実行し、通らなかった式はこのAnalyzerでは確認し直さない
+func (a *Analyzer) checkUnsavedSQLExpression(dc *DerivedColumn) {
+	if dc.CalculationType != "sql_expression" || dc.CheckedExpression == dc.Expression || dc.expressionErr != nil {
+		return
+	}
+	dc.expressionErr = a.CheckSQLExpression(dc)
+}
@@ -0,0 +1,192 @@
+package analyzer
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// NumericStatsConfig は数値の記述統計量の集計設定
+type NumericStatsConfig struct {
+	Column       *Column // 統計量を求める列（数値に変換して集計）
+	GroupColumn  *Column // グループ分けする列（nilの場合は全体のみ）
+	SplitGroup   bool    // グループ分けする列を複数回答として分割するか
+	Filter       *Filter
+	WeightColumn *Column // ウェイト列（nilの場合はウェイトなし）
+}
+
+// NumericStats は数値の記述統計量
+// 数値に変換できない値（空欄を含む）は N に含めず Missing に数える
+// 計算できない統計量（N が0の場合の各値、N が1の場合の標準偏差など）は NULL（Valid が false）のまま返す
+type NumericStats struct {
+	Group        string          // グループの値（全体の場合は空）
+	N            int             // 数値の件数
+	Missing      int             // 数値に変換できなかった件数
+	Mean         sql.NullFloat64 // 平均
+	Median       sql.NullFloat64 // 中央値
+	SD           sql.NullFloat64 // 標準偏差（不偏、N が1以下の場合はNULL）
+	Min          sql.NullFloat64
+	Max          sql.NullFloat64
+	Q1           sql.NullFloat64 // 第1四分位数
+	Q3           sql.NullFloat64 // 第3四分位数
+	WeightedMean sql.NullFloat64 // ウェイト付き平均（ウェイトなしの場合は平均と同じ）
+}
+
+// NumericStatsResult は数値の記述統計量の集計結果
+type NumericStatsResult struct {
+	Column       string
+	GroupColumn  string // グループ分けした列（全体のみの場合は空）
+	WeightColumn string // ウェイト列（空ならウェイトなし）
+	Overall      NumericStats
+	Groups       []NumericStats // グループの値ごとの統計量（値の表示順）
+}
+
+// IsGrouped はグループ分けした結果かどうかを返す
+func (r *NumericStatsResult) IsGrouped() bool {
+	return r.GroupColumn != ""
+}
+
+// IsWeighted はウェイト付きの結果かどうかを返す
+func (r *NumericStatsResult) IsWeighted() bool {
+	return r.WeightColumn != ""
+}
+
+// NumericStats は列の値を数値として記述統計量を集計する
+// GroupColumn を指定した場合は、全体に加えてグループの値ごと（クロス集計の行ごと）にも集計する
+func (a *Analyzer) NumericStats(config NumericStatsConfig) (*NumericStatsResult, error) {
+	if config.Column == nil {
+		return nil, fmt.Errorf("numeric stats requires a column")
+	}
+
+	result := &NumericStatsResult{Column: config.Column.Name}
+	if config.WeightColumn != nil {
+		result.WeightColumn = config.WeightColumn.Name
+	}
+
+	overall, err := a.queryNumericStats(config, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(overall) > 0 {
+		result.Overall = overall[0]
+	}
+
+	if config.GroupColumn == nil {
+		return result, nil
+	}
+	result.GroupColumn = config.GroupColumn.Name
+
+	// 派生列は merge タイプ以外は分割に対応しない
+	if config.GroupColumn.IsDerived && !config.GroupColumn.IsMulti {
+		config.SplitGroup = false
+	}
+
+	groups, err := a.queryNumericStats(config, true)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, len(groups))
+	byValue := make(map[string]NumericStats)
+	for i, g := range groups {
+		values[i] = g.Group
+		byValue[g.Group] = g
+	}
+	sortByOrder(values, a.GetValueOrder(config.GroupColumn.Name))
+	for _, value := range values {
+		result.Groups = append(result.Groups, byValue[value])
+	}
+
+	return result, nil
+}
+
+// queryNumericStats は記述統計量のSQLを実行する
+// grouped が false の場合は全体の1行を返す
+func (a *Analyzer) queryNumericStats(config NumericStatsConfig, grouped bool) ([]NumericStats, error) {
+	query := a.buildNumericStatsQuery(config, grouped)
+
+	rows, err := a.db.Query(query.SQL, query.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute numeric stats query: %w", err)
+	}
+	defer rows.Close()
+
+	var result []NumericStats
+	for rows.Next() {
+		var s NumericStats
+		if err := rows.Scan(&s.Group, &s.N, &s.Missing, &s.Mean, &s.Median, &s.SD, &s.Min, &s.Max, &s.Q1, &s.Q3, &s.WeightedMean); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		result = append(result, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return result, nil
+}
+
+// buildNumericStatsQuery は記述統計量のSQLを生成
+// 値は TRY_CAST で数値に変換し、変換できない値は欠損として数える
+// 計算できない統計量は0で埋めずにNULLのまま返す
+// 分割するグループの列は unnest で展開するため、1人の回答が複数のグループに入る
+func (a *Analyzer) buildNumericStatsQuery(config NumericStatsConfig, grouped bool) Expr {
+	conditions := []Expr{filterCondition(a, config.Filter)}
+	group := NewExpr("NULL")
+	groupValue := NewExpr("''")
+	sources := NewExpr("source_data")
+	var groupConditions []Expr
+	groupBy := Expr{}
+
+	if grouped {
+		// 派生列のNULLは値を評価した後に除外する（分割する場合はNULLが展開されないため不要）
+		conditions = append(conditions, notNullCondition(config.GroupColumn))
+		group = config.GroupColumn.GetSQLExpression()
+		groupValue = NewExpr("group_raw")
+		if config.SplitGroup {
+			sources = Exprf("source_data, unnest(string_split(group_raw, %s)) AS split_group(group_split)", splitSeparator(config.GroupColumn))
+			groupValue = NewExpr("split_group.group_split")
+		} else {
+			groupConditions = append(groupConditions, derivedNotNullCondition(config.GroupColumn, NewExpr("group_raw")))
+		}
+		groupBy = NewExpr("GROUP BY group_value")
+	}
+
+	sql := `
+		WITH source_data AS (
+			SELECT
+				TRY_CAST(%s AS DOUBLE) as value,
+				CAST(%s AS VARCHAR) as group_raw,
+				%s as weight
+			FROM %s
+			%s
+		)
+		SELECT
+			%s as group_value,
+			COUNT(value) as n,
+			COUNT(*) - COUNT(value) as missing,
+			AVG(value) as mean,
+			MEDIAN(value) as median,
+			STDDEV_SAMP(value) as sd,
+			MIN(value) as min,
+			MAX(value) as max,
+			QUANTILE_CONT(value, 0.25) as q1,
+			QUANTILE_CONT(value, 0.75) as q3,
+			SUM(value * weight) / NULLIF(SUM(CASE WHEN value IS NOT NULL THEN weight END), 0) as weighted_mean
+		FROM %s
+		%s
+		%s
+	`
+
+	return Exprf(sql,
+		config.Column.GetSQLExpression(),
+		group,
+		weightExpression(config.WeightColumn),
+		a.tableExpression(),
+		whereClause(conditions),
+		groupValue,
+		sources,
+		whereClause(groupConditions),
+		groupBy,
+	)
+}
@@ -0,0 +1,79 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultStopwords は語の出現頻度から常に除く語（どの回答にも出てくる語）
+var DefaultStopwords = []string{
+	"する", "ある", "いる", "なる", "できる", "れる", "られる", "せる", "おる",
+	"思う", "言う", "いう", "くる", "いく", "みる", "しまう",
+	"こと", "もの", "よう", "ため", "ところ", "とき", "感じ", "方", "点", "等",
+	"ない", "いい", "よい", "なし", "特に",
+}
+
+// StopwordConfig は語の出現頻度から除く語の設定
+type StopwordConfig struct {
+	Stopwords []string `yaml:"stopwords"`
+}
+
+// LoadStopwords は設定ファイルから除く語を読み込む
+func LoadStopwords(configPath string) ([]string, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config StopwordConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse yaml: %w", err)
+	}
+
+	return config.Stopwords, nil
+}
+
+// SaveStopwords は除く語を設定ファイルに書き込む
+func SaveStopwords(configPath string, stopwords []string) error {
+	config := StopwordConfig{
+		Stopwords: stopwords,
+	}
+
+	data, err := yaml.Marshal(&config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal yaml: %w", err)
+	}
+
+	// ヘッダーコメントを追加
+	header := "# 自由回答の語の出現頻度から除く語（原形で指定）\n# 「する」「こと」などの既定の語に加えて除外します\n\n"
+	data = append([]byte(header), data...)
+
+	if err := os.WriteFile(configPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	return nil
+}
+
+// ParseKeywords はカンマ・読点・改行で区切ったキーワードをリストにする
+// 前後の空白を除き、空の要素と重複は除く
+func ParseKeywords(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '、' || r == '，' || r == '\n' || r == '\r'
+	})
+
+	seen := make(map[string]bool)
+	var keywords []string
+	for _, f := range fields {
+		f = strings.TrimSpace(f)
+		if f == "" || seen[f] {
+			continue
+		}
+		seen[f] = true
+		keywords = append(keywords, f)
+	}
+	return keywords
+}
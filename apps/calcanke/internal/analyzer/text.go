@@ -0,0 +1,513 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 自由回答の分析の種類
+const (
+	TextAnalysisFrequency    = "frequency"     // 語の出現頻度
+	TextAnalysisContext      = "context"       // キーワードを含む回答の一覧（KWIC）
+	TextAnalysisKeywordCross = "keyword_cross" // キーワードを含む回答者の割合（バナーの値ごと）
+)
+
+const (
+	defaultWordLimit    = 100 // 頻度表の語数の既定値
+	defaultContextLimit = 200 // KWICの行数の既定値
+	contextWidth        = 20  // KWICのキーワードの前後に表示する文字数
+)
+
+// TextConfig は自由回答の分析の設定
+type TextConfig struct {
+	Column       *Column  // 自由回答の列
+	Keywords     []string // キーワード（context, keyword_cross で使用、部分一致）
+	BannerColumn *Column  // keyword_cross でクロス集計するバナーの列（nilの場合は全体のみ）
+	SplitBanner  bool     // バナーの列を複数回答として分割するか
+	Stopwords    []string // 頻度表から除く語（DefaultStopwords に加えて除く）
+	Limit        int      // 頻度表の語数・KWICの行数の上限（0の場合は既定値）
+	Filter       *Filter
+	WeightColumn *Column
+}
+
+// WordFrequencyRow は語の出現頻度の1行
+type WordFrequencyRow struct {
+	Word               string
+	PartOfSpeech       string
+	Occurrences        int     // 出現回数（延べ）
+	Count              int     // 語を含む回答者数
+	Percentage         float64 // 回答者数に対する割合
+	WeightedCount      float64 // ウェイト付きの回答者数（ウェイトなしの場合はCountと同じ）
+	WeightedPercentage float64
+}
+
+// WordFrequencyResult は自由回答の語の出現頻度
+type WordFrequencyResult struct {
+	Column              string
+	WeightColumn        string // ウェイト列（空の場合はウェイトなし）
+	Rows                []WordFrequencyRow
+	Respondents         int     // 回答のある回答者数
+	WeightedRespondents float64 // ウェイト付きの回答者数
+	DistinctWords       int     // 異なり語数（上限で打ち切る前）
+}
+
+// IsWeighted はウェイト付きの集計かどうかを返す
+func (r *WordFrequencyResult) IsWeighted() bool {
+	return r.WeightColumn != ""
+}
+
+// KeywordContext はKWICの1行（キーワードの出現箇所）
+type KeywordContext struct {
+	Left    string // キーワードの前の文字列
+	Keyword string
+	Right   string // キーワードの後の文字列
+}
+
+// KeywordContextResult はキーワードを含む回答の一覧
+type KeywordContextResult struct {
+	Column      string
+	Keywords    []string
+	Rows        []KeywordContext
+	Matches     int  // キーワードを含む回答者数
+	Respondents int  // 回答のある回答者数
+	Truncated   bool // 上限の行数で打ち切ったか
+}
+
+// KeywordCrosstabCell はキーワードのクロス集計の1セル
+// 割合は列（全体またはバナーの値）の回答者数に対する割合
+type KeywordCrosstabCell struct {
+	Count              int
+	Percentage         float64
+	WeightedCount      float64
+	WeightedPercentage float64
+}
+
+// KeywordCrosstabColumn はキーワードのクロス集計の列（全体、またはバナーの値）
+type KeywordCrosstabColumn struct {
+	Banner        string // バナーの値（全体の列は空）
+	Total         int    // 回答のある回答者数
+	WeightedTotal float64
+}
+
+// KeywordCrosstabRow はキーワードのクロス集計の1行
+type KeywordCrosstabRow struct {
+	Keyword string
+	Cells   []KeywordCrosstabCell // KeywordCrosstabResult.Columns の順
+}
+
+// KeywordCrosstabResult はキーワードを含む回答者の数と割合の表（行: キーワード、列: 全体とバナーの値）
+type KeywordCrosstabResult struct {
+	Column       string
+	BannerColumn string // バナーの列（全体のみの場合は空）
+	WeightColumn string // ウェイト列（空の場合はウェイトなし）
+	Columns      []KeywordCrosstabColumn
+	Rows         []KeywordCrosstabRow
+}
+
+// IsGrouped はバナーでクロス集計した結果かどうかを返す
+func (r *KeywordCrosstabResult) IsGrouped() bool {
+	return r.BannerColumn != ""
+}
+
+// IsWeighted はウェイト付きの集計かどうかを返す
+func (r *KeywordCrosstabResult) IsWeighted() bool {
+	return r.WeightColumn != ""
+}
+
+// textValueExpression は自由回答の列を文字列にする式を返す
+func textValueExpression(column *Column) Expr {
+	return Exprf("CAST(%s AS VARCHAR)", column.GetSQLExpression())
+}
+
+// textAnsweredCondition は自由回答の列に回答がある（空白だけでない）条件を返す
+func textAnsweredCondition(column *Column) Expr {
+	return Exprf("NULLIF(trim(%s), '') IS NOT NULL", textValueExpression(column))
+}
+
+// keywordCondition はいずれかのキーワードを含む条件を返す
+func keywordCondition(text Expr, keywords []string) Expr {
+	conditions := make([]Expr, len(keywords))
+	for i, keyword := range keywords {
+		conditions[i] = Exprf("contains(%s, %s)", text, Param(keyword))
+	}
+	return Exprf("(%s)", JoinExprs(conditions, " OR "))
+}
+
+// WordFrequency は自由回答を形態素解析して、語ごとに出現回数と語を含む回答者数を集計する
+// 回答者数の多い順に config.Limit 語まで返す
+func (a *Analyzer) WordFrequency(config TextConfig) (*WordFrequencyResult, error) {
+	if config.Column == nil {
+		return nil, fmt.Errorf("word frequency requires a column")
+	}
+
+	query := Exprf("SELECT %s as text, %s as weight FROM %s %s",
+		textValueExpression(config.Column),
+		weightExpression(config.WeightColumn),
+		a.tableExpression(),
+		whereClause([]Expr{textAnsweredCondition(config.Column), filterCondition(a, config.Filter)}),
+	)
+
+	rows, err := a.db.Query(query.SQL, query.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute word frequency query: %w", err)
+	}
+	defer rows.Close()
+
+	stopwords := make(map[string]bool)
+	for _, w := range append(append([]string{}, DefaultStopwords...), config.Stopwords...) {
+		stopwords[w] = true
+	}
+
+	result := &WordFrequencyResult{Column: config.Column.Name}
+	if config.WeightColumn != nil {
+		result.WeightColumn = config.WeightColumn.Name
+	}
+
+	byWord := make(map[string]*WordFrequencyRow)
+	for rows.Next() {
+		var text string
+		var weight float64
+		if err := rows.Scan(&text, &weight); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		result.Respondents++
+		result.WeightedRespondents += weight
+
+		words, err := ExtractWords(text)
+		if err != nil {
+			return nil, err
+		}
+
+		// 同じ回答に同じ語が何度出ても回答者数は1と数える
+		seen := make(map[string]bool)
+		for _, w := range words {
+			if stopwords[w.Base] {
+				continue
+			}
+			row, exists := byWord[w.Base]
+			if !exists {
+				row = &WordFrequencyRow{Word: w.Base, PartOfSpeech: w.PartOfSpeech}
+				byWord[w.Base] = row
+			}
+			row.Occurrences++
+			if !seen[w.Base] {
+				seen[w.Base] = true
+				row.Count++
+				row.WeightedCount += weight
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	for _, row := range byWord {
+		row.Percentage = percentage(float64(row.Count), float64(result.Respondents))
+		row.WeightedPercentage = percentage(row.WeightedCount, result.WeightedRespondents)
+		result.Rows = append(result.Rows, *row)
+	}
+	sort.Slice(result.Rows, func(i, j int) bool {
+		ri, rj := result.Rows[i], result.Rows[j]
+		if ri.Count != rj.Count {
+			return ri.Count > rj.Count
+		}
+		if ri.Occurrences != rj.Occurrences {
+			return ri.Occurrences > rj.Occurrences
+		}
+		return ri.Word < rj.Word
+	})
+
+	result.DistinctWords = len(result.Rows)
+	limit := config.Limit
+	if limit <= 0 {
+		limit = defaultWordLimit
+	}
+	if len(result.Rows) > limit {
+		result.Rows = result.Rows[:limit]
+	}
+
+	return result, nil
+}
+
+// KeywordContexts はキーワードを含む回答について、キーワードの出現箇所ごとに前後の文字列を返す（KWIC）
+// 1つの回答に複数回出現する場合はそれぞれ1行とし、config.Limit 行で打ち切る
+func (a *Analyzer) KeywordContexts(config TextConfig) (*KeywordContextResult, error) {
+	if config.Column == nil {
+		return nil, fmt.Errorf("keyword context requires a column")
+	}
+	if len(config.Keywords) == 0 {
+		return nil, fmt.Errorf("keyword context requires at least one keyword")
+	}
+
+	respondents, _, err := a.countTextRespondents(config)
+	if err != nil {
+		return nil, err
+	}
+
+	text := textValueExpression(config.Column)
+	query := Exprf("SELECT %s as text FROM %s %s",
+		text,
+		a.tableExpression(),
+		whereClause([]Expr{
+			textAnsweredCondition(config.Column),
+			filterCondition(a, config.Filter),
+			keywordCondition(text, config.Keywords),
+		}),
+	)
+
+	rows, err := a.db.Query(query.SQL, query.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute keyword context query: %w", err)
+	}
+	defer rows.Close()
+
+	result := &KeywordContextResult{
+		Column:      config.Column.Name,
+		Keywords:    config.Keywords,
+		Respondents: respondents,
+	}
+	limit := config.Limit
+	if limit <= 0 {
+		limit = defaultContextLimit
+	}
+
+	for rows.Next() {
+		var text string
+		if err := rows.Scan(&text); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		result.Matches++
+
+		for _, context := range keywordContexts(text, config.Keywords) {
+			if len(result.Rows) >= limit {
+				result.Truncated = true
+				break
+			}
+			result.Rows = append(result.Rows, context)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return result, nil
+}
+
+// keywordContexts は回答の中のキーワードの出現箇所を前後の文字列とともに返す（出現順）
+// 改行は空白に置き換え、前後は contextWidth 文字まで
+func keywordContexts(text string, keywords []string) []KeywordContext {
+	text = strings.Join(strings.Fields(text), " ")
+
+	type match struct {
+		start   int
+		keyword string
+	}
+	var matches []match
+	for _, keyword := range keywords {
+		for offset := 0; offset < len(text); {
+			i := strings.Index(text[offset:], keyword)
+			if i < 0 {
+				break
+			}
+			matches = append(matches, match{start: offset + i, keyword: keyword})
+			offset += i + len(keyword)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].start < matches[j].start
+	})
+
+	contexts := make([]KeywordContext, len(matches))
+	for i, m := range matches {
+		left := []rune(text[:m.start])
+		right := []rune(text[m.start+len(m.keyword):])
+		if len(left) > contextWidth {
+			left = left[len(left)-contextWidth:]
+		}
+		if len(right) > contextWidth {
+			right = right[:contextWidth]
+		}
+		contexts[i] = KeywordContext{Left: string(left), Keyword: m.keyword, Right: string(right)}
+	}
+	return contexts
+}
+
+// countTextRespondents は自由回答の列に回答がある回答者の数（とウェイト付きの数）を数える
+func (a *Analyzer) countTextRespondents(config TextConfig) (int, float64, error) {
+	query := Exprf("SELECT COUNT(*), COALESCE(SUM(%s), 0) FROM %s %s",
+		weightExpression(config.WeightColumn),
+		a.tableExpression(),
+		whereClause([]Expr{textAnsweredCondition(config.Column), filterCondition(a, config.Filter)}),
+	)
+
+	var respondents int
+	var weighted float64
+	if err := a.db.QueryRow(query.SQL, query.Args...).Scan(&respondents, &weighted); err != nil {
+		return 0, 0, fmt.Errorf("failed to count respondents: %w", err)
+	}
+	return respondents, weighted, nil
+}
+
+// KeywordCrosstab はキーワードごとに、キーワードを含む回答者の数と割合を全体とバナーの値ごとに集計する
+// 割合の分母は列（全体、バナーの値）の回答のある回答者数
+func (a *Analyzer) KeywordCrosstab(config TextConfig) (*KeywordCrosstabResult, error) {
+	if config.Column == nil {
+		return nil, fmt.Errorf("keyword crosstab requires a column")
+	}
+	if len(config.Keywords) == 0 {
+		return nil, fmt.Errorf("keyword crosstab requires at least one keyword")
+	}
+
+	result := &KeywordCrosstabResult{Column: config.Column.Name}
+	if config.WeightColumn != nil {
+		result.WeightColumn = config.WeightColumn.Name
+	}
+
+	overall, err := a.queryKeywordCounts(config, false)
+	if err != nil {
+		return nil, err
+	}
+	counts := overall
+
+	if config.BannerColumn != nil {
+		result.BannerColumn = config.BannerColumn.Name
+
+		// 派生列は merge タイプ以外は分割に対応しない
+		if config.BannerColumn.IsDerived && !config.BannerColumn.IsMulti {
+			config.SplitBanner = false
+		}
+
+		groups, err := a.queryKeywordCounts(config, true)
+		if err != nil {
+			return nil, err
+		}
+		banners := make([]string, len(groups))
+		byBanner := make(map[string]keywordCounts)
+		for i, g := range groups {
+			banners[i] = g.banner
+			byBanner[g.banner] = g
+		}
+		sortByOrder(banners, a.GetValueOrder(config.BannerColumn.Name))
+		for _, banner := range banners {
+			counts = append(counts, byBanner[banner])
+		}
+	}
+
+	for _, c := range counts {
+		result.Columns = append(result.Columns, KeywordCrosstabColumn{Banner: c.banner, Total: c.total, WeightedTotal: c.weightedTotal})
+	}
+	for i, keyword := range config.Keywords {
+		row := KeywordCrosstabRow{Keyword: keyword}
+		for _, c := range counts {
+			row.Cells = append(row.Cells, KeywordCrosstabCell{
+				Count:              c.counts[i],
+				Percentage:         percentage(float64(c.counts[i]), float64(c.total)),
+				WeightedCount:      c.weighted[i],
+				WeightedPercentage: percentage(c.weighted[i], c.weightedTotal),
+			})
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	return result, nil
+}
+
+// keywordCounts は1列分（全体、またはバナーの値）のキーワードを含む回答者数
+type keywordCounts struct {
+	banner        string
+	total         int
+	weightedTotal float64
+	counts        []int     // キーワードの順
+	weighted      []float64 // キーワードの順
+}
+
+// queryKeywordCounts はキーワードを含む回答者数を集計する
+// grouped が false の場合は全体の1行、true の場合はバナーの値ごとの行を返す
+func (a *Analyzer) queryKeywordCounts(config TextConfig, grouped bool) ([]keywordCounts, error) {
+	conditions := []Expr{textAnsweredCondition(config.Column), filterCondition(a, config.Filter)}
+	banner := NewExpr("NULL")
+	bannerValue := NewExpr("''")
+	sources := NewExpr("source_data")
+	var bannerConditions []Expr
+	groupBy := Expr{}
+
+	if grouped {
+		// 派生列のNULLは値を評価した後に除外する（分割する場合はNULLが展開されないため不要）
+		conditions = append(conditions, notNullCondition(config.BannerColumn))
+		banner = config.BannerColumn.GetSQLExpression()
+		bannerValue = NewExpr("banner_raw")
+		if config.SplitBanner {
+			sources = Exprf("source_data, unnest(string_split(banner_raw, %s)) AS split_banner(banner_split)", splitSeparator(config.BannerColumn))
+			bannerValue = NewExpr("split_banner.banner_split")
+		} else {
+			bannerConditions = append(bannerConditions, derivedNotNullCondition(config.BannerColumn, NewExpr("banner_raw")))
+		}
+		groupBy = NewExpr("GROUP BY banner_value")
+	}
+
+	var aggregates []Expr
+	for _, keyword := range config.Keywords {
+		aggregates = append(aggregates,
+			Exprf("COUNT(*) FILTER (WHERE contains(text, %s))", Param(keyword)),
+			Exprf("COALESCE(SUM(weight) FILTER (WHERE contains(text, %s)), 0)", Param(keyword)),
+		)
+	}
+
+	query := Exprf(`
+		WITH source_data AS (
+			SELECT
+				%s as text,
+				CAST(%s AS VARCHAR) as banner_raw,
+				%s as weight
+			FROM %s
+			%s
+		)
+		SELECT
+			%s as banner_value,
+			COUNT(*) as total,
+			COALESCE(SUM(weight), 0) as weighted_total,
+			%s
+		FROM %s
+		%s
+		%s
+	`,
+		textValueExpression(config.Column),
+		banner,
+		weightExpression(config.WeightColumn),
+		a.tableExpression(),
+		whereClause(conditions),
+		bannerValue,
+		JoinExprs(aggregates, ",\n\t\t\t"),
+		sources,
+		whereClause(bannerConditions),
+		groupBy,
+	)
+
+	rows, err := a.db.Query(query.SQL, query.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute keyword crosstab query: %w", err)
+	}
+	defer rows.Close()
+
+	var result []keywordCounts
+	for rows.Next() {
+		c := keywordCounts{
+			counts:   make([]int, len(config.Keywords)),
+			weighted: make([]float64, len(config.Keywords)),
+		}
+		dest := []interface{}{&c.banner, &c.total, &c.weightedTotal}
+		for i := range config.Keywords {
+			dest = append(dest, &c.counts[i], &c.weighted[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		result = append(result, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,86 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ikawaha/kagome-dict/ipa"
+	"github.com/ikawaha/kagome/v2/tokenizer"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Word は形態素解析で取り出した語
+type Word struct {
+	Base         string // 原形（活用しない語は表層形）
+	PartOfSpeech string // 品詞（名詞・動詞・形容詞）
+}
+
+var (
+	tokenizerOnce   sync.Once
+	sharedTokenizer *tokenizer.Tokenizer
+	tokenizerErr    error
+)
+
+// getTokenizer は形態素解析器を返す
+// 辞書の読み込みに時間がかかるため、初回の呼び出しで作成したものを使い回す
+func getTokenizer() (*tokenizer.Tokenizer, error) {
+	tokenizerOnce.Do(func() {
+		sharedTokenizer, tokenizerErr = tokenizer.New(ipa.Dict(), tokenizer.OmitBosEos())
+		if tokenizerErr != nil {
+			tokenizerErr = fmt.Errorf("failed to create tokenizer: %w", tokenizerErr)
+		}
+	})
+	return sharedTokenizer, tokenizerErr
+}
+
+// ExtractWords は文章を形態素解析して内容語（名詞・動詞・形容詞）を原形で取り出す
+// 全角英数字などはNFKCで正規化し、英字は小文字にそろえる
+// 数・代名詞・非自立語・接尾辞や、記号・助詞・助動詞は取り出さない
+func ExtractWords(text string) ([]Word, error) {
+	t, err := getTokenizer()
+	if err != nil {
+		return nil, err
+	}
+
+	text = strings.ToLower(norm.NFKC.String(text))
+
+	var words []Word
+	for _, token := range t.Tokenize(text) {
+		pos := token.POS()
+		if len(pos) == 0 || !isContentWord(pos) {
+			continue
+		}
+
+		base, ok := token.BaseForm()
+		if !ok || base == "*" {
+			base = token.Surface
+		}
+		base = strings.TrimSpace(base)
+		if base == "" {
+			continue
+		}
+		words = append(words, Word{Base: base, PartOfSpeech: pos[0]})
+	}
+	return words, nil
+}
+
+// isContentWord は品詞（IPA辞書の品詞, 品詞細分類1, ...）が頻度表の対象になる内容語かどうかを返す
+func isContentWord(pos []string) bool {
+	sub := ""
+	if len(pos) > 1 {
+		sub = pos[1]
+	}
+
+	switch pos[0] {
+	case "名詞":
+		switch sub {
+		case "数", "代名詞", "非自立", "接尾", "特殊":
+			return false
+		}
+		return true
+	case "動詞", "形容詞":
+		return sub == "自立"
+	}
+	return false
+}
@@ -1,29 +1,68 @@
 package analyzer
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // Column は列の情報を保持
 type Column struct {
-	Index       int    // 1始まりの列番号
-	Name        string // 列名
-	Type        string // データ型（VARCHAR, INTEGER等）
-	IsMulti     bool   // 複数回答かどうか（改行含む割合で判定）
-	UniqueCount int    // ユニーク値の数
-	IsDerived   bool   // 派生列かどうか
-	SQLExpr     string // 派生列の場合のSQL式（CASE式など）
+	Index       int           // 1始まりの列番号
+	Name        string        // 列名
+	Type        string        // データ型（VARCHAR, INTEGER等）
+	IsMulti     bool          // 複数回答かどうか（改行含む割合で判定）
+	UniqueCount int           // ユニーク値の数
+	IsDerived   bool          // 派生列かどうか
+	SQLExpr     string        // 派生列の場合のSQL式（CASE式など）
+	SQLArgs     []interface{} // SQLExprのバインド引数
 }
 
-// GetSQLExpression はSQL SELECT句で使用する式を返す
-func (c *Column) GetSQLExpression() string {
+// GetSQLExpression はSQL SELECT句で使用する式とバインド引数を返す
+func (c *Column) GetSQLExpression() Expr {
 	if c.IsDerived {
-		return c.SQLExpr
+		return Expr{SQL: c.SQLExpr, Args: c.SQLArgs}
 	}
-	return fmt.Sprintf(`"%s"`, c.Name)
+	return Ident(c.Name)
+}
+
+// IsNumeric は数値型の列かどうかを返す（ウェイト列の候補に使用）
+func (c *Column) IsNumeric() bool {
+	if c.IsDerived {
+		return false
+	}
+	t := strings.ToUpper(c.Type)
+	for _, prefix := range []string{"TINYINT", "SMALLINT", "INTEGER", "BIGINT", "HUGEINT", "UTINYINT", "USMALLINT", "UINTEGER", "UBIGINT", "FLOAT", "DOUBLE", "DECIMAL", "REAL"} {
+		if strings.HasPrefix(t, prefix) {
+			return true
+		}
+	}
+	return false
 }
 
 // ColumnList は列の一覧
 type ColumnList []Column
 
+// FindByName は列名で列を探す（見つからない場合はnil）
+func (cl ColumnList) FindByName(name string) *Column {
+	for i := range cl {
+		if cl[i].Name == name {
+			return &cl[i]
+		}
+	}
+	return nil
+}
+
+// NumericColumns は数値型の列だけを返す
+func (cl ColumnList) NumericColumns() ColumnList {
+	var result ColumnList
+	for _, col := range cl {
+		if col.IsNumeric() {
+			result = append(result, col)
+		}
+	}
+	return result
+}
+
 // ToOptions は survey 用のオプション文字列に変換
 func (cl ColumnList) ToOptions() []string {
 	options := make([]string, len(cl))
@@ -48,46 +87,161 @@ type AnalysisConfig struct {
 	// 集計対象列
 	XColumn *Column
 	YColumn *Column
+	ZColumn *Column // 層（3重クロス集計のみ、nilの場合は層なし）
 
 	// オプション
 	SplitX bool // X軸を分割（複数回答）
 	SplitY bool // Y軸を分割
+	SplitZ bool // 層を分割
 
 	// フィルタ
 	Filter *Filter // 適用するフィルタ（nilの場合はフィルタなし）
 
+	// ウェイト
+	WeightColumn *Column // ウェイト列（nilの場合はウェイトなし）
+
+	// 割合の基準（クロス集計のみ、空の場合は行%）
+	PercentageBase PercentageBase
+
 	// 出力
 	OutputPath string // CSVエクスポート先（空なら画面表示のみ）
 }
 
+// PercentageBase はクロス集計の割合の基準
+type PercentageBase string
+
+const (
+	PercentageBaseRow    PercentageBase = "row"    // 行%（X値ごとの合計を100%とする）
+	PercentageBaseColumn PercentageBase = "column" // 列%（Y値ごとの合計を100%とする）
+	PercentageBaseTotal  PercentageBase = "total"  // 全体%（総数を100%とする）
+)
+
+// PercentageBases は選択できる割合の基準の一覧
+var PercentageBases = []PercentageBase{PercentageBaseRow, PercentageBaseColumn, PercentageBaseTotal}
+
+// ParsePercentageBase は割合の基準を解釈する（空の場合は行%）
+func ParsePercentageBase(s string) (PercentageBase, error) {
+	switch PercentageBase(strings.ToLower(s)) {
+	case "", PercentageBaseRow:
+		return PercentageBaseRow, nil
+	case PercentageBaseColumn:
+		return PercentageBaseColumn, nil
+	case PercentageBaseTotal:
+		return PercentageBaseTotal, nil
+	default:
+		return "", fmt.Errorf("unsupported percentage base: %s (supported: row, column, total)", s)
+	}
+}
+
+// OrDefault は未指定の場合に行%を返す
+func (b PercentageBase) OrDefault() PercentageBase {
+	if b == "" {
+		return PercentageBaseRow
+	}
+	return b
+}
+
+// Label は表示用の名前を返す
+func (b PercentageBase) Label() string {
+	switch b.OrDefault() {
+	case PercentageBaseColumn:
+		return "列%"
+	case PercentageBaseTotal:
+		return "全体%"
+	default:
+		return "行%"
+	}
+}
+
 // CrosstabResult はクロス集計の結果
 type CrosstabResult struct {
-	XColumn string
-	YColumn string
-	Rows    []CrosstabRow
-	Total   int
+	XColumn        string
+	YColumn        string
+	Rows           []CrosstabRow
+	Total          int
+	WeightColumn   string         // ウェイト列（空の場合はウェイトなし）
+	WeightedTotal  float64        // ウェイト付きの総数
+	PercentageBase PercentageBase // 表示する割合の基準
+	Split          bool           // X軸・Y軸のどちらかを複数回答として分割して集計したか
 }
 
 // CrosstabRow はクロス集計の1行
+// 割合は行%・列%・全体%の3つの基準すべてを持つ
 type CrosstabRow struct {
-	XValue     string
-	YValue     string
-	Count      int
-	Percentage float64 // X値内での割合
+	XValue                   string
+	YValue                   string
+	Count                    int     // ウェイトなしの件数（n）
+	Percentage               float64 // X値内での割合（行%）
+	ColumnPercentage         float64 // Y値内での割合（列%）
+	TotalPercentage          float64 // 総数に対する割合（全体%）
+	WeightedCount            float64 // ウェイト付きの件数（ウェイトなしの場合はCountと同じ）
+	WeightedPercentage       float64 // X値内でのウェイト付きの割合
+	WeightedColumnPercentage float64 // Y値内でのウェイト付きの割合
+	WeightedTotalPercentage  float64 // ウェイト付きの総数に対する割合
+}
+
+// PercentageFor は指定した基準の割合を返す
+func (r CrosstabRow) PercentageFor(base PercentageBase) float64 {
+	switch base.OrDefault() {
+	case PercentageBaseColumn:
+		return r.ColumnPercentage
+	case PercentageBaseTotal:
+		return r.TotalPercentage
+	default:
+		return r.Percentage
+	}
+}
+
+// WeightedPercentageFor は指定した基準のウェイト付きの割合を返す
+func (r CrosstabRow) WeightedPercentageFor(base PercentageBase) float64 {
+	switch base.OrDefault() {
+	case PercentageBaseColumn:
+		return r.WeightedColumnPercentage
+	case PercentageBaseTotal:
+		return r.WeightedTotalPercentage
+	default:
+		return r.WeightedPercentage
+	}
+}
+
+// IsWeighted はウェイト付きの集計かどうかを返す
+func (r *CrosstabResult) IsWeighted() bool {
+	return r.WeightColumn != ""
 }
 
 // SimpletabResult は単純集計の結果
+// 複数回答を分割した場合、Total は回答数（延べ数）、Respondents は回答者数
 type SimpletabResult struct {
-	Column string
-	Rows   []SimpletabRow
-	Total  int
+	Column              string
+	Rows                []SimpletabRow
+	Total               int
+	WeightColumn        string  // ウェイト列（空の場合はウェイトなし）
+	WeightedTotal       float64 // ウェイト付きの総数
+	Split               bool    // 複数回答を分割して集計したか
+	Respondents         int     // 回答者数（分割しない場合は Total と同じ）
+	WeightedRespondents float64 // ウェイト付きの回答者数
 }
 
 // SimpletabRow は単純集計の1行
+// Percentage は回答数ベース、RespondentPercentage は回答者数ベースの割合（分割しない場合は同じ値）
 type SimpletabRow struct {
-	Value      string
-	Count      int
-	Percentage float64
+	Value                        string
+	Count                        int // ウェイトなしの件数（n）
+	Percentage                   float64
+	WeightedCount                float64 // ウェイト付きの件数（ウェイトなしの場合はCountと同じ）
+	WeightedPercentage           float64
+	RespondentPercentage         float64 // 回答者数に対する割合
+	WeightedRespondentPercentage float64 // ウェイト付きの回答者数に対する割合
+}
+
+// IsWeighted はウェイト付きの集計かどうかを返す
+func (r *SimpletabResult) IsWeighted() bool {
+	return r.WeightColumn != ""
+}
+
+// IsSplit は複数回答を分割した集計かどうかを返す
+func (r *SimpletabResult) IsSplit() bool {
+	return r.Split
 }
 
 // SortByAnalyzer は列の値の表示順序に従って行をソートする
@@ -111,11 +265,33 @@ type CrosstabPivot struct {
 	YValues []string                           // Y軸の値リスト（ソート済み）
 	Matrix  map[string]map[string]CrosstabCell // [X値][Y値] -> Cell
 	Total   int
+
+	WeightColumn  string  // ウェイト列（空の場合はウェイトなし）
+	WeightedTotal float64 // ウェイト付きの総数
+
+	// 割合の基準と周辺度数
+	// セルの Percentage / WeightedPercentage は PercentageBase の基準の割合
+	PercentageBase PercentageBase
+	RowTotals      map[string]CrosstabCell // [X値] -> X値ごとの合計（表の「全体」列）
+	ColumnTotals   map[string]CrosstabCell // [Y値] -> Y値ごとの合計（表の先頭の「全体」行）
+	GrandTotal     CrosstabCell            // 総数（「全体」行と「全体」列の交点）
+
+	Split        bool              // 複数回答を分割した表か（件数が回答者ではなく回答の数のため検定しない）
+	Significance *SignificanceTest // カイ二乗検定の結果（検定できない表・複数回答を分割した表の場合はnil）
+}
+
+// IsWeighted はウェイト付きの集計かどうかを返す
+func (p *CrosstabPivot) IsWeighted() bool {
+	return p.WeightColumn != ""
 }
 
 // CrosstabCell はピボット表の1セル
 type CrosstabCell struct {
-	Count      int
-	Percentage float64
-	Exists     bool // データが存在するか（0件とデータなしを区別）
+	Count              int
+	Percentage         float64
+	WeightedCount      float64
+	WeightedPercentage float64
+	Exists             bool    // データが存在するか（0件とデータなしを区別）
+	AdjustedResidual   float64 // 調整済み残差
+	Marker             string  // 有意差のマーカー（▲▲/▲/▼/▼▼、有意でなければ空）
 }
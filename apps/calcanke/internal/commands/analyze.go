@@ -1,13 +1,18 @@
 package commands
 
 import (
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/exporter"
 	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 var (
-	analyzeDBPath string
-	analyzeTable  string
+	analyzeDBPath  string
+	analyzeTable   string
+	analyzeOutput  string
+	analyzeWeight  string
+	analyzePercent string
 )
 
 // NewAnalyzeCmd はanalyzeコマンドを作成
@@ -21,10 +26,29 @@ func NewAnalyzeCmd() *cobra.Command {
 
 	cmd.Flags().StringVar(&analyzeDBPath, "db", "data/app.duckdb", "DuckDBデータベースのパス")
 	cmd.Flags().StringVar(&analyzeTable, "table", "excel_import", "テーブル名")
+	cmd.Flags().StringVarP(&analyzeOutput, "output", "o", "", "集計結果の出力先（.csv または .xlsx）")
+	cmd.Flags().StringVar(&analyzeWeight, "weight", "", "ウェイト列名（指定するとウェイト付きの件数・割合も集計）")
+	cmd.Flags().StringVar(&analyzePercent, "percent", "row", "クロス集計の割合の基準（row: 行%, column: 列%, total: 全体%）")
 
 	return cmd
 }
 
 func runAnalyze(cmd *cobra.Command, args []string) error {
-	return ui.RunInteractive(analyzeDBPath, analyzeTable)
+	// 出力形式を事前に確認（集計後にエラーにならないように）
+	if analyzeOutput != "" {
+		if _, err := exporter.FormatFromPath(analyzeOutput); err != nil {
+			return err
+		}
+	}
+
+	percentageBase, err := analyzer.ParsePercentageBase(analyzePercent)
+	if err != nil {
+		return err
+	}
+
+	return ui.RunInteractive(analyzeDBPath, analyzeTable, ui.Options{
+		OutputPath:     analyzeOutput,
+		WeightColumn:   analyzeWeight,
+		PercentageBase: percentageBase,
+	})
 }
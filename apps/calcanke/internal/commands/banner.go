@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/exporter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bannerDBPath  string
+	bannerTable   string
+	bannerOutput  string
+	bannerWeight  string
+	bannerBanners []string
+	bannerStubs   []string
+)
+
+// NewBannerCmd はbannerコマンドを作成
+func NewBannerCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "banner",
+		Short: "バナー表（表頭×表側の集計表）を出力",
+		Long:  "表側の設問ごとに、全体と表頭の各列の値ごとの集計を1シートにまとめたファイルを出力します。",
+		RunE:  runBanner,
+	}
+
+	cmd.Flags().StringVar(&bannerDBPath, "db", "data/app.duckdb", "DuckDBデータベースのパス")
+	cmd.Flags().StringVar(&bannerTable, "table", "excel_import", "テーブル名")
+	cmd.Flags().StringVarP(&bannerOutput, "output", "o", "data/banner.xlsx", "出力先（.csv または .xlsx）")
+	cmd.Flags().StringVar(&bannerWeight, "weight", "", "ウェイト列名（指定するとウェイト付きの件数・割合も集計）")
+	cmd.Flags().StringSliceVar(&bannerBanners, "banner", nil, "表頭の列名（複数指定可、カンマ区切りも可）")
+	cmd.Flags().StringSliceVar(&bannerStubs, "stub", nil, "表側の列名（複数指定可、カンマ区切りも可）")
+	cmd.MarkFlagRequired("banner")
+	cmd.MarkFlagRequired("stub")
+
+	return cmd
+}
+
+func runBanner(cmd *cobra.Command, args []string) error {
+	// 出力形式を事前に確認（集計後にエラーにならないように）
+	if _, err := exporter.FormatFromPath(bannerOutput); err != nil {
+		return err
+	}
+
+	a, err := analyzer.NewAnalyzer(bannerDBPath, bannerTable)
+	if err != nil {
+		return fmt.Errorf("failed to initialize analyzer: %w", err)
+	}
+	defer a.Close()
+
+	columns, err := a.GetColumns()
+	if err != nil {
+		return fmt.Errorf("failed to get columns: %w", err)
+	}
+
+	config := analyzer.BannerTableConfig{}
+	if config.Banners, err = findColumns(columns, bannerBanners); err != nil {
+		return err
+	}
+	if config.Stubs, err = findColumns(columns, bannerStubs); err != nil {
+		return err
+	}
+	if bannerWeight != "" {
+		if config.WeightColumn = columns.FindByName(bannerWeight); config.WeightColumn == nil {
+			return fmt.Errorf("weight column not found: %s", bannerWeight)
+		}
+	}
+
+	book, err := a.BannerTables(config)
+	if err != nil {
+		return err
+	}
+
+	if err := exporter.WriteFile(bannerOutput, exporter.BannerTableSheets(book, nil)); err != nil {
+		return fmt.Errorf("failed to export banner tables: %w", err)
+	}
+
+	fmt.Printf("✓ %d問のバナー表を %s に出力しました\n", len(book.Tables), bannerOutput)
+	return nil
+}
+
+// findColumns は列名のリストから列を取得する
+func findColumns(columns analyzer.ColumnList, names []string) ([]*analyzer.Column, error) {
+	result := make([]*analyzer.Column, 0, len(names))
+	for _, name := range names {
+		column := columns.FindByName(name)
+		if column == nil {
+			return nil, fmt.Errorf("column not found: %s", name)
+		}
+		result = append(result, column)
+	}
+	return result, nil
+}
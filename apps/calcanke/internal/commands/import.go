@@ -1,35 +1,44 @@
 package commands
 
 import (
-	"database/sql"
 	"fmt"
 	"os"
 
 	"github.com/joho/godotenv"
-	_ "github.com/marcboeker/go-duckdb"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/importer"
 	"github.com/spf13/cobra"
 )
 
 var (
-	excelPath    string
-	sheetName    string
-	importDBPath string
-	targetTable  string
+	importPath      string
+	excelPath       string
+	sheetName       string
+	importDBPath    string
+	targetTable     string
+	importFormat    string
+	importEncoding  string
+	importDelimiter string
+	importReplace   bool
 )
 
 // NewImportCmd はimportコマンドを作成
 func NewImportCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "import",
-		Short: "ExcelファイルをDuckDBにインポート",
-		Long:  "ExcelファイルをDuckDBデータベースにインポートします",
+		Short: "データファイルをDuckDBにインポート",
+		Long:  "Excel・CSV・TSV・Parquet・JSON LinesのファイルをDuckDBデータベースにインポートします",
 		RunE:  runImport,
 	}
 
-	cmd.Flags().StringVar(&excelPath, "excel", "", "Excelファイルのパス（.envから読み込み可）")
-	cmd.Flags().StringVar(&sheetName, "sheet", "", "シート名（.envから読み込み可）")
+	cmd.Flags().StringVar(&importPath, "file", "", "インポートするファイルのパス（.envの IMPORT_PATH から読み込み可）")
+	cmd.Flags().StringVar(&excelPath, "excel", "", "Excelファイルのパス（--file と同じ。.envの EXCEL_PATH から読み込み可）")
+	cmd.Flags().StringVar(&sheetName, "sheet", "", "シート名（Excelのみ。.envから読み込み可）")
 	cmd.Flags().StringVar(&importDBPath, "db", "", "DuckDBデータベースのパス（.envから読み込み可）")
 	cmd.Flags().StringVar(&targetTable, "table", "", "テーブル名（.envから読み込み可）")
+	cmd.Flags().StringVar(&importFormat, "format", "", "ファイル形式: xlsx, csv, tsv, parquet, jsonl（省略時は拡張子で判定）")
+	cmd.Flags().StringVar(&importEncoding, "encoding", "", "CSV/TSVの文字コード: utf-8, shift_jis（省略時は自動判定）")
+	cmd.Flags().StringVar(&importDelimiter, "delimiter", "", "CSVの区切り文字（省略時は自動判定）")
+	cmd.Flags().BoolVar(&importReplace, "replace", false, "既存のテーブルを作り直す（省略時は追記）")
 
 	return cmd
 }
@@ -39,8 +48,14 @@ func runImport(cmd *cobra.Command, args []string) error {
 	_ = godotenv.Load()
 
 	// フラグまたは環境変数から値を取得
-	if excelPath == "" {
-		excelPath = os.Getenv("EXCEL_PATH")
+	if importPath == "" {
+		importPath = excelPath
+	}
+	if importPath == "" {
+		importPath = os.Getenv("IMPORT_PATH")
+	}
+	if importPath == "" {
+		importPath = os.Getenv("EXCEL_PATH")
 	}
 	if sheetName == "" {
 		sheetName = os.Getenv("SHEET_NAME")
@@ -58,77 +73,47 @@ func runImport(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if excelPath == "" {
-		return fmt.Errorf("EXCEL_PATH is required (set via flag or .env)")
-	}
-
-	// DuckDB接続
-	dsn := importDBPath
-	db, err := sql.Open("duckdb", dsn)
-	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
+	if importPath == "" {
+		return fmt.Errorf("IMPORT_PATH (or EXCEL_PATH) is required (set via flag or .env)")
 	}
-	defer db.Close()
 
-	// 拡張機能の自動インストールを有効化
-	if _, err := db.Exec("SET autoinstall_known_extensions = true;"); err != nil {
-		return fmt.Errorf("set autoinstall failed: %w", err)
+	// ファイル形式を判定
+	var format importer.Format
+	var err error
+	if importFormat != "" {
+		format, err = importer.ParseFormat(importFormat)
+	} else {
+		format, err = importer.DetectFormat(importPath)
 	}
-	if _, err := db.Exec("SET autoload_known_extensions = true;"); err != nil {
-		return fmt.Errorf("set autoload failed: %w", err)
+	if err != nil {
+		return err
 	}
 
-	// INSTALL と LOAD spatial 拡張機能
-	if _, err := db.Exec("INSTALL spatial;"); err != nil {
-		return fmt.Errorf("install spatial failed: %w", err)
-	}
-	if _, err := db.Exec("LOAD spatial;"); err != nil {
-		return fmt.Errorf("load spatial failed: %w", err)
+	src, err := importer.Open(importPath, format, importer.Options{
+		Encoding:  importEncoding,
+		Delimiter: importDelimiter,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", importPath, err)
 	}
+	defer src.Close()
 
-	// テーブルが無ければスキーマを作る
-	createSQL := "CREATE TABLE IF NOT EXISTS " + targetTable + " AS SELECT * FROM st_read(?" +
-		func() string {
-			if sheetName != "" {
-				return ", layer:=?"
-			}
-			return ""
-		}() +
-		") LIMIT 0"
-
-	var errExec error
-	if sheetName != "" {
-		_, errExec = db.Exec(createSQL, excelPath, sheetName)
-	} else {
-		_, errExec = db.Exec(createSQL, excelPath)
-	}
-	if errExec != nil {
-		return fmt.Errorf("create table failed: %w", errExec)
+	// シート名はExcelのみ有効
+	sheet := ""
+	if format == importer.FormatXLSX {
+		sheet = sheetName
 	}
 
-	// 追記
-	insertSQL := "INSERT INTO " + targetTable + " SELECT * FROM st_read(?" +
-		func() string {
-			if sheetName != "" {
-				return ", layer:=?"
-			}
-			return ""
-		}() + ")"
-
-	if sheetName != "" {
-		_, err = db.Exec(insertSQL, excelPath, sheetName)
-	} else {
-		_, err = db.Exec(insertSQL, excelPath)
-	}
+	tables, err := importer.Import(src, importDBPath, []importer.SheetTable{{Sheet: sheet, Table: targetTable}}, !importReplace)
 	if err != nil {
-		return fmt.Errorf("insert failed: %w", err)
+		return err
 	}
 
-	sheet := sheetName
-	if sheet == "" {
-		sheet = "<auto>"
+	label := string(format)
+	if sheet != "" {
+		label += ", sheet=" + sheet
 	}
-	fmt.Printf("✓ Imported %s (sheet=%s) into table %s\n", excelPath, sheet, targetTable)
+	fmt.Printf("✓ Imported %s (%s) into table %s (%d rows)\n", importPath, label, targetTable, tables[0].RowCount)
 
 	return nil
 }
@@ -0,0 +1,104 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+)
+
+// BannerTableSheets はバナー表を表側の設問ごとのシートに変換する
+func BannerTableSheets(book *analyzer.BannerBook, filter *analyzer.Filter) []Sheet {
+	sheets := make([]Sheet, 0, len(book.Tables))
+	for _, table := range book.Tables {
+		sheets = append(sheets, bannerTableSheet(book, table, filter))
+	}
+	return sheets
+}
+
+// bannerTableSheet は表側の1つの設問を、全体と表頭の値を列に並べたシートに変換する
+// 先頭の全体行は各列の件数（基数）、以降は表側の値ごとに件数の行と割合の行を出力する
+// ウェイト付きの場合は件数（n）・ウェイト付き件数・ウェイト付き割合の3行を出力する
+func bannerTableSheet(book *analyzer.BannerBook, table analyzer.BannerTable, filter *analyzer.Filter) Sheet {
+	sheet := Sheet{
+		Name:  table.Stub,
+		Title: "バナー表: " + table.Stub,
+		Notes: conditionNotes(filter, table.Total.Total),
+	}
+	sheet.Notes = append(sheet.Notes, "表頭: "+strings.Join(book.Banners, ", "))
+	if book.IsWeighted() {
+		sheet.Notes = append(sheet.Notes, weightNote(book.WeightColumn, table.Total.WeightedTotal))
+	}
+	sheet.Notes = append(sheet.Notes, "割合: 表頭の値ごとの割合（全体は総数に対する割合）")
+	for _, banner := range table.Banners {
+		if banner.Pivot.Significance != nil {
+			sheet.Notes = append(sheet.Notes, "▲/▼: 表頭の列ごとの調整済み残差により全体より有意に高い/低いセル（▲▲/▼▼: 1%水準、▲/▼: 5%水準、n で検定）")
+			break
+		}
+	}
+
+	sheet.Header = []string{table.Stub, "", "全体"}
+	for _, banner := range table.Banners {
+		for _, x := range banner.Pivot.XValues {
+			sheet.Header = append(sheet.Header, fmt.Sprintf("%s: %s", banner.Column, x))
+		}
+	}
+
+	weighted := book.IsWeighted()
+	countLabel := "件数"
+	if weighted {
+		countLabel = "n"
+	}
+
+	// 全体行（列ごとの基数）
+	countCells := []Cell{Text("全体"), Text(countLabel), Int(table.Total.Total)}
+	weightedCells := []Cell{Text(""), Text("ウェイト付き件数"), Float(table.Total.WeightedTotal)}
+	for _, banner := range table.Banners {
+		for _, x := range banner.Pivot.XValues {
+			total := banner.Pivot.RowTotals[x]
+			countCells = append(countCells, Int(total.Count))
+			weightedCells = append(weightedCells, Float(total.WeightedCount))
+		}
+	}
+	sheet.AddTotalRow(countCells...)
+	if weighted {
+		sheet.AddTotalRow(weightedCells...)
+	}
+
+	// 全体の列の値（表側の値 -> 単純集計の行）
+	totals := make(map[string]analyzer.SimpletabRow)
+	for _, row := range table.Total.Rows {
+		totals[row.Value] = row
+	}
+
+	for _, value := range table.Values {
+		total := totals[value]
+		countCells := []Cell{Text(value), Text(countLabel), Int(total.Count)}
+		weightedCells := []Cell{Text(""), Text("ウェイト付き件数"), Float(total.WeightedCount)}
+		percentCells := []Cell{Text(""), Text("%"), Percent(total.Percentage)}
+		if weighted {
+			percentCells[2] = Percent(total.WeightedPercentage)
+		}
+
+		for _, banner := range table.Banners {
+			for _, x := range banner.Pivot.XValues {
+				cell := banner.Pivot.Matrix[x][value]
+				percent := cell.Percentage
+				if weighted {
+					percent = cell.WeightedPercentage
+				}
+				countCells = append(countCells, Int(cell.Count))
+				weightedCells = append(weightedCells, Float(cell.WeightedCount))
+				percentCells = append(percentCells, Percent(percent).WithMark(cell.Marker))
+			}
+		}
+
+		sheet.AddRow(countCells...)
+		if weighted {
+			sheet.AddRow(weightedCells...)
+		}
+		sheet.AddRow(percentCells...)
+	}
+
+	return sheet
+}
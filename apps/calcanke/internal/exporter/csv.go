@@ -0,0 +1,52 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// utf8BOM はExcelでUTF-8として開かせるためのBOM
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// writeCSV はシートをBOM付きUTF-8のCSVとして書き出す
+func writeCSV(w io.Writer, sheets []Sheet) error {
+	if _, err := w.Write(utf8BOM); err != nil {
+		return fmt.Errorf("failed to write BOM: %w", err)
+	}
+
+	cw := csv.NewWriter(w)
+
+	for i, sheet := range sheets {
+		// 複数シートの場合は空行で区切り、シート名を見出しとして出力
+		if len(sheets) > 1 {
+			if i > 0 {
+				if err := cw.Write([]string{}); err != nil {
+					return fmt.Errorf("failed to write csv: %w", err)
+				}
+			}
+			if err := cw.Write([]string{sheet.Name}); err != nil {
+				return fmt.Errorf("failed to write csv: %w", err)
+			}
+		}
+
+		if len(sheet.Header) > 0 {
+			if err := cw.Write(sheet.Header); err != nil {
+				return fmt.Errorf("failed to write csv header: %w", err)
+			}
+		}
+
+		for _, row := range sheet.Rows {
+			record := make([]string, len(row.Cells))
+			for j, cell := range row.Cells {
+				record[j] = cell.String()
+			}
+			if err := cw.Write(record); err != nil {
+				return fmt.Errorf("failed to write csv row: %w", err)
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,84 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format はエクスポート形式
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// ParseFormat は文字列からエクスポート形式を取得（空の場合はCSV）
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "csv":
+		return FormatCSV, nil
+	case "xlsx", "excel":
+		return FormatXLSX, nil
+	default:
+		return "", fmt.Errorf("unsupported export format: %s", s)
+	}
+}
+
+// FormatFromPath はファイルの拡張子からエクスポート形式を判定
+func FormatFromPath(path string) (Format, error) {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if ext == "" {
+		return "", fmt.Errorf("cannot determine export format from path: %s", path)
+	}
+	return ParseFormat(ext)
+}
+
+// ContentType はHTTPレスポンス用のContent-Typeを返す
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Extension はファイル拡張子（ドットなし）を返す
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// Write はシートを指定された形式で書き出す
+// CSVの場合、複数シートは空行を挟んで連結する
+func Write(w io.Writer, format Format, sheets []Sheet) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, sheets)
+	case FormatXLSX:
+		return writeXLSX(w, sheets)
+	default:
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// WriteFile はシートをファイルに書き出す（形式は拡張子から判定）
+func WriteFile(path string, sheets []Sheet) error {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer f.Close()
+
+	if err := Write(f, format, sheets); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,73 @@
+package exporter
+
+import (
+	"fmt"
+
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+)
+
+// FilterComparisonSheet はフィルタ比較の結果をシートに変換する
+// 列がフィルタ、行が値の表で、先頭にフィルタごとの回答者数（n）の行を置き、
+// 値ごとに件数の行と割合（列ごとの回答者数ベース）の行を出力する
+// ウェイト付きの場合は件数（n）・ウェイト付き件数・ウェイト付き割合の3行を出力する
+func FilterComparisonSheet(result *analyzer.FilterComparisonResult) Sheet {
+	sheet := Sheet{
+		Name:   "フィルタ比較_" + result.Column,
+		Title:  "フィルタ比較: " + result.Column,
+		Header: []string{result.Column, ""},
+	}
+	if result.Filter != nil {
+		sheet.Notes = append(sheet.Notes, filterNote(result.Filter)+"（すべての列に適用）")
+	}
+	for _, col := range result.Columns {
+		sheet.Header = append(sheet.Header, col.Name)
+		if col.Description != "" {
+			sheet.Notes = append(sheet.Notes, fmt.Sprintf("%s: %s", col.Name, col.Description))
+		}
+	}
+	if result.IsWeighted() {
+		sheet.Notes = append(sheet.Notes, "ウェイト: "+result.WeightColumn)
+	}
+	sheet.Notes = append(sheet.Notes, "割合: 列（フィルタ）ごとの回答者数ベース")
+
+	countLabel := "件数"
+	if result.IsWeighted() {
+		countLabel = "n"
+	}
+
+	// 回答者数の行（各列の割合の基準）
+	respondentCells := []Cell{Text("回答者数"), Text("n")}
+	weightedRespondentCells := []Cell{Text(""), Text("ウェイト付き")}
+	for _, col := range result.Columns {
+		respondentCells = append(respondentCells, Int(col.Result.Respondents))
+		weightedRespondentCells = append(weightedRespondentCells, Float(col.Result.WeightedRespondents))
+	}
+	sheet.AddTotalRow(respondentCells...)
+	if result.IsWeighted() {
+		sheet.AddTotalRow(weightedRespondentCells...)
+	}
+
+	for _, value := range result.Values {
+		countCells := []Cell{Text(value), Text(countLabel)}
+		weightedCells := []Cell{Text(""), Text("ウェイト付き件数")}
+		percentCells := []Cell{Text(""), Text("割合")}
+		for _, col := range result.Columns {
+			cell := col.Cell(value)
+			countCells = append(countCells, Int(cell.Count))
+			weightedCells = append(weightedCells, Float(cell.WeightedCount))
+			if result.IsWeighted() {
+				percentCells = append(percentCells, Percent(cell.WeightedRespondentPercentage))
+			} else {
+				percentCells = append(percentCells, Percent(cell.RespondentPercentage))
+			}
+		}
+
+		sheet.AddRow(countCells...)
+		if result.IsWeighted() {
+			sheet.AddRow(weightedCells...)
+		}
+		sheet.AddRow(percentCells...)
+	}
+
+	return sheet
+}
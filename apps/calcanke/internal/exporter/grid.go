@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"fmt"
+
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+)
+
+// GridSheet はマトリクス設問の集計結果をシートに変換する
+// 項目ごとに件数と割合（項目の回答数に対する行%）の行を出力し、
+// バナーを指定した場合は項目全体の行に続けてバナーの値ごとの行を出力する
+func GridSheet(result *analyzer.GridResult, filter *analyzer.Filter) Sheet {
+	sheet := Sheet{
+		Name:  result.Group,
+		Title: "マトリクス設問: " + result.Group,
+	}
+	if result.IsGrouped() {
+		sheet.Name = fmt.Sprintf("%s×%s", result.Group, result.BannerColumn)
+		sheet.Title = fmt.Sprintf("マトリクス設問: %s × %s", result.Group, result.BannerColumn)
+	}
+	if result.Description != "" {
+		sheet.Notes = append(sheet.Notes, result.Description)
+	}
+	sheet.Notes = append(sheet.Notes, filterNote(filter))
+	if result.IsWeighted() {
+		sheet.Notes = append(sheet.Notes, "ウェイト: "+result.WeightColumn)
+	}
+	sheet.Notes = append(sheet.Notes, "割合の基準: 項目（バナーの値）ごとの回答数")
+
+	sheet.Header = []string{"項目"}
+	if result.IsGrouped() {
+		sheet.Header = append(sheet.Header, result.BannerColumn)
+	}
+	sheet.Header = append(sheet.Header, "", "合計")
+	sheet.Header = append(sheet.Header, result.Values...)
+
+	for _, row := range result.Rows {
+		// 行の見出し（2行目以降は空欄）
+		head := []Cell{Text(row.Item)}
+		blank := []Cell{Text("")}
+		if result.IsGrouped() {
+			head = []Cell{Text(""), Text(row.Banner)}
+			if row.IsItemTotal() {
+				head = []Cell{Text(row.Item), Text("全体")}
+			}
+			blank = []Cell{Text(""), Text("")}
+		}
+
+		add := sheet.AddRow
+		if result.IsGrouped() && row.IsItemTotal() {
+			add = sheet.AddTotalRow
+		}
+
+		countLabel := "件数"
+		if result.IsWeighted() {
+			countLabel = "n"
+		}
+		countCells := append(head, Text(countLabel), Int(row.Total))
+		for _, cell := range row.Cells {
+			countCells = append(countCells, Int(cell.Count))
+		}
+		add(countCells...)
+
+		if result.IsWeighted() {
+			weightedCells := append(append([]Cell{}, blank...), Text("ウェイト付き件数"), Float(row.WeightedTotal))
+			for _, cell := range row.Cells {
+				weightedCells = append(weightedCells, Float(cell.WeightedCount))
+			}
+			add(weightedCells...)
+		}
+
+		rowTotal := 0.0
+		if row.Total > 0 {
+			rowTotal = 100
+		}
+		percentCells := append(append([]Cell{}, blank...), Text("行%"), Percent(rowTotal))
+		for _, cell := range row.Cells {
+			if result.IsWeighted() {
+				percentCells = append(percentCells, Percent(cell.WeightedPercentage))
+			} else {
+				percentCells = append(percentCells, Percent(cell.Percentage))
+			}
+		}
+		add(percentCells...)
+	}
+
+	return sheet
+}
@@ -0,0 +1,73 @@
+package exporter
+
+import (
+	"strconv"
+
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+)
+
+// SelectionCountSheet は複数回答の選択数の分布をシートに変換する
+func SelectionCountSheet(result *analyzer.SelectionCountResult, filter *analyzer.Filter) Sheet {
+	sheet := Sheet{
+		Name:   "選択数_" + result.Column,
+		Title:  "選択数の分布: " + result.Column,
+		Notes:  conditionNotes(filter, result.Respondents),
+		Header: []string{"選択数", "回答者数", "割合"},
+	}
+	sheet.Notes = append(sheet.Notes, "平均選択数: "+strconv.FormatFloat(result.Mean, 'f', 2, 64))
+
+	if result.IsWeighted() {
+		sheet.Notes = append(sheet.Notes, weightNote(result.WeightColumn, result.WeightedRespondents),
+			"ウェイト付き平均選択数: "+strconv.FormatFloat(result.WeightedMean, 'f', 2, 64))
+		sheet.Header = append(sheet.Header, "ウェイト付き回答者数", "ウェイト付き割合")
+	}
+
+	for _, row := range result.Rows {
+		cells := []Cell{Text(strconv.Itoa(row.Selections)), Int(row.Count), Percent(row.Percentage)}
+		if result.IsWeighted() {
+			cells = append(cells, Float(row.WeightedCount), Percent(row.WeightedPercentage))
+		}
+		sheet.AddRow(cells...)
+	}
+
+	totalCells := []Cell{Text("合計"), Int(result.Respondents), Percent(100)}
+	if result.IsWeighted() {
+		totalCells = append(totalCells, Float(result.WeightedRespondents), Percent(100))
+	}
+	sheet.AddTotalRow(totalCells...)
+
+	return sheet
+}
+
+// CooccurrenceSheet は複数回答の同時選択の表をシートに変換する
+// 選択肢ごとに回答者数の行と割合の行を出力する（ウェイト付きの場合は割合をウェイト付きにする）
+func CooccurrenceSheet(result *analyzer.CooccurrenceResult, filter *analyzer.Filter) Sheet {
+	sheet := Sheet{
+		Name:  "同時選択_" + result.Column,
+		Title: "同時選択: " + result.Column,
+		Notes: append(conditionNotes(filter, result.Respondents),
+			"割合: 行の選択肢を選んだ回答者のうち列の選択肢も選んだ割合（対角は回答者全体に対する割合）"),
+		Header: append([]string{result.Column, ""}, result.Options...),
+	}
+	if result.IsWeighted() {
+		sheet.Notes = append(sheet.Notes, weightNote(result.WeightColumn, result.WeightedRespondents))
+	}
+
+	for _, rowItem := range result.Options {
+		countCells := []Cell{Text(rowItem), Text("回答者数")}
+		percentCells := []Cell{Text(""), Text("割合")}
+		for _, columnItem := range result.Options {
+			cell := result.Cell(rowItem, columnItem)
+			countCells = append(countCells, Int(cell.Count))
+			if result.IsWeighted() {
+				percentCells = append(percentCells, Percent(cell.WeightedPercentage))
+			} else {
+				percentCells = append(percentCells, Percent(cell.Percentage))
+			}
+		}
+		sheet.AddRow(countCells...)
+		sheet.AddRow(percentCells...)
+	}
+
+	return sheet
+}
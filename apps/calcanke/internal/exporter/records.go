@@ -0,0 +1,31 @@
+package exporter
+
+import (
+	"fmt"
+
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+)
+
+// RecordsSheet は回答者の一覧をシートに変換する（NULLは空欄）
+func RecordsSheet(result *analyzer.RecordsResult, filter *analyzer.Filter) Sheet {
+	sheet := Sheet{
+		Name:   "回答者一覧",
+		Title:  "回答者一覧",
+		Notes:  []string{filterNote(filter), fmt.Sprintf("件数: %d件", result.Total)},
+		Header: result.Columns,
+	}
+
+	for _, row := range result.Rows {
+		cells := make([]Cell, len(row))
+		for i, value := range row {
+			if value == nil {
+				cells[i] = Text("")
+			} else {
+				cells[i] = Text(*value)
+			}
+		}
+		sheet.AddRow(cells...)
+	}
+
+	return sheet
+}
@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+)
+
+// ScaleSummarySheet は評価尺度の集計結果をシートに変換する
+// 先頭に全体行、グループ分けした場合はグループの値ごとの行を続ける
+// ウェイト付きの場合、割合・平均点はウェイト付きの値、nは実数を出力する
+func ScaleSummarySheet(result *analyzer.ScaleSummaryResult, filter *analyzer.Filter) Sheet {
+	kind := "評価尺度"
+	if result.IsNPS() {
+		kind = "NPS"
+	}
+
+	sheet := Sheet{
+		Name:  result.Column,
+		Title: kind + ": " + result.Column,
+		Notes: conditionNotes(filter, result.Overall.N+result.Overall.Excluded),
+	}
+	if result.IsGrouped() {
+		sheet.Name = fmt.Sprintf("%s×%s", result.GroupColumn, result.Column)
+		sheet.Title = fmt.Sprintf("%s: %s × %s", kind, result.GroupColumn, result.Column)
+	}
+	if result.IsWeighted() {
+		sheet.Notes = append(sheet.Notes, "ウェイト: "+result.WeightColumn)
+	}
+	sheet.Notes = append(sheet.Notes, scaleNotes(result)...)
+
+	label := result.Column
+	if result.IsGrouped() {
+		label = result.GroupColumn
+	}
+	if result.IsNPS() {
+		sheet.Header = []string{label, "n", "対象外", "推奨者", "中立者", "批判者", "NPS", "平均"}
+	} else {
+		sheet.Header = []string{label, "n", "対象外", result.TopBoxLabel(), result.BottomBoxLabel(), "平均"}
+	}
+
+	if result.IsGrouped() {
+		sheet.AddTotalRow(scaleSummaryCells(result, "全体", result.Overall)...)
+		for _, group := range result.Groups {
+			sheet.AddRow(scaleSummaryCells(result, group.Group, group)...)
+		}
+	} else {
+		sheet.AddRow(scaleSummaryCells(result, "全体", result.Overall)...)
+	}
+
+	return sheet
+}
+
+// scaleNotes は得点の対応と Top/Bottom Box・NPS の区分を説明する注記を作成
+func scaleNotes(result *analyzer.ScaleSummaryResult) []string {
+	if result.IsNPS() {
+		return []string{
+			fmt.Sprintf("推奨者: %g以上、批判者: %g以下、NPS = 推奨者%% − 批判者%%", result.PromoterMin, result.DetractorMax),
+			"対象外: 数値でない回答の件数",
+		}
+	}
+
+	var scores []string
+	for _, p := range result.Points {
+		scores = append(scores, fmt.Sprintf("%s=%g", p.Value, p.Score))
+	}
+	return []string{
+		"得点: " + strings.Join(scores, ", "),
+		result.TopBoxLabel() + ": " + strings.Join(result.TopBoxValues(), "・"),
+		result.BottomBoxLabel() + ": " + strings.Join(result.BottomBoxValues(), "・"),
+		"対象外: 得点のない回答の件数",
+	}
+}
+
+// scaleSummaryCells は評価尺度の集計の1行分のセルを作成
+func scaleSummaryCells(result *analyzer.ScaleSummaryResult, label string, s analyzer.ScaleSummary) []Cell {
+	m := s.Metrics
+	if result.IsWeighted() {
+		m = s.Weighted
+	}
+
+	cells := []Cell{Text(label), Int(s.N), Int(s.Excluded)}
+	if result.IsNPS() {
+		return append(cells, Percent(m.Promoters), Percent(m.Passives), Percent(m.Detractors), Float(m.NPS), Float(m.Mean))
+	}
+	return append(cells, Percent(m.TopBox), Percent(m.BottomBox), Float(m.Mean))
+}
@@ -0,0 +1,89 @@
+package exporter
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// CellKind はセルの値の種類（XLSXの書式に使用）
+type CellKind int
+
+const (
+	KindText    CellKind = iota // 文字列
+	KindInt                     // 件数
+	KindPercent                 // 割合（0〜100）
+	KindFloat                   // 小数
+)
+
+// Cell は表の1セル
+type Cell struct {
+	Kind  CellKind
+	Text  string
+	Value float64
+	Mark  string // 値の後ろに付ける記号（有意差の▲▼など）
+}
+
+// Text は文字列セルを作成
+func Text(s string) Cell {
+	return Cell{Kind: KindText, Text: s}
+}
+
+// Int は件数セルを作成
+func Int(n int) Cell {
+	return Cell{Kind: KindInt, Value: float64(n)}
+}
+
+// Percent は割合セルを作成（値は0〜100）
+func Percent(p float64) Cell {
+	return Cell{Kind: KindPercent, Value: p}
+}
+
+// Float は小数セルを作成
+func Float(f float64) Cell {
+	return Cell{Kind: KindFloat, Value: f}
+}
+
+// WithMark は記号を付けたセルを返す
+func (c Cell) WithMark(mark string) Cell {
+	c.Mark = mark
+	return c
+}
+
+// String はCSV出力用の文字列表現を返す
+func (c Cell) String() string {
+	switch c.Kind {
+	case KindInt:
+		return strconv.FormatInt(int64(c.Value), 10) + c.Mark
+	case KindPercent:
+		return fmt.Sprintf("%.1f%%", c.Value) + c.Mark
+	case KindFloat:
+		return strconv.FormatFloat(c.Value, 'f', 2, 64) + c.Mark
+	default:
+		return c.Text + c.Mark
+	}
+}
+
+// Row は表の1行
+type Row struct {
+	Cells   []Cell
+	IsTotal bool // 合計行（太字で表示）
+}
+
+// Sheet は出力する1枚の表
+type Sheet struct {
+	Name   string   // シート名（XLSXのシート名、CSVでは複数の表の見出し。エクスポートのファイル名にも使う）
+	Title  string   // 表題（XLSXのみ使用）
+	Notes  []string // 集計条件などの補足（XLSXのみ使用）
+	Header []string // 見出し行
+	Rows   []Row
+}
+
+// AddRow は行を追加
+func (s *Sheet) AddRow(cells ...Cell) {
+	s.Rows = append(s.Rows, Row{Cells: cells})
+}
+
+// AddTotalRow は合計行を追加
+func (s *Sheet) AddTotalRow(cells ...Cell) {
+	s.Rows = append(s.Rows, Row{Cells: cells, IsTotal: true})
+}
@@ -0,0 +1,66 @@
+package exporter
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+)
+
+// NumericStatsSheet は数値の記述統計量をシートに変換する
+// 先頭に全体行、グループ分けした場合はグループの値ごとの行を続ける
+func NumericStatsSheet(result *analyzer.NumericStatsResult, filter *analyzer.Filter) Sheet {
+	sheet := Sheet{
+		Name:  result.Column,
+		Title: "記述統計量: " + result.Column,
+		Notes: conditionNotes(filter, result.Overall.N+result.Overall.Missing),
+	}
+	if result.IsGrouped() {
+		sheet.Name = fmt.Sprintf("%s×%s", result.GroupColumn, result.Column)
+		sheet.Title = fmt.Sprintf("記述統計量: %s × %s", result.GroupColumn, result.Column)
+	}
+	if result.IsWeighted() {
+		sheet.Notes = append(sheet.Notes, "ウェイト: "+result.WeightColumn)
+	}
+	sheet.Notes = append(sheet.Notes, "欠損: 数値に変換できない値（空欄を含む）の件数")
+
+	label := result.Column
+	if result.IsGrouped() {
+		label = result.GroupColumn
+	}
+	sheet.Header = []string{label, "n", "欠損", "平均", "標準偏差", "最小値", "第1四分位", "中央値", "第3四分位", "最大値"}
+	if result.IsWeighted() {
+		sheet.Header = append(sheet.Header, "ウェイト付き平均")
+	}
+
+	if result.IsGrouped() {
+		sheet.AddTotalRow(numericStatsCells(result, "全体", result.Overall)...)
+		for _, group := range result.Groups {
+			sheet.AddRow(numericStatsCells(result, group.Group, group)...)
+		}
+	} else {
+		sheet.AddRow(numericStatsCells(result, "全体", result.Overall)...)
+	}
+
+	return sheet
+}
+
+// numericStatsCells は記述統計量の1行分のセルを作成
+func numericStatsCells(result *analyzer.NumericStatsResult, label string, s analyzer.NumericStats) []Cell {
+	cells := []Cell{
+		Text(label), Int(s.N), Int(s.Missing),
+		nullFloat(s.Mean), nullFloat(s.SD), nullFloat(s.Min), nullFloat(s.Q1), nullFloat(s.Median), nullFloat(s.Q3), nullFloat(s.Max),
+	}
+	if result.IsWeighted() {
+		cells = append(cells, nullFloat(s.WeightedMean))
+	}
+	return cells
+}
+
+// nullFloat は統計量のセルを作成（計算できない統計量は – とする）
+func nullFloat(v sql.NullFloat64) Cell {
+	if !v.Valid {
+		return Text("–")
+	}
+	return Float(v.Float64)
+}
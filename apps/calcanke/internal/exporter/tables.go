@@ -0,0 +1,223 @@
+package exporter
+
+import (
+	"fmt"
+
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+)
+
+// SimpletabSheet は単純集計結果をシートに変換する
+func SimpletabSheet(result *analyzer.SimpletabResult, filter *analyzer.Filter) Sheet {
+	sheet := Sheet{
+		Name:   result.Column,
+		Title:  "単純集計: " + result.Column,
+		Notes:  conditionNotes(filter, result.Total),
+		Header: []string{result.Column, "件数", "割合"},
+	}
+
+	// 複数回答を分割した場合は回答数ベースの割合に並べて回答者数ベースの割合を出力
+	if result.IsSplit() {
+		sheet.Notes = append(sheet.Notes, fmt.Sprintf("回答者数: %d人（割合は回答数ベース、回答者%%は回答者数ベース）", result.Respondents))
+		sheet.Header = append(sheet.Header, "回答者%")
+	}
+
+	// ウェイト付きの場合は件数（n）と並べてウェイト付きの件数・割合を出力
+	if result.IsWeighted() {
+		sheet.Notes = append(sheet.Notes, weightNote(result.WeightColumn, result.WeightedTotal))
+		sheet.Header = append(sheet.Header, "ウェイト付き件数", "ウェイト付き割合")
+		if result.IsSplit() {
+			sheet.Header = append(sheet.Header, "ウェイト付き回答者%")
+		}
+	}
+
+	for _, row := range result.Rows {
+		cells := []Cell{Text(row.Value), Int(row.Count), Percent(row.Percentage)}
+		if result.IsSplit() {
+			cells = append(cells, Percent(row.RespondentPercentage))
+		}
+		if result.IsWeighted() {
+			cells = append(cells, Float(row.WeightedCount), Percent(row.WeightedPercentage))
+			if result.IsSplit() {
+				cells = append(cells, Percent(row.WeightedRespondentPercentage))
+			}
+		}
+		sheet.AddRow(cells...)
+	}
+
+	// 回答者%の合計は100%を超えるため、合計行には回答者数を出力
+	totalCells := []Cell{Text("合計"), Int(result.Total), Percent(100)}
+	if result.IsSplit() {
+		totalCells = append(totalCells, Text(fmt.Sprintf("n=%d", result.Respondents)))
+	}
+	if result.IsWeighted() {
+		totalCells = append(totalCells, Float(result.WeightedTotal), Percent(100))
+		if result.IsSplit() {
+			totalCells = append(totalCells, Text(fmt.Sprintf("n=%.1f", result.WeightedRespondents)))
+		}
+	}
+	sheet.AddTotalRow(totalCells...)
+
+	return sheet
+}
+
+// CrosstabSheet はクロス集計のピボットをシートに変換する
+// 先頭に全体行、X値の直後に全体列を置き、X値ごとに件数の行と割合の行を出力する
+// 割合はピボットの割合の基準（行%・列%・全体%）に従う
+// ウェイト付きの場合は件数（n）・ウェイト付き件数・ウェイト付き割合の3行を出力する
+func CrosstabSheet(pivot *analyzer.CrosstabPivot, filter *analyzer.Filter) Sheet {
+	if pivot.IsWeighted() {
+		return weightedCrosstabSheet(pivot, filter)
+	}
+
+	sheet := Sheet{
+		Name:  fmt.Sprintf("%s×%s", pivot.XColumn, pivot.YColumn),
+		Title: fmt.Sprintf("クロス集計: %s × %s", pivot.XColumn, pivot.YColumn),
+		Notes: append(conditionNotes(filter, pivot.Total), percentageBaseNote(pivot)),
+	}
+	sheet.Notes = append(sheet.Notes, significanceNotes(pivot)...)
+	sheet.Header = crosstabHeader(pivot)
+	percentLabel := pivot.PercentageBase.Label()
+
+	// 全体行（Y値ごとの合計）
+	countCells := []Cell{Text("全体"), Text("件数"), Int(pivot.GrandTotal.Count)}
+	percentCells := []Cell{Text(""), Text(percentLabel), Percent(pivot.GrandTotal.Percentage)}
+	for _, y := range pivot.YValues {
+		total := pivot.ColumnTotals[y]
+		countCells = append(countCells, Int(total.Count))
+		percentCells = append(percentCells, Percent(total.Percentage))
+	}
+	sheet.AddTotalRow(countCells...)
+	sheet.AddTotalRow(percentCells...)
+
+	for _, x := range pivot.XValues {
+		total := pivot.RowTotals[x]
+		countCells := []Cell{Text(x), Text("件数"), Int(total.Count)}
+		percentCells := []Cell{Text(""), Text(percentLabel), Percent(total.Percentage)}
+		for _, y := range pivot.YValues {
+			cell := pivot.Matrix[x][y]
+			countCells = append(countCells, Int(cell.Count))
+			percentCells = append(percentCells, Percent(cell.Percentage).WithMark(cell.Marker))
+		}
+
+		sheet.AddRow(countCells...)
+		sheet.AddRow(percentCells...)
+	}
+
+	return sheet
+}
+
+// weightedCrosstabSheet はウェイト付きのクロス集計のピボットをシートに変換する
+func weightedCrosstabSheet(pivot *analyzer.CrosstabPivot, filter *analyzer.Filter) Sheet {
+	sheet := Sheet{
+		Name:  fmt.Sprintf("%s×%s", pivot.XColumn, pivot.YColumn),
+		Title: fmt.Sprintf("クロス集計: %s × %s", pivot.XColumn, pivot.YColumn),
+		Notes: append(conditionNotes(filter, pivot.Total), weightNote(pivot.WeightColumn, pivot.WeightedTotal), percentageBaseNote(pivot)),
+	}
+	sheet.Notes = append(sheet.Notes, significanceNotes(pivot)...)
+	sheet.Header = crosstabHeader(pivot)
+	percentLabel := pivot.PercentageBase.Label()
+
+	// 全体行（Y値ごとの合計）
+	countCells := []Cell{Text("全体"), Text("n"), Int(pivot.GrandTotal.Count)}
+	weightedCells := []Cell{Text(""), Text("ウェイト付き件数"), Float(pivot.GrandTotal.WeightedCount)}
+	percentCells := []Cell{Text(""), Text(percentLabel), Percent(pivot.GrandTotal.WeightedPercentage)}
+	for _, y := range pivot.YValues {
+		total := pivot.ColumnTotals[y]
+		countCells = append(countCells, Int(total.Count))
+		weightedCells = append(weightedCells, Float(total.WeightedCount))
+		percentCells = append(percentCells, Percent(total.WeightedPercentage))
+	}
+	sheet.AddTotalRow(countCells...)
+	sheet.AddTotalRow(weightedCells...)
+	sheet.AddTotalRow(percentCells...)
+
+	for _, x := range pivot.XValues {
+		total := pivot.RowTotals[x]
+		countCells := []Cell{Text(x), Text("n"), Int(total.Count)}
+		weightedCells := []Cell{Text(""), Text("ウェイト付き件数"), Float(total.WeightedCount)}
+		percentCells := []Cell{Text(""), Text(percentLabel), Percent(total.WeightedPercentage)}
+		for _, y := range pivot.YValues {
+			cell := pivot.Matrix[x][y]
+			countCells = append(countCells, Int(cell.Count))
+			weightedCells = append(weightedCells, Float(cell.WeightedCount))
+			percentCells = append(percentCells, Percent(cell.WeightedPercentage).WithMark(cell.Marker))
+		}
+
+		sheet.AddRow(countCells...)
+		sheet.AddRow(weightedCells...)
+		sheet.AddRow(percentCells...)
+	}
+
+	return sheet
+}
+
+// LayeredCrosstabSheets は層別クロス集計を、全体と層の値ごとのシートに変換する
+func LayeredCrosstabSheets(result *analyzer.LayeredCrosstabResult, filter *analyzer.Filter) []Sheet {
+	overall := CrosstabSheet(result.Overall, filter)
+	overall.Name = "全体"
+	overall.Title = fmt.Sprintf("層別クロス集計: %s × %s（全体）", result.XColumn, result.YColumn)
+	sheets := []Sheet{overall}
+
+	for _, layer := range result.Layers {
+		sheet := CrosstabSheet(layer.Pivot, filter)
+		sheet.Name = layer.Value
+		sheet.Title = fmt.Sprintf("層別クロス集計: %s × %s（%s: %s）", result.XColumn, result.YColumn, result.ZColumn, layer.Value)
+		sheets = append(sheets, sheet)
+	}
+
+	return sheets
+}
+
+// crosstabHeader はクロス集計の見出し行（X値・行の種類・全体・Y値）を作成
+func crosstabHeader(pivot *analyzer.CrosstabPivot) []string {
+	header := []string{pivot.XColumn + " \\ " + pivot.YColumn, "", "全体"}
+	return append(header, pivot.YValues...)
+}
+
+// percentageBaseNote は割合の基準の補足行を作成
+func percentageBaseNote(pivot *analyzer.CrosstabPivot) string {
+	return "割合: " + pivot.PercentageBase.Label()
+}
+
+// significanceNotes はカイ二乗検定の結果と有意差マーカーの凡例の補足行を作成
+func significanceNotes(pivot *analyzer.CrosstabPivot) []string {
+	if pivot.Split {
+		return []string{"χ²検定: 複数回答を分割した表のため行っていません"}
+	}
+	test := pivot.Significance
+	if test == nil {
+		return nil
+	}
+
+	pValue := fmt.Sprintf("p = %.3f", test.PValue)
+	if test.PValue < 0.001 {
+		pValue = "p < 0.001"
+	}
+
+	return []string{
+		fmt.Sprintf("χ²検定: χ² = %.2f, 自由度 = %d, %s, Cramér's V = %.3f", test.ChiSquare, test.DF, pValue, test.CramersV),
+		"▲/▼: 調整済み残差により全体より有意に高い/低いセル（▲▲/▼▼: 1%水準、▲/▼: 5%水準、n で検定）",
+	}
+}
+
+// weightNote はウェイトの補足行を作成
+func weightNote(column string, weightedTotal float64) string {
+	return fmt.Sprintf("ウェイト: %s（ウェイト付き総数: %.1f）", column, weightedTotal)
+}
+
+// conditionNotes は集計条件の補足行を作成
+func conditionNotes(filter *analyzer.Filter, total int) []string {
+	return []string{filterNote(filter), fmt.Sprintf("総件数: %d件", total)}
+}
+
+// filterNote はフィルタの注記を作成
+func filterNote(filter *analyzer.Filter) string {
+	if filter == nil {
+		return "フィルタ: なし（全データ）"
+	}
+	note := "フィルタ: " + filter.Name
+	if filter.Description != "" {
+		note += fmt.Sprintf("（%s）", filter.Description)
+	}
+	return note
+}
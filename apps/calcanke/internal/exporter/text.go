@@ -0,0 +1,112 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+)
+
+// WordFrequencySheet は自由回答の語の出現頻度をシートに変換する
+func WordFrequencySheet(result *analyzer.WordFrequencyResult, filter *analyzer.Filter) Sheet {
+	sheet := Sheet{
+		Name:  "頻出語_" + result.Column,
+		Title: "語の出現頻度: " + result.Column,
+		Notes: []string{
+			filterNote(filter),
+			fmt.Sprintf("回答者数: %d人（異なり語数: %d語）", result.Respondents, result.DistinctWords),
+			"割合: 語を含む回答者数の回答者数に対する割合",
+		},
+		Header: []string{"語", "品詞", "出現回数", "回答者数", "割合"},
+	}
+
+	if result.IsWeighted() {
+		sheet.Notes = append(sheet.Notes, weightNote(result.WeightColumn, result.WeightedRespondents))
+		sheet.Header = append(sheet.Header, "ウェイト付き回答者数", "ウェイト付き割合")
+	}
+
+	for _, row := range result.Rows {
+		cells := []Cell{Text(row.Word), Text(row.PartOfSpeech), Int(row.Occurrences), Int(row.Count), Percent(row.Percentage)}
+		if result.IsWeighted() {
+			cells = append(cells, Float(row.WeightedCount), Percent(row.WeightedPercentage))
+		}
+		sheet.AddRow(cells...)
+	}
+
+	return sheet
+}
+
+// KeywordContextSheet はキーワードを含む回答の一覧（KWIC）をシートに変換する
+func KeywordContextSheet(result *analyzer.KeywordContextResult, filter *analyzer.Filter) Sheet {
+	sheet := Sheet{
+		Name:  "KWIC_" + result.Column,
+		Title: "キーワードを含む回答: " + result.Column,
+		Notes: []string{
+			filterNote(filter),
+			"キーワード: " + strings.Join(result.Keywords, "、"),
+			fmt.Sprintf("キーワードを含む回答者数: %d人 / %d人", result.Matches, result.Respondents),
+		},
+		Header: []string{"前", "キーワード", "後"},
+	}
+	if result.Truncated {
+		sheet.Notes = append(sheet.Notes, fmt.Sprintf("先頭の%d件のみ出力", len(result.Rows)))
+	}
+
+	for _, row := range result.Rows {
+		sheet.AddRow(Text(row.Left), Text(row.Keyword), Text(row.Right))
+	}
+
+	return sheet
+}
+
+// KeywordCrosstabSheet はキーワードを含む回答者の表をシートに変換する
+// キーワードごとに回答者数の行と割合の行を出力する（ウェイト付きの場合は割合をウェイト付きにする）
+func KeywordCrosstabSheet(result *analyzer.KeywordCrosstabResult, filter *analyzer.Filter) Sheet {
+	sheet := Sheet{
+		Name:  "キーワード_" + result.Column,
+		Title: "キーワードを含む回答者: " + result.Column,
+		Notes: []string{
+			filterNote(filter),
+			"割合: 列（全体・バナーの値）の回答者数に対する割合",
+		},
+	}
+	if result.IsGrouped() {
+		sheet.Name = fmt.Sprintf("キーワード_%s×%s", result.Column, result.BannerColumn)
+		sheet.Title = fmt.Sprintf("キーワードを含む回答者: %s × %s", result.Column, result.BannerColumn)
+	}
+	if result.IsWeighted() {
+		sheet.Notes = append(sheet.Notes, "ウェイト: "+result.WeightColumn)
+	}
+
+	sheet.Header = []string{"キーワード", ""}
+	for _, column := range result.Columns {
+		if column.Banner == "" {
+			sheet.Header = append(sheet.Header, "全体")
+		} else {
+			sheet.Header = append(sheet.Header, column.Banner)
+		}
+	}
+
+	totalCells := []Cell{Text("回答者数"), Text("")}
+	for _, column := range result.Columns {
+		totalCells = append(totalCells, Int(column.Total))
+	}
+	sheet.AddTotalRow(totalCells...)
+
+	for _, row := range result.Rows {
+		countCells := []Cell{Text(row.Keyword), Text("回答者数")}
+		percentCells := []Cell{Text(""), Text("割合")}
+		for _, cell := range row.Cells {
+			countCells = append(countCells, Int(cell.Count))
+			if result.IsWeighted() {
+				percentCells = append(percentCells, Percent(cell.WeightedPercentage))
+			} else {
+				percentCells = append(percentCells, Percent(cell.Percentage))
+			}
+		}
+		sheet.AddRow(countCells...)
+		sheet.AddRow(percentCells...)
+	}
+
+	return sheet
+}
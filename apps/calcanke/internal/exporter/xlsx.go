@@ -0,0 +1,359 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// maxSheetNameLength はExcelのシート名の最大文字数
+const maxSheetNameLength = 31
+
+// xlsxStyles はXLSX出力で使用するスタイルIDの集合
+type xlsxStyles struct {
+	title  int
+	note   int
+	header int
+	cells  map[CellKind]int // 通常行
+	totals map[CellKind]int // 合計行
+	marked map[string]int   // 記号付きのセル（必要になった時点で作成）
+}
+
+// markedStyleKey は記号付きセルのスタイルを区別するキー
+func markedStyleKey(kind CellKind, total bool, mark string) string {
+	return fmt.Sprintf("%d/%t/%s", kind, total, mark)
+}
+
+// writeXLSX はシートを書式付きのXLSXとして書き出す
+func writeXLSX(w io.Writer, sheets []Sheet) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	styles, err := newXLSXStyles(f)
+	if err != nil {
+		return err
+	}
+
+	defaultSheet := f.GetSheetName(0)
+	usedNames := make(map[string]bool)
+
+	for i, sheet := range sheets {
+		name := uniqueSheetName(sheet.Name, i+1, usedNames)
+		if i == 0 {
+			if err := f.SetSheetName(defaultSheet, name); err != nil {
+				return fmt.Errorf("failed to rename sheet: %w", err)
+			}
+		} else if _, err := f.NewSheet(name); err != nil {
+			return fmt.Errorf("failed to create sheet: %w", err)
+		}
+
+		if err := writeXLSXSheet(f, name, sheet, styles); err != nil {
+			return err
+		}
+	}
+
+	f.SetActiveSheet(0)
+
+	if err := f.Write(w); err != nil {
+		return fmt.Errorf("failed to write xlsx: %w", err)
+	}
+
+	return nil
+}
+
+// writeXLSXSheet は1シート分の内容を書き込む
+func writeXLSXSheet(f *excelize.File, name string, sheet Sheet, styles *xlsxStyles) error {
+	row := 1
+
+	// 表題と補足
+	if sheet.Title != "" {
+		if err := setCell(f, name, 1, row, sheet.Title, styles.title); err != nil {
+			return err
+		}
+		row++
+	}
+	for _, note := range sheet.Notes {
+		if err := setCell(f, name, 1, row, note, styles.note); err != nil {
+			return err
+		}
+		row++
+	}
+	if row > 1 {
+		row++ // 表との間に空行
+	}
+
+	// 見出し行
+	headerRow := row
+	for col, header := range sheet.Header {
+		if err := setCell(f, name, col+1, row, header, styles.header); err != nil {
+			return err
+		}
+	}
+	if len(sheet.Header) > 0 {
+		row++
+	}
+
+	// データ行
+	maxCols := len(sheet.Header)
+	for _, r := range sheet.Rows {
+		styleMap := styles.cells
+		if r.IsTotal {
+			styleMap = styles.totals
+		}
+		for col, cell := range r.Cells {
+			var value interface{}
+			if cell.Kind == KindText {
+				value = cell.Text + cell.Mark
+			} else if cell.Kind == KindInt {
+				value = int64(cell.Value)
+			} else {
+				value = cell.Value
+			}
+			style := styleMap[cell.Kind]
+			if cell.Mark != "" && cell.Kind != KindText {
+				// 数値のまま記号を表示するため、記号を含む表示形式のスタイルを使う
+				var err error
+				if style, err = styles.markedStyle(f, cell.Kind, r.IsTotal, cell.Mark); err != nil {
+					return err
+				}
+			}
+			if err := setCell(f, name, col+1, row, value, style); err != nil {
+				return err
+			}
+		}
+		if len(r.Cells) > maxCols {
+			maxCols = len(r.Cells)
+		}
+		row++
+	}
+
+	// 列幅（1列目は見出し用に広めに取る）
+	if maxCols > 0 {
+		if err := f.SetColWidth(name, "A", "A", firstColumnWidth(sheet)); err != nil {
+			return fmt.Errorf("failed to set column width: %w", err)
+		}
+	}
+	if maxCols > 1 {
+		lastCol, _ := excelize.ColumnNumberToName(maxCols)
+		if err := f.SetColWidth(name, "B", lastCol, 12); err != nil {
+			return fmt.Errorf("failed to set column width: %w", err)
+		}
+	}
+
+	// 見出し行と1列目を固定
+	if len(sheet.Header) > 0 {
+		topLeft, _ := excelize.CoordinatesToCellName(2, headerRow+1)
+		if err := f.SetPanes(name, &excelize.Panes{
+			Freeze:      true,
+			XSplit:      1,
+			YSplit:      headerRow,
+			TopLeftCell: topLeft,
+			ActivePane:  "bottomRight",
+		}); err != nil {
+			return fmt.Errorf("failed to freeze panes: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// setCell はセルに値とスタイルを設定
+func setCell(f *excelize.File, sheet string, col, row int, value interface{}, style int) error {
+	cell, err := excelize.CoordinatesToCellName(col, row)
+	if err != nil {
+		return fmt.Errorf("invalid cell position: %w", err)
+	}
+	if err := f.SetCellValue(sheet, cell, value); err != nil {
+		return fmt.Errorf("failed to set cell value: %w", err)
+	}
+	if err := f.SetCellStyle(sheet, cell, cell, style); err != nil {
+		return fmt.Errorf("failed to set cell style: %w", err)
+	}
+	return nil
+}
+
+// firstColumnWidth は1列目の文字数から列幅を決める
+func firstColumnWidth(sheet Sheet) float64 {
+	width := 12
+	measure := func(s string) {
+		// 全角文字は半角2文字分として数える
+		w := 0
+		for _, r := range s {
+			if utf8.RuneLen(r) > 1 {
+				w += 2
+			} else {
+				w++
+			}
+		}
+		if w > width {
+			width = w
+		}
+	}
+	if len(sheet.Header) > 0 {
+		measure(sheet.Header[0])
+	}
+	for _, r := range sheet.Rows {
+		if len(r.Cells) > 0 {
+			measure(r.Cells[0].Text)
+		}
+	}
+	if width > 60 {
+		width = 60
+	}
+	return float64(width + 2)
+}
+
+// newXLSXStyles はXLSX出力用のスタイルを登録
+func newXLSXStyles(f *excelize.File) (*xlsxStyles, error) {
+	border := xlsxBorder()
+	intFmt := xlsxNumFmts[KindInt]
+	percentFmt := xlsxNumFmts[KindPercent]
+	floatFmt := xlsxNumFmts[KindFloat]
+
+	newStyle := func(style *excelize.Style) (int, error) {
+		id, err := f.NewStyle(style)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create xlsx style: %w", err)
+		}
+		return id, nil
+	}
+
+	styles := &xlsxStyles{
+		cells:  make(map[CellKind]int),
+		totals: make(map[CellKind]int),
+		marked: make(map[string]int),
+	}
+
+	var err error
+	if styles.title, err = newStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Size: 12},
+	}); err != nil {
+		return nil, err
+	}
+	if styles.note, err = newStyle(&excelize.Style{
+		Font: &excelize.Font{Color: "595959"},
+	}); err != nil {
+		return nil, err
+	}
+	if styles.header, err = newStyle(&excelize.Style{
+		Border:    border,
+		Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"D9E1F2"}},
+		Font:      &excelize.Font{Bold: true},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center", WrapText: true},
+	}); err != nil {
+		return nil, err
+	}
+
+	numFmts := map[CellKind]*string{
+		KindText:    nil,
+		KindInt:     &intFmt,
+		KindPercent: &percentFmt,
+		KindFloat:   &floatFmt,
+	}
+	for kind, numFmt := range numFmts {
+		alignment := &excelize.Alignment{Vertical: "center"}
+		if kind == KindText {
+			alignment.WrapText = true
+		}
+		if styles.cells[kind], err = newStyle(&excelize.Style{
+			Border:       border,
+			Alignment:    alignment,
+			CustomNumFmt: numFmt,
+		}); err != nil {
+			return nil, err
+		}
+		if styles.totals[kind], err = newStyle(&excelize.Style{
+			Border:       border,
+			Alignment:    alignment,
+			Fill:         excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"F2F2F2"}},
+			Font:         &excelize.Font{Bold: true},
+			CustomNumFmt: numFmt,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return styles, nil
+}
+
+// xlsxNumFmts は値の種類ごとの表示形式
+var xlsxNumFmts = map[CellKind]string{
+	KindInt:     "#,##0",
+	KindPercent: `0.0"%"`,
+	KindFloat:   "#,##0.00",
+}
+
+// xlsxBorder はセルの罫線
+func xlsxBorder() []excelize.Border {
+	return []excelize.Border{
+		{Type: "left", Color: "A6A6A6", Style: 1},
+		{Type: "top", Color: "A6A6A6", Style: 1},
+		{Type: "right", Color: "A6A6A6", Style: 1},
+		{Type: "bottom", Color: "A6A6A6", Style: 1},
+	}
+}
+
+// markedStyle は記号付きの数値セルのスタイルを返す
+// 表示形式の末尾に記号を付け、▲は赤・▼は青で表示する
+func (s *xlsxStyles) markedStyle(f *excelize.File, kind CellKind, total bool, mark string) (int, error) {
+	key := markedStyleKey(kind, total, mark)
+	if id, ok := s.marked[key]; ok {
+		return id, nil
+	}
+
+	numFmt := xlsxNumFmts[kind] + `"` + strings.ReplaceAll(mark, `"`, "") + `"`
+	font := &excelize.Font{Bold: total}
+	if strings.Contains(mark, "▲") {
+		font.Color = "C00000"
+	} else if strings.Contains(mark, "▼") {
+		font.Color = "0070C0"
+	}
+	style := &excelize.Style{
+		Border:       xlsxBorder(),
+		Alignment:    &excelize.Alignment{Vertical: "center"},
+		Font:         font,
+		CustomNumFmt: &numFmt,
+	}
+	if total {
+		style.Fill = excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"F2F2F2"}}
+	}
+
+	id, err := f.NewStyle(style)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create xlsx style: %w", err)
+	}
+	s.marked[key] = id
+	return id, nil
+}
+
+// uniqueSheetName はExcelで使用できる重複のないシート名を作成
+func uniqueSheetName(name string, index int, used map[string]bool) string {
+	// Excelのシート名に使えない文字を置換
+	replacer := strings.NewReplacer(":", "：", "\\", "＼", "/", "／", "?", "？", "*", "＊", "[", "［", "]", "］")
+	name = strings.TrimSpace(replacer.Replace(name))
+	if name == "" {
+		name = fmt.Sprintf("Sheet%d", index)
+	}
+	name = truncateRunes(name, maxSheetNameLength)
+
+	candidate := name
+	for counter := 2; used[strings.ToLower(candidate)]; counter++ {
+		suffix := fmt.Sprintf(" (%d)", counter)
+		candidate = truncateRunes(name, maxSheetNameLength-len(suffix)) + suffix
+	}
+	used[strings.ToLower(candidate)] = true
+
+	return candidate
+}
+
+// truncateRunes は文字列を指定した文字数までに切り詰める
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,236 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
+)
+
+// 文字コード
+const (
+	EncodingUTF8     = "utf-8"
+	EncodingShiftJIS = "shift_jis"
+)
+
+// delimiterCandidates は区切り文字の自動判定の候補
+var delimiterCandidates = []string{",", "\t", ";", "|"}
+
+// headSize は区切り文字の判定に読み込むファイル先頭のバイト数
+const headSize = 64 * 1024
+
+// csvSource はCSV/TSVのSource
+// UTF-8以外のファイルは一時ファイルにUTF-8で書き出してから読み込む
+type csvSource struct {
+	path      string // 読み込むファイル（変換した場合は一時ファイル）
+	tempPath  string
+	format    Format
+	Encoding  string // 判定または指定された文字コード
+	Delimiter string // 判定または指定された区切り文字
+}
+
+// openCSVSource はCSV/TSVファイルの文字コードと区切り文字を判定してSourceを作成
+// ファイル全体をメモリに読み込まず、変換が必要な場合も逐次一時ファイルに書き出す
+func openCSVSource(path string, format Format, opts Options) (*csvSource, error) {
+	encoding, err := ParseEncoding(opts.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer f.Close()
+
+	// BOMは文字コード判定とDuckDBの読み込みの邪魔になるため除去する
+	r, hasBOM, err := readerSkippingBOM(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if encoding == "" {
+		if encoding, err = detectEncoding(r); err != nil {
+			return nil, err
+		}
+		if r, _, err = readerSkippingBOM(f); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &csvSource{path: path, format: format, Encoding: encoding}
+
+	// UTF-8以外、またはBOM付きの場合はUTF-8の一時ファイルに変換
+	if encoding != EncodingUTF8 || hasBOM {
+		var decoded io.Reader = r
+		if encoding == EncodingShiftJIS {
+			decoded = transform.NewReader(r, japanese.ShiftJIS.NewDecoder())
+		}
+		if err := s.writeTemp(decoded); err != nil {
+			return nil, err
+		}
+	}
+
+	s.Delimiter = opts.Delimiter
+	if s.Delimiter == "" {
+		head, err := readHead(s.path)
+		if err != nil {
+			return nil, err
+		}
+		s.Delimiter = detectDelimiter(head)
+	}
+
+	return s, nil
+}
+
+// ParseEncoding は文字コード名の表記揺れをまとめる（空または auto は自動判定として空を返す）
+func ParseEncoding(encoding string) (string, error) {
+	switch strings.ToLower(strings.ReplaceAll(encoding, "-", "_")) {
+	case "", "auto":
+		return "", nil
+	case "utf_8", "utf8":
+		return EncodingUTF8, nil
+	case "shift_jis", "sjis", "cp932", "windows_31j":
+		return EncodingShiftJIS, nil
+	default:
+		return "", fmt.Errorf("unsupported encoding: %s", encoding)
+	}
+}
+
+// readerSkippingBOM はファイルを先頭から読み直すReaderを返す（BOMがあれば読み飛ばす）
+func readerSkippingBOM(f *os.File) (*bufio.Reader, bool, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, false, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	r := bufio.NewReader(f)
+	head, err := r.Peek(len(utf8BOM))
+	if err != nil && err != io.EOF {
+		return nil, false, fmt.Errorf("failed to read file: %w", err)
+	}
+	if !bytes.Equal(head, utf8BOM) {
+		return r, false, nil
+	}
+	if _, err := r.Discard(len(utf8BOM)); err != nil {
+		return nil, false, fmt.Errorf("failed to read file: %w", err)
+	}
+	return r, true, nil
+}
+
+// detectEncoding はUTF-8として正しくなければShift_JISとみなす
+func detectEncoding(r io.RuneReader) (string, error) {
+	for {
+		ch, size, err := r.ReadRune()
+		if err == io.EOF {
+			return EncodingUTF8, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+		if ch == utf8.RuneError && size == 1 {
+			return EncodingShiftJIS, nil
+		}
+	}
+}
+
+// readHead は区切り文字の判定用にファイルの先頭部分を読み込む
+func readHead(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer f.Close()
+
+	head, err := io.ReadAll(io.LimitReader(f, headSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return bytes.TrimPrefix(head, utf8BOM), nil
+}
+
+// detectDelimiter は先頭行（見出し）で最も多く使われている区切り文字を返す
+// 引用符で囲まれた部分は数えない
+func detectDelimiter(data []byte) string {
+	header := firstRecord(data)
+
+	best := ","
+	bestCount := 0
+	for _, candidate := range delimiterCandidates {
+		count := 0
+		inQuotes := false
+		for _, r := range header {
+			if r == '"' {
+				inQuotes = !inQuotes
+			} else if !inQuotes && string(r) == candidate {
+				count++
+			}
+		}
+		if count > bestCount {
+			best = candidate
+			bestCount = count
+		}
+	}
+
+	return best
+}
+
+// firstRecord は引用符内の改行を考慮して先頭の1レコードを返す
+func firstRecord(data []byte) string {
+	inQuotes := false
+	for i, b := range data {
+		switch b {
+		case '"':
+			inQuotes = !inQuotes
+		case '\n':
+			if !inQuotes {
+				return strings.TrimSuffix(string(data[:i]), "\r")
+			}
+		}
+	}
+	return string(data)
+}
+
+// writeTemp は変換後の内容を一時ファイルに書き出し、読み込み先を切り替える
+func (s *csvSource) writeTemp(r io.Reader) error {
+	f, err := os.CreateTemp("", "calcanke-import-*."+s.format.Extension())
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	s.path = f.Name()
+	s.tempPath = f.Name()
+	return nil
+}
+
+func (s *csvSource) Format() Format            { return s.format }
+func (s *csvSource) Sheets() ([]string, error) { return []string{""}, nil }
+func (s *csvSource) Prepare(db *sql.DB) error  { return nil }
+
+// Query は1行目を見出しとして読み込むSELECT文を返す
+func (s *csvSource) Query(string) (string, []interface{}) {
+	return "SELECT * FROM read_csv(?, header=true, delim=?)", []interface{}{s.path, s.Delimiter}
+}
+
+// Close は変換用の一時ファイルを削除
+func (s *csvSource) Close() error {
+	if s.tempPath == "" {
+		return nil
+	}
+	return os.Remove(s.tempPath)
+}
+
+// utf8BOM はUTF-8のBOM
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
@@ -3,63 +3,46 @@ package importer
 import (
 	"database/sql"
 	"fmt"
-	"path/filepath"
 
-	_ "github.com/marcboeker/go-duckdb"
+	"github.com/xuri/excelize/v2"
 )
 
-// ImportExcel はExcelファイルをDuckDBにインポートする
-func ImportExcel(excelPath, dbPath, tableName string) error {
-	// DuckDB接続
-	db, err := sql.Open("duckdb", dbPath)
+// xlsxSource はExcel（.xlsx）のSource
+// DuckDBのspatial拡張（st_read）でシートごとに読み込む
+type xlsxSource struct {
+	path string
+}
+
+func (s *xlsxSource) Format() Format { return FormatXLSX }
+func (s *xlsxSource) Close() error   { return nil }
+
+// Sheets はシート名を並び順どおりに返す
+func (s *xlsxSource) Sheets() ([]string, error) {
+	f, err := excelize.OpenFile(s.path)
 	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
+		return nil, fmt.Errorf("failed to open excel file: %w", err)
 	}
-	defer db.Close()
+	defer f.Close()
 
-	// 拡張機能の自動インストールを有効化
-	if _, err := db.Exec("SET autoinstall_known_extensions = true;"); err != nil {
-		return fmt.Errorf("set autoinstall failed: %w", err)
-	}
-	if _, err := db.Exec("SET autoload_known_extensions = true;"); err != nil {
-		return fmt.Errorf("set autoload failed: %w", err)
-	}
+	return f.GetSheetList(), nil
+}
 
-	// spatial 拡張機能をインストール・ロード
+// Prepare はspatial拡張機能をインストール・ロードする
+func (s *xlsxSource) Prepare(db *sql.DB) error {
 	if _, err := db.Exec("INSTALL spatial;"); err != nil {
 		return fmt.Errorf("install spatial failed: %w", err)
 	}
 	if _, err := db.Exec("LOAD spatial;"); err != nil {
 		return fmt.Errorf("load spatial failed: %w", err)
 	}
+	return nil
+}
 
-	// 絶対パスに変換
-	absPath, err := filepath.Abs(excelPath)
-	if err != nil {
-		return fmt.Errorf("failed to get absolute path: %w", err)
-	}
-
-	// テーブルが既に存在する場合は削除
-	dropSQL := fmt.Sprintf("DROP TABLE IF EXISTS %s", tableName)
-	if _, err := db.Exec(dropSQL); err != nil {
-		return fmt.Errorf("failed to drop table: %w", err)
-	}
-
-	// Excelファイルからテーブルを作成
-	// open_options=['HEADERS=FORCE'] で1行目をヘッダーとして扱う
-	createSQL := fmt.Sprintf("CREATE TABLE %s AS SELECT * FROM st_read(?, open_options=['HEADERS=FORCE'])", tableName)
-	if _, err := db.Exec(createSQL, absPath); err != nil {
-		return fmt.Errorf("failed to create table from excel: %w", err)
-	}
-
-	// データが正常にインポートされたか確認
-	var count int
-	countSQL := fmt.Sprintf("SELECT COUNT(*) FROM %s", tableName)
-	if err := db.QueryRow(countSQL).Scan(&count); err != nil {
-		return fmt.Errorf("failed to count rows: %w", err)
+// Query はシートを読み込むSELECT文を返す（シート名が空の場合は先頭シート）
+// open_options=['HEADERS=FORCE'] で1行目をヘッダーとして扱う
+func (s *xlsxSource) Query(sheet string) (string, []interface{}) {
+	if sheet == "" {
+		return "SELECT * FROM st_read(?, open_options=['HEADERS=FORCE'])", []interface{}{s.path}
 	}
-
-	fmt.Printf("Successfully imported %d rows into table '%s'\n", count, tableName)
-
-	return nil
+	return "SELECT * FROM st_read(?, layer:=?, open_options=['HEADERS=FORCE'])", []interface{}{s.path, sheet}
 }
@@ -0,0 +1,207 @@
+package importer
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	_ "github.com/marcboeker/go-duckdb"
+)
+
+// SheetTable はインポートする表（シート）と格納先テーブルの組
+type SheetTable struct {
+	Sheet string `json:"sheet"`
+	Table string `json:"table"`
+}
+
+// ImportedTable はインポート済みテーブルの情報
+type ImportedTable struct {
+	Sheet    string `json:"sheet"`
+	Table    string `json:"table"`
+	RowCount int    `json:"row_count"`
+}
+
+// ProgressFunc はインポートの進捗を受け取る（done は完了した表の数、sheet は次に読み込む表）
+type ProgressFunc func(done, total int, sheet SheetTable)
+
+// Import はSourceの指定した表をそれぞれ別テーブルとしてDuckDBにインポートする
+// appendRowsがtrueの場合は既存テーブルに追記し、falseの場合はテーブルを作り直す
+// すべての表を1つのトランザクションで読み込むため、途中で失敗した場合は既存のテーブルが残る
+func Import(src Source, dbPath string, sheets []SheetTable, appendRows bool) ([]ImportedTable, error) {
+	return ImportWithProgress(src, dbPath, sheets, appendRows, nil, nil)
+}
+
+// ImportWithProgress は Import と同じ処理を行い、表を読み込むたびに progress を呼び出す
+// staleTables のうち今回読み込む表に含まれないテーブルは、同じトランザクションで削除する
+func ImportWithProgress(src Source, dbPath string, sheets []SheetTable, appendRows bool, staleTables []string, progress ProgressFunc) ([]ImportedTable, error) {
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("no sheets to import")
+	}
+
+	// DuckDB接続
+	db, err := sql.Open("duckdb", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	// 拡張機能の自動インストールを有効化
+	if _, err := db.Exec("SET autoinstall_known_extensions = true;"); err != nil {
+		return nil, fmt.Errorf("set autoinstall failed: %w", err)
+	}
+	if _, err := db.Exec("SET autoload_known_extensions = true;"); err != nil {
+		return nil, fmt.Errorf("set autoload failed: %w", err)
+	}
+
+	if err := src.Prepare(db); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var imported []ImportedTable
+	for i, st := range sheets {
+		if progress != nil {
+			progress(i, len(sheets), st)
+		}
+		count, err := importTable(tx, src, st, appendRows)
+		if err != nil {
+			return nil, err
+		}
+		imported = append(imported, ImportedTable{
+			Sheet:    st.Sheet,
+			Table:    st.Table,
+			RowCount: count,
+		})
+	}
+
+	if err := dropStaleTables(tx, staleTables, sheets); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+
+	return imported, nil
+}
+
+// dropStaleTables は以前インポートしたテーブルのうち、今回読み込んだ表に含まれないものを削除する
+func dropStaleTables(tx *sql.Tx, staleTables []string, sheets []SheetTable) error {
+	current := make(map[string]bool, len(sheets))
+	for _, st := range sheets {
+		current[strings.ToLower(st.Table)] = true
+	}
+
+	for _, name := range staleTables {
+		if name == "" || current[strings.ToLower(name)] {
+			continue
+		}
+		if _, err := tx.Exec("DROP TABLE IF EXISTS " + quoteIdentifier(name)); err != nil {
+			return fmt.Errorf("failed to drop table %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// importTable は1つの表をテーブルとして作成（または追記）し、行数を返す
+func importTable(tx *sql.Tx, src Source, st SheetTable, appendRows bool) (int, error) {
+	table := quoteIdentifier(st.Table)
+	query, args := src.Query(st.Sheet)
+
+	if appendRows {
+		// テーブルが無ければスキーマだけ作ってから追記
+		createSQL := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s AS SELECT * FROM (%s) LIMIT 0", table, query)
+		if _, err := tx.Exec(createSQL, args...); err != nil {
+			return 0, fmt.Errorf("failed to create table %s: %w", st.Table, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("INSERT INTO %s %s", table, query), args...); err != nil {
+			return 0, fmt.Errorf("failed to insert into table %s: %w", st.Table, err)
+		}
+	} else {
+		// テーブルが既に存在する場合は削除
+		if _, err := tx.Exec("DROP TABLE IF EXISTS " + table); err != nil {
+			return 0, fmt.Errorf("failed to drop table %s: %w", st.Table, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("CREATE TABLE %s AS %s", table, query), args...); err != nil {
+			return 0, fmt.Errorf("failed to create table from %s: %w", describeSheet(src, st.Sheet), err)
+		}
+	}
+
+	// データが正常にインポートされたか確認
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count rows: %w", err)
+	}
+
+	return count, nil
+}
+
+// describeSheet はエラーメッセージ用に読み込み元を表す
+func describeSheet(src Source, sheet string) string {
+	if sheet == "" {
+		return string(src.Format()) + " file"
+	}
+	return fmt.Sprintf("sheet %q", sheet)
+}
+
+// DefaultSheetTables は各表にテーブル名を割り当てる
+// テーブル名はシート名（シートを持たない形式は元のファイル名）から作成し、重複する場合は連番を付ける
+func DefaultSheetTables(sheets []string, filename string) []SheetTable {
+	used := make(map[string]bool)
+	result := make([]SheetTable, len(sheets))
+	for i, sheet := range sheets {
+		name := sheet
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+		}
+		result[i] = SheetTable{
+			Sheet: sheet,
+			Table: uniqueTableName(TableNameForSheet(name), used),
+		}
+	}
+	return result
+}
+
+// TableNameForSheet はシート名をテーブル名として使える形に整える
+// 文字・数字以外は _ に置き換える（日本語はそのまま残す）
+func TableNameForSheet(sheet string) string {
+	var sb strings.Builder
+	for _, r := range strings.TrimSpace(sheet) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune('_')
+		}
+	}
+
+	name := strings.Trim(sb.String(), "_")
+	if name == "" {
+		name = "sheet"
+	}
+	if unicode.IsDigit([]rune(name)[0]) {
+		name = "t_" + name
+	}
+	return name
+}
+
+// uniqueTableName は重複しないテーブル名を返す（大文字小文字は区別しない）
+func uniqueTableName(name string, used map[string]bool) string {
+	candidate := name
+	for counter := 2; used[strings.ToLower(candidate)]; counter++ {
+		candidate = fmt.Sprintf("%s_%d", name, counter)
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// quoteIdentifier はテーブル名をダブルクォートで囲む
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,118 @@
+package importer
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Format はインポート元ファイルの形式
+type Format string
+
+const (
+	FormatXLSX    Format = "xlsx"
+	FormatCSV     Format = "csv"
+	FormatTSV     Format = "tsv"
+	FormatParquet Format = "parquet"
+	FormatJSONL   Format = "jsonl"
+)
+
+// formatExtensions は拡張子と形式の対応
+var formatExtensions = map[string]Format{
+	".xlsx":    FormatXLSX,
+	".csv":     FormatCSV,
+	".tsv":     FormatTSV,
+	".parquet": FormatParquet,
+	".jsonl":   FormatJSONL,
+	".ndjson":  FormatJSONL,
+}
+
+// SupportedExtensions はインポート可能な拡張子の一覧（ファイル選択の accept 属性用）
+func SupportedExtensions() []string {
+	return []string{".xlsx", ".csv", ".tsv", ".parquet", ".jsonl", ".ndjson"}
+}
+
+// DetectFormat はファイル名の拡張子から形式を判定
+func DetectFormat(filename string) (Format, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if format, ok := formatExtensions[ext]; ok {
+		return format, nil
+	}
+	return "", fmt.Errorf("unsupported file type: %s (supported: %s)", ext, strings.Join(SupportedExtensions(), ", "))
+}
+
+// ParseFormat は形式名（xlsx, csv, tsv, parquet, jsonl）を解釈
+func ParseFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(s)) {
+	case FormatXLSX, FormatCSV, FormatTSV, FormatParquet, FormatJSONL:
+		return Format(strings.ToLower(s)), nil
+	default:
+		return "", fmt.Errorf("unsupported format: %s", s)
+	}
+}
+
+// Extension はファイル保存時の拡張子を返す
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// Options はインポート時のオプション
+type Options struct {
+	Encoding  string // CSV/TSVの文字コード（空の場合は自動判定: utf-8 / shift_jis）
+	Delimiter string // CSVの区切り文字（空の場合は自動判定）
+}
+
+// Source はインポート元ファイル
+// 形式ごとの実装が、含まれる表の一覧と、表を読み込むSELECT文を提供する
+type Source interface {
+	// Format はファイル形式を返す
+	Format() Format
+	// Sheets はファイルに含まれる表の名前を返す（シートを持たない形式は空文字1件）
+	Sheets() ([]string, error)
+	// Prepare は読み込みに必要な拡張機能などを準備する
+	Prepare(db *sql.DB) error
+	// Query は指定した表を読み込むSELECT文とバインド引数を返す
+	Query(sheet string) (string, []interface{})
+	// Close は一時ファイルなどを片付ける
+	Close() error
+}
+
+// Open はファイル形式に応じたSourceを作成
+func Open(path string, format Format, opts Options) (Source, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	switch format {
+	case FormatXLSX:
+		return &xlsxSource{path: absPath}, nil
+	case FormatCSV:
+		return openCSVSource(absPath, FormatCSV, opts)
+	case FormatTSV:
+		opts.Delimiter = "\t"
+		return openCSVSource(absPath, FormatTSV, opts)
+	case FormatParquet:
+		return &fileSource{path: absPath, format: FormatParquet, query: "SELECT * FROM read_parquet(?)"}, nil
+	case FormatJSONL:
+		return &fileSource{path: absPath, format: FormatJSONL, query: "SELECT * FROM read_json(?, format='newline_delimited')"}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// fileSource は1ファイル1表の形式（Parquet, JSON Lines）のSource
+type fileSource struct {
+	path   string
+	format Format
+	query  string
+}
+
+func (s *fileSource) Format() Format            { return s.format }
+func (s *fileSource) Sheets() ([]string, error) { return []string{""}, nil }
+func (s *fileSource) Prepare(db *sql.DB) error  { return nil }
+func (s *fileSource) Close() error              { return nil }
+func (s *fileSource) Query(string) (string, []interface{}) {
+	return s.query, []interface{}{s.path}
+}
@@ -0,0 +1,83 @@
+// Package jobs は時間のかかる処理（インポートなど）をバックグラウンドで実行する
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+// ProgressFunc は処理の進捗（0〜100）と説明を報告する
+type ProgressFunc func(progress int, message string)
+
+// Task はバックグラウンドで実行する処理
+type Task func(report ProgressFunc) error
+
+// Store はジョブの進捗と結果を記録する先
+type Store interface {
+	UpdateJobProgress(id string, progress int, message string) error
+	FinishJob(id string, jobErr error) error
+}
+
+// Runner はジョブを同時実行数を制限して実行する
+type Runner struct {
+	store Store
+	sem   chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewRunner はRunnerを作成（concurrencyは同時に実行するジョブの数）
+func NewRunner(store Store, concurrency int) *Runner {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Runner{
+		store: store,
+		sem:   make(chan struct{}, concurrency),
+	}
+}
+
+// Start はジョブをバックグラウンドで開始する
+// 実行枠が空くまで待機し、終了時にStoreへ結果を記録する
+func (r *Runner) Start(id string, task Task) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		r.sem <- struct{}{}
+		defer func() { <-r.sem }()
+
+		err := r.run(id, task)
+		if finishErr := r.store.FinishJob(id, err); finishErr != nil {
+			log.Printf("job %s: %v", id, finishErr)
+		}
+	}()
+}
+
+// run はタスクを実行する（panicはエラーとして扱う）
+func (r *Runner) run(id string, task Task) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+
+	report := func(progress int, message string) {
+		if progress < 0 {
+			progress = 0
+		} else if progress > 100 {
+			progress = 100
+		}
+		if err := r.store.UpdateJobProgress(id, progress, message); err != nil {
+			log.Printf("job %s: %v", id, err)
+		}
+	}
+
+	report(0, "開始")
+	return task(report)
+}
+
+// Wait は実行中のジョブがすべて終わるまで待つ
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
@@ -0,0 +1,199 @@
+package project
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrImportRunning はプロジェクトに終了していないインポートジョブがあることを表す
+var ErrImportRunning = errors.New("import is already running")
+
+// ImportJob はバックグラウンドで実行するインポート処理の状態
+type ImportJob struct {
+	ID         string     `json:"id"`
+	ProjectID  string     `json:"project_id"`
+	Status     string     `json:"status"`   // 'queued', 'running', 'succeeded', 'failed'
+	Progress   int        `json:"progress"` // 0〜100
+	Message    string     `json:"message"`  // 進捗の説明（処理中のシートなど）
+	Error      string     `json:"error"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+// JobStatus はインポートジョブの状態
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// IsFinished はジョブが終了しているかどうかを返す
+func (j *ImportJob) IsFinished() bool {
+	return j.Status == string(JobSucceeded) || j.Status == string(JobFailed)
+}
+
+// NewImportJob は待機中のインポートジョブを作成
+func NewImportJob(id, projectID string) *ImportJob {
+	now := time.Now()
+	return &ImportJob{
+		ID:        id,
+		ProjectID: projectID,
+		Status:    string(JobQueued),
+		Message:   "待機中",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// CreateImportJob はインポートジョブを登録
+// 同じプロジェクトに終了していないジョブがある場合は登録せず ErrImportRunning を返す
+// 確認と登録を1つの文で行うため、同時に開始しようとしても登録されるジョブは1つだけになる
+func (r *Repository) CreateImportJob(job *ImportJob) error {
+	query := `
+		INSERT INTO import_jobs (id, project_id, status, progress, message, error, created_at, updated_at)
+		SELECT ?, ?, ?, ?, ?, ?, ?, ?
+		WHERE NOT EXISTS (
+			SELECT 1 FROM import_jobs WHERE project_id = ? AND status IN (?, ?)
+		)
+	`
+
+	result, err := r.db.Exec(query,
+		job.ID,
+		job.ProjectID,
+		job.Status,
+		job.Progress,
+		job.Message,
+		job.Error,
+		job.CreatedAt,
+		job.UpdatedAt,
+		job.ProjectID,
+		string(JobQueued),
+		string(JobRunning),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	created, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to create import job: %w", err)
+	}
+	if created == 0 {
+		return ErrImportRunning
+	}
+
+	return nil
+}
+
+// FindLatestImportJob はプロジェクトの最新のインポートジョブを取得（ない場合はnil）
+func (r *Repository) FindLatestImportJob(projectID string) (*ImportJob, error) {
+	query := `
+		SELECT id, project_id, status, progress, message, error, created_at, updated_at, finished_at
+		FROM import_jobs
+		WHERE project_id = ?
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	job := &ImportJob{}
+	var finishedAt sql.NullTime
+	err := r.db.QueryRow(query, projectID).Scan(
+		&job.ID,
+		&job.ProjectID,
+		&job.Status,
+		&job.Progress,
+		&job.Message,
+		&job.Error,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&finishedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find import job: %w", err)
+	}
+
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+
+	return job, nil
+}
+
+// UpdateJobProgress はジョブを実行中にして進捗を記録
+func (r *Repository) UpdateJobProgress(id string, progress int, message string) error {
+	query := `
+		UPDATE import_jobs
+		SET status = ?, progress = ?, message = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	if _, err := r.db.Exec(query, string(JobRunning), progress, message, time.Now(), id); err != nil {
+		return fmt.Errorf("failed to update import job: %w", err)
+	}
+
+	return nil
+}
+
+// FinishJob はジョブの終了を記録（jobErr が nil なら成功）
+func (r *Repository) FinishJob(id string, jobErr error) error {
+	status := string(JobSucceeded)
+	message := "完了"
+	errMessage := ""
+	if jobErr != nil {
+		status = string(JobFailed)
+		message = "失敗"
+		errMessage = jobErr.Error()
+	}
+
+	// 失敗した場合は進捗をどこまで進んだかの目安として残す
+	query := `
+		UPDATE import_jobs
+		SET status = ?, progress = CASE WHEN ? THEN 100 ELSE progress END, message = ?, error = ?, updated_at = ?, finished_at = ?
+		WHERE id = ?
+	`
+
+	now := time.Now()
+	if _, err := r.db.Exec(query, status, jobErr == nil, message, errMessage, now, now, id); err != nil {
+		return fmt.Errorf("failed to finish import job: %w", err)
+	}
+
+	return nil
+}
+
+// FailInterruptedJobs は前回の起動中に終わらなかったジョブとプロジェクトをエラーにする
+// サーバーの再起動時に呼び出す
+func (r *Repository) FailInterruptedJobs() error {
+	const message = "サーバーの再起動によりインポートが中断されました"
+	now := time.Now()
+
+	_, err := r.db.Exec(`
+		UPDATE projects
+		SET status = ?, error_message = ?, updated_at = ?
+		WHERE id IN (SELECT project_id FROM import_jobs WHERE status IN (?, ?))
+	`, string(StatusError), message, now, string(JobQueued), string(JobRunning))
+	if err != nil {
+		return fmt.Errorf("failed to fail interrupted projects: %w", err)
+	}
+
+	_, err = r.db.Exec(`
+		UPDATE import_jobs
+		SET status = ?, message = '失敗', error = ?, updated_at = ?, finished_at = ?
+		WHERE status IN (?, ?)
+	`, string(JobFailed), message, now, now, string(JobQueued), string(JobRunning))
+	if err != nil {
+		return fmt.Errorf("failed to fail interrupted jobs: %w", err)
+	}
+
+	return nil
+}
@@ -6,14 +6,24 @@ import (
 
 // Project はプロジェクトのドメインモデル
 type Project struct {
-	ID            string    `json:"id"`
-	Name          string    `json:"name"`
-	Description   string    `json:"description"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
-	TableName     string    `json:"table_name"`
-	ExcelFilename string    `json:"excel_filename"`
-	Status        string    `json:"status"` // 'ready', 'importing', 'error'
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	TableName      string    `json:"table_name"`
+	SourceFilename string    `json:"source_filename"` // アップロードされた元のファイル名
+	SourceFormat   string    `json:"source_format"`   // 元ファイルの形式（xlsx, csv, tsv, parquet, jsonl）
+	Status         string    `json:"status"`          // 'ready', 'importing', 'error'
+	ErrorMessage   string    `json:"error_message"`   // インポートに失敗した場合のエラー
+	Tables         []Table   `json:"tables"`          // インポート済みのテーブル（シートごと）
+}
+
+// Table はプロジェクトにインポートされたテーブル
+type Table struct {
+	Name      string `json:"name"`       // DuckDBのテーブル名
+	SheetName string `json:"sheet_name"` // 元のシート名
+	RowCount  int    `json:"row_count"`
 }
 
 // ProjectStatus はプロジェクトの状態
@@ -48,9 +58,29 @@ func (p *Project) GetDuckDBPath(baseDir string) string {
 	return p.GetProjectDir(baseDir) + "/data.duckdb"
 }
 
-// GetExcelPath はExcelファイルのパスを返す
-func (p *Project) GetExcelPath(baseDir string) string {
-	return p.GetProjectDir(baseDir) + "/source.xlsx"
+// GetSourcePath はアップロードされた元ファイルのパスを返す
+// 形式が記録されていない以前のプロジェクトはExcelファイルとして扱う
+func (p *Project) GetSourcePath(baseDir string) string {
+	ext := p.SourceFormat
+	if ext == "" {
+		ext = "xlsx"
+	}
+	return p.GetProjectDir(baseDir) + "/source." + ext
+}
+
+// FindTable は名前でテーブルを検索（見つからない場合はnil）
+func (p *Project) FindTable(name string) *Table {
+	for i := range p.Tables {
+		if p.Tables[i].Name == name {
+			return &p.Tables[i]
+		}
+	}
+	return nil
+}
+
+// GetDataSourcePath は集計対象テーブル（結合）設定ファイルのパスを返す
+func (p *Project) GetDataSourcePath(baseDir string) string {
+	return p.GetProjectDir(baseDir) + "/data_source.yaml"
 }
 
 // GetDerivedColumnsPath は派生列設定ファイルのパスを返す
@@ -67,3 +97,13 @@ func (p *Project) GetFiltersPath(baseDir string) string {
 func (p *Project) GetColumnOrdersPath(baseDir string) string {
 	return p.GetProjectDir(baseDir) + "/column_orders.yaml"
 }
+
+// GetQuestionGroupsPath はマトリクス設問（設問グループ）設定ファイルのパスを返す
+func (p *Project) GetQuestionGroupsPath(baseDir string) string {
+	return p.GetProjectDir(baseDir) + "/question_groups.yaml"
+}
+
+// GetStopwordsPath は自由回答の語の出現頻度から除く語の設定ファイルのパスを返す
+func (p *Project) GetStopwordsPath(baseDir string) string {
+	return p.GetProjectDir(baseDir) + "/stopwords.yaml"
+}
@@ -20,6 +20,9 @@ func NewRepository(dbPath string) (*Repository, error) {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
+	// バックグラウンドのインポートと同時に書き込むため、接続を1つにして書き込みを直列化する
+	db.SetMaxOpenConns(1)
+
 	// テーブル作成
 	if err := createTables(db); err != nil {
 		db.Close()
@@ -44,23 +47,100 @@ func createTables(db *sql.DB) error {
 		created_at TIMESTAMP NOT NULL,
 		updated_at TIMESTAMP NOT NULL,
 		table_name TEXT,
-		excel_filename TEXT,
-		status TEXT NOT NULL
+		source_filename TEXT,
+		source_format TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		error_message TEXT NOT NULL DEFAULT ''
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_projects_created_at ON projects(created_at DESC);
 	CREATE INDEX IF NOT EXISTS idx_projects_status ON projects(status);
+
+	CREATE TABLE IF NOT EXISTS project_tables (
+		project_id TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		table_name TEXT NOT NULL,
+		sheet_name TEXT,
+		row_count INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (project_id, table_name)
+	);
+
+	CREATE TABLE IF NOT EXISTS import_jobs (
+		id TEXT PRIMARY KEY,
+		project_id TEXT NOT NULL,
+		status TEXT NOT NULL,
+		progress INTEGER NOT NULL DEFAULT 0,
+		message TEXT NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		finished_at TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_import_jobs_project ON import_jobs(project_id, created_at DESC);
 	`
 
-	_, err := db.Exec(schema)
-	return err
+	if _, err := db.Exec(schema); err != nil {
+		return err
+	}
+
+	return migrateTables(db)
+}
+
+// migrateTables は以前のバージョンで作成されたテーブルに不足している列を追加する
+func migrateTables(db *sql.DB) error {
+	columns, err := tableColumns(db, "projects")
+	if err != nil {
+		return err
+	}
+
+	// excel_filename は CSV などにも対応したため source_filename に改名
+	if columns["excel_filename"] && !columns["source_filename"] {
+		if _, err := db.Exec("ALTER TABLE projects RENAME COLUMN excel_filename TO source_filename"); err != nil {
+			return fmt.Errorf("failed to rename excel_filename: %w", err)
+		}
+	}
+
+	if !columns["source_format"] {
+		if _, err := db.Exec("ALTER TABLE projects ADD COLUMN source_format TEXT NOT NULL DEFAULT ''"); err != nil {
+			return fmt.Errorf("failed to add source_format: %w", err)
+		}
+	}
+
+	if !columns["error_message"] {
+		if _, err := db.Exec("ALTER TABLE projects ADD COLUMN error_message TEXT NOT NULL DEFAULT ''"); err != nil {
+			return fmt.Errorf("failed to add error_message: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// tableColumns はテーブルの列名の集合を返す
+func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table info: %w", err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan table info: %w", err)
+		}
+		columns[name] = true
+	}
+
+	return columns, rows.Err()
 }
 
 // Create はプロジェクトを作成
 func (r *Repository) Create(p *Project) error {
 	query := `
-		INSERT INTO projects (id, name, description, created_at, updated_at, table_name, excel_filename, status)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO projects (id, name, description, created_at, updated_at, table_name, source_filename, source_format, status, error_message)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := r.db.Exec(query,
@@ -70,8 +150,10 @@ func (r *Repository) Create(p *Project) error {
 		p.CreatedAt,
 		p.UpdatedAt,
 		p.TableName,
-		p.ExcelFilename,
+		p.SourceFilename,
+		p.SourceFormat,
 		p.Status,
+		p.ErrorMessage,
 	)
 
 	if err != nil {
@@ -84,7 +166,7 @@ func (r *Repository) Create(p *Project) error {
 // FindByID はIDでプロジェクトを取得
 func (r *Repository) FindByID(id string) (*Project, error) {
 	query := `
-		SELECT id, name, description, created_at, updated_at, table_name, excel_filename, status
+		SELECT id, name, description, created_at, updated_at, table_name, source_filename, source_format, status, error_message
 		FROM projects
 		WHERE id = ?
 	`
@@ -97,8 +179,10 @@ func (r *Repository) FindByID(id string) (*Project, error) {
 		&p.CreatedAt,
 		&p.UpdatedAt,
 		&p.TableName,
-		&p.ExcelFilename,
+		&p.SourceFilename,
+		&p.SourceFormat,
 		&p.Status,
+		&p.ErrorMessage,
 	)
 
 	if err == sql.ErrNoRows {
@@ -109,13 +193,17 @@ func (r *Repository) FindByID(id string) (*Project, error) {
 		return nil, fmt.Errorf("failed to find project: %w", err)
 	}
 
+	if p.Tables, err = r.FindTables(p.ID); err != nil {
+		return nil, err
+	}
+
 	return p, nil
 }
 
 // FindAll は全てのプロジェクトを取得
 func (r *Repository) FindAll() ([]*Project, error) {
 	query := `
-		SELECT id, name, description, created_at, updated_at, table_name, excel_filename, status
+		SELECT id, name, description, created_at, updated_at, table_name, source_filename, source_format, status, error_message
 		FROM projects
 		ORDER BY created_at DESC
 	`
@@ -136,8 +224,10 @@ func (r *Repository) FindAll() ([]*Project, error) {
 			&p.CreatedAt,
 			&p.UpdatedAt,
 			&p.TableName,
-			&p.ExcelFilename,
+			&p.SourceFilename,
+			&p.SourceFormat,
 			&p.Status,
+			&p.ErrorMessage,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan project: %w", err)
@@ -149,6 +239,12 @@ func (r *Repository) FindAll() ([]*Project, error) {
 		return nil, fmt.Errorf("error iterating projects: %w", err)
 	}
 
+	for _, p := range projects {
+		if p.Tables, err = r.FindTables(p.ID); err != nil {
+			return nil, err
+		}
+	}
+
 	return projects, nil
 }
 
@@ -158,7 +254,7 @@ func (r *Repository) Update(p *Project) error {
 
 	query := `
 		UPDATE projects
-		SET name = ?, description = ?, updated_at = ?, table_name = ?, excel_filename = ?, status = ?
+		SET name = ?, description = ?, updated_at = ?, table_name = ?, source_filename = ?, source_format = ?, status = ?, error_message = ?
 		WHERE id = ?
 	`
 
@@ -167,8 +263,10 @@ func (r *Repository) Update(p *Project) error {
 		p.Description,
 		p.UpdatedAt,
 		p.TableName,
-		p.ExcelFilename,
+		p.SourceFilename,
+		p.SourceFormat,
 		p.Status,
+		p.ErrorMessage,
 		p.ID,
 	)
 
@@ -188,5 +286,75 @@ func (r *Repository) Delete(id string) error {
 		return fmt.Errorf("failed to delete project: %w", err)
 	}
 
+	if _, err := r.db.Exec("DELETE FROM project_tables WHERE project_id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete project tables: %w", err)
+	}
+
+	if _, err := r.db.Exec("DELETE FROM import_jobs WHERE project_id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete import jobs: %w", err)
+	}
+
+	return nil
+}
+
+// FindTables はプロジェクトのテーブル一覧をインポート順に取得
+func (r *Repository) FindTables(projectID string) ([]Table, error) {
+	query := `
+		SELECT table_name, sheet_name, row_count
+		FROM project_tables
+		WHERE project_id = ?
+		ORDER BY position
+	`
+
+	rows, err := r.db.Query(query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query project tables: %w", err)
+	}
+	defer rows.Close()
+
+	tables := []Table{}
+	for rows.Next() {
+		var t Table
+		var sheetName sql.NullString
+		if err := rows.Scan(&t.Name, &sheetName, &t.RowCount); err != nil {
+			return nil, fmt.Errorf("failed to scan project table: %w", err)
+		}
+		t.SheetName = sheetName.String
+		tables = append(tables, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating project tables: %w", err)
+	}
+
+	return tables, nil
+}
+
+// SaveTables はプロジェクトのテーブル一覧を置き換える
+func (r *Repository) SaveTables(projectID string, tables []Table) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM project_tables WHERE project_id = ?", projectID); err != nil {
+		return fmt.Errorf("failed to clear project tables: %w", err)
+	}
+
+	query := `
+		INSERT INTO project_tables (project_id, position, table_name, sheet_name, row_count)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	for i, t := range tables {
+		if _, err := tx.Exec(query, projectID, i, t.Name, t.SheetName, t.RowCount); err != nil {
+			return fmt.Errorf("failed to save project table: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit project tables: %w", err)
+	}
+
 	return nil
 }
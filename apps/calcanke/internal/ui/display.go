@@ -1,56 +1,454 @@
 package ui
 
 import (
+	"database/sql"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
 	"github.com/olekukonko/tablewriter"
 )
 
-// DisplayCrosstabResult はクロス集計の結果を表形式で表示
-func DisplayCrosstabResult(result *analyzer.CrosstabResult) {
+// DisplayCrosstabPivot はクロス集計の結果を集計表（先頭に全体行、左に全体列）の形式で表示
+// 各セルには件数と、選択された基準の割合を表示する
+func DisplayCrosstabPivot(pivot *analyzer.CrosstabPivot) {
 	fmt.Printf("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("クロス集計結果\n")
+	fmt.Printf("クロス集計結果: %s × %s（%s）\n", pivot.XColumn, pivot.YColumn, pivot.PercentageBase.Label())
 	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
 
+	header := []string{pivot.XColumn + " \\ " + pivot.YColumn, "全体"}
+	header = append(header, pivot.YValues...)
+
 	table := tablewriter.NewWriter(os.Stdout)
-	table.Header(result.XColumn, result.YColumn, "件数", "割合")
+	table.Header(header)
 
-	for _, row := range result.Rows {
-		table.Append(
-			row.XValue,
-			row.YValue,
-			formatNumber(row.Count),
-			fmt.Sprintf("%.1f%%", row.Percentage),
-		)
+	// 全体行（Y値ごとの合計）
+	cells := []string{"全体", formatPivotCell(pivot, pivot.GrandTotal)}
+	for _, y := range pivot.YValues {
+		cells = append(cells, formatPivotCell(pivot, pivot.ColumnTotals[y]))
+	}
+	table.Append(cells)
+
+	for _, x := range pivot.XValues {
+		cells := []string{x, formatPivotCell(pivot, pivot.RowTotals[x])}
+		for _, y := range pivot.YValues {
+			cells = append(cells, formatPivotCell(pivot, pivot.Matrix[x][y]))
+		}
+		table.Append(cells)
 	}
 
 	table.Render()
 
-	fmt.Printf("\n総件数: %s\n\n", formatNumber(result.Total))
+	fmt.Printf("\n総件数: %s\n", formatNumber(pivot.Total))
+	if pivot.IsWeighted() {
+		fmt.Printf("ウェイト: %s（ウェイト付き総数: %.1f）\n", pivot.WeightColumn, pivot.WeightedTotal)
+	}
+	if test := pivot.Significance; test != nil {
+		fmt.Printf("χ²検定: χ² = %.2f, 自由度 = %d, p = %.3f, Cramér's V = %.3f\n", test.ChiSquare, test.DF, test.PValue, test.CramersV)
+	}
+	fmt.Println()
+}
+
+// formatPivotCell は集計表の1セルを「件数 (割合)」の形式にする
+// ウェイト付きの場合はウェイト付きの件数・割合とnを表示する
+func formatPivotCell(pivot *analyzer.CrosstabPivot, cell analyzer.CrosstabCell) string {
+	if !cell.Exists {
+		return "-"
+	}
+	if pivot.IsWeighted() {
+		return fmt.Sprintf("%.1f (%.1f%%%s) n=%d", cell.WeightedCount, cell.WeightedPercentage, cell.Marker, cell.Count)
+	}
+	return fmt.Sprintf("%s (%.1f%%%s)", formatNumber(cell.Count), cell.Percentage, cell.Marker)
 }
 
 // DisplaySimpletabResult は単純集計の結果を表形式で表示
+// 複数回答を分割した場合は回答数ベースの割合に並べて回答者数ベースの割合（回答者%）を表示する
 func DisplaySimpletabResult(result *analyzer.SimpletabResult) {
 	fmt.Printf("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 	fmt.Printf("単純集計結果: %s\n", result.Column)
 	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
 
+	header := []string{result.Column, "件数", "割合"}
+	if result.IsSplit() {
+		header = append(header, "回答者%")
+	}
+	if result.IsWeighted() {
+		header = append(header, "ウェイト付き件数", "ウェイト付き割合")
+		if result.IsSplit() {
+			header = append(header, "ウェイト付き回答者%")
+		}
+	}
+
 	table := tablewriter.NewWriter(os.Stdout)
-	table.Header(result.Column, "件数", "割合")
+	table.Header(header)
 
 	for _, row := range result.Rows {
-		table.Append(
+		cells := []string{
 			row.Value,
 			formatNumber(row.Count),
 			fmt.Sprintf("%.1f%%", row.Percentage),
-		)
+		}
+		if result.IsSplit() {
+			cells = append(cells, fmt.Sprintf("%.1f%%", row.RespondentPercentage))
+		}
+		if result.IsWeighted() {
+			cells = append(cells, fmt.Sprintf("%.1f", row.WeightedCount), fmt.Sprintf("%.1f%%", row.WeightedPercentage))
+			if result.IsSplit() {
+				cells = append(cells, fmt.Sprintf("%.1f%%", row.WeightedRespondentPercentage))
+			}
+		}
+		table.Append(cells)
+	}
+
+	table.Render()
+
+	fmt.Printf("\n総件数: %s\n", formatNumber(result.Total))
+	if result.IsSplit() {
+		fmt.Printf("回答者数: %s（回答者%%は回答者数に対する割合）\n", formatNumber(result.Respondents))
+	}
+	if result.IsWeighted() {
+		fmt.Printf("ウェイト: %s（ウェイト付き総数: %.1f）\n", result.WeightColumn, result.WeightedTotal)
+	}
+	fmt.Println()
+}
+
+// DisplayNumericStats は数値の記述統計量を表形式で表示
+// グループ分けした場合は先頭に全体行、続けてグループの値ごとの行を表示する
+func DisplayNumericStats(result *analyzer.NumericStatsResult) {
+	fmt.Printf("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	if result.IsGrouped() {
+		fmt.Printf("記述統計量: %s × %s\n", result.GroupColumn, result.Column)
+	} else {
+		fmt.Printf("記述統計量: %s\n", result.Column)
+	}
+	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	header := []string{result.GroupColumn, "n", "欠損", "平均", "標準偏差", "最小値", "第1四分位", "中央値", "第3四分位", "最大値"}
+	if result.IsWeighted() {
+		header = append(header, "ウェイト付き平均")
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header(header)
+
+	table.Append(formatNumericStats(result, "全体", result.Overall))
+	for _, group := range result.Groups {
+		table.Append(formatNumericStats(result, group.Group, group))
+	}
+
+	table.Render()
+
+	if result.IsWeighted() {
+		fmt.Printf("\nウェイト: %s\n", result.WeightColumn)
+	}
+	fmt.Println()
+}
+
+// formatNumericStats は記述統計量の1行分のセルを作成（計算できない統計量は – を表示）
+func formatNumericStats(result *analyzer.NumericStatsResult, label string, s analyzer.NumericStats) []string {
+	cells := []string{label, formatNumber(s.N), formatNumber(s.Missing)}
+	values := []sql.NullFloat64{s.Mean, s.SD, s.Min, s.Q1, s.Median, s.Q3, s.Max}
+	if result.IsWeighted() {
+		values = append(values, s.WeightedMean)
+	}
+	for _, v := range values {
+		if v.Valid {
+			cells = append(cells, fmt.Sprintf("%.2f", v.Float64))
+		} else {
+			cells = append(cells, "–")
+		}
+	}
+	return cells
+}
+
+// DisplayScaleSummary は評価尺度の集計結果を表形式で表示
+// ウェイト付きの場合、割合・平均点はウェイト付きの値、nは実数を表示する
+func DisplayScaleSummary(result *analyzer.ScaleSummaryResult) {
+	kind := "評価尺度"
+	if result.IsNPS() {
+		kind = "NPS"
+	}
+	fmt.Printf("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	if result.IsGrouped() {
+		fmt.Printf("%s: %s × %s\n", kind, result.GroupColumn, result.Column)
+	} else {
+		fmt.Printf("%s: %s\n", kind, result.Column)
+	}
+	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	header := []string{result.GroupColumn, "n", "対象外", result.TopBoxLabel(), result.BottomBoxLabel(), "平均"}
+	if result.IsNPS() {
+		header = []string{result.GroupColumn, "n", "対象外", "推奨者", "中立者", "批判者", "NPS", "平均"}
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header(header)
+
+	table.Append(formatScaleSummary(result, "全体", result.Overall))
+	for _, group := range result.Groups {
+		table.Append(formatScaleSummary(result, group.Group, group))
+	}
+
+	table.Render()
+
+	fmt.Println()
+	if result.IsNPS() {
+		fmt.Printf("推奨者: %g以上、批判者: %g以下、NPS = 推奨者%% − 批判者%%\n", result.PromoterMin, result.DetractorMax)
+	} else {
+		fmt.Printf("%s: %s\n", result.TopBoxLabel(), strings.Join(result.TopBoxValues(), "・"))
+		fmt.Printf("%s: %s\n", result.BottomBoxLabel(), strings.Join(result.BottomBoxValues(), "・"))
+	}
+	if result.IsWeighted() {
+		fmt.Printf("ウェイト: %s\n", result.WeightColumn)
+	}
+	fmt.Println()
+}
+
+// formatScaleSummary は評価尺度の集計の1行分のセルを作成（集計対象がない場合は - を表示）
+func formatScaleSummary(result *analyzer.ScaleSummaryResult, label string, s analyzer.ScaleSummary) []string {
+	m := s.Metrics
+	if result.IsWeighted() {
+		m = s.Weighted
+	}
+
+	cells := []string{label, formatNumber(s.N), formatNumber(s.Excluded)}
+	if s.N == 0 {
+		dashes := 3
+		if result.IsNPS() {
+			dashes = 5
+		}
+		for i := 0; i < dashes; i++ {
+			cells = append(cells, "-")
+		}
+		return cells
+	}
+
+	if result.IsNPS() {
+		return append(cells,
+			fmt.Sprintf("%.1f%%", m.Promoters), fmt.Sprintf("%.1f%%", m.Passives), fmt.Sprintf("%.1f%%", m.Detractors),
+			fmt.Sprintf("%+.1f", m.NPS), fmt.Sprintf("%.2f", m.Mean))
+	}
+	return append(cells, fmt.Sprintf("%.1f%%", m.TopBox), fmt.Sprintf("%.1f%%", m.BottomBox), fmt.Sprintf("%.2f", m.Mean))
+}
+
+// DisplayGrid はマトリクス設問の集計結果を表形式で表示（行: 項目、列: 選択肢）
+// 各セルには割合（項目ごとの回答数に対する行%）と件数を表示する
+func DisplayGrid(result *analyzer.GridResult) {
+	fmt.Printf("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	if result.IsGrouped() {
+		fmt.Printf("マトリクス設問: %s × %s\n", result.Group, result.BannerColumn)
+	} else {
+		fmt.Printf("マトリクス設問: %s\n", result.Group)
+	}
+	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	header := []string{"項目"}
+	if result.IsGrouped() {
+		header = append(header, result.BannerColumn)
+	}
+	header = append(header, "n")
+	header = append(header, result.Values...)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header(header)
+
+	for _, row := range result.Rows {
+		cells := []string{row.Item}
+		if result.IsGrouped() {
+			if row.IsItemTotal() {
+				cells = append(cells, "全体")
+			} else {
+				cells = []string{"", row.Banner}
+			}
+		}
+		cells = append(cells, formatNumber(row.Total))
+		for _, cell := range row.Cells {
+			p := cell.Percentage
+			if result.IsWeighted() {
+				p = cell.WeightedPercentage
+			}
+			cells = append(cells, fmt.Sprintf("%.1f%% (%d)", p, cell.Count))
+		}
+		table.Append(cells)
+	}
+
+	table.Render()
+
+	if result.IsWeighted() {
+		fmt.Printf("\nウェイト: %s（割合はウェイト付き、件数は実数）\n", result.WeightColumn)
+	}
+	fmt.Println()
+}
+
+// DisplaySelectionCount は複数回答の選択数の分布を表形式で表示
+func DisplaySelectionCount(result *analyzer.SelectionCountResult) {
+	fmt.Printf("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	fmt.Printf("選択数の分布: %s\n", result.Column)
+	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	header := []string{"選択数", "回答者数", "割合"}
+	if result.IsWeighted() {
+		header = append(header, "ウェイト付き回答者数", "ウェイト付き割合")
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header(header)
+
+	for _, row := range result.Rows {
+		cells := []string{
+			fmt.Sprintf("%d", row.Selections),
+			formatNumber(row.Count),
+			fmt.Sprintf("%.1f%%", row.Percentage),
+		}
+		if result.IsWeighted() {
+			cells = append(cells, fmt.Sprintf("%.1f", row.WeightedCount), fmt.Sprintf("%.1f%%", row.WeightedPercentage))
+		}
+		table.Append(cells)
 	}
 
 	table.Render()
 
-	fmt.Printf("\n総件数: %s\n\n", formatNumber(result.Total))
+	fmt.Printf("\n回答者数: %s、平均選択数: %.2f\n", formatNumber(result.Respondents), result.Mean)
+	if result.IsWeighted() {
+		fmt.Printf("ウェイト: %s（ウェイト付き平均選択数: %.2f）\n", result.WeightColumn, result.WeightedMean)
+	}
+	fmt.Println()
+}
+
+// DisplayCooccurrence は複数回答の同時選択の表を表示（行・列: 選択肢）
+// 各セルには両方を選んだ回答者数と、行の選択肢を選んだ回答者に対する割合を表示する
+func DisplayCooccurrence(result *analyzer.CooccurrenceResult) {
+	fmt.Printf("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	fmt.Printf("同時選択: %s\n", result.Column)
+	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header(append([]string{""}, result.Options...))
+
+	for _, rowItem := range result.Options {
+		cells := []string{rowItem}
+		for _, columnItem := range result.Options {
+			cell := result.Cell(rowItem, columnItem)
+			p := cell.Percentage
+			if result.IsWeighted() {
+				p = cell.WeightedPercentage
+			}
+			cells = append(cells, fmt.Sprintf("%d (%.1f%%)", cell.Count, p))
+		}
+		table.Append(cells)
+	}
+
+	table.Render()
+
+	fmt.Printf("\n回答者数: %s（対角は回答者全体に対する割合）\n", formatNumber(result.Respondents))
+	if result.IsWeighted() {
+		fmt.Printf("ウェイト: %s（割合はウェイト付き、人数は実数）\n", result.WeightColumn)
+	}
+	fmt.Println()
+}
+
+// DisplayWordFrequency は自由回答の語の出現頻度を表形式で表示
+func DisplayWordFrequency(result *analyzer.WordFrequencyResult) {
+	fmt.Printf("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	fmt.Printf("語の出現頻度: %s\n", result.Column)
+	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	header := []string{"語", "品詞", "出現回数", "回答者数", "割合"}
+	if result.IsWeighted() {
+		header = append(header, "ウェイト付き回答者数", "ウェイト付き割合")
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header(header)
+
+	for _, row := range result.Rows {
+		cells := []string{
+			row.Word,
+			row.PartOfSpeech,
+			formatNumber(row.Occurrences),
+			formatNumber(row.Count),
+			fmt.Sprintf("%.1f%%", row.Percentage),
+		}
+		if result.IsWeighted() {
+			cells = append(cells, fmt.Sprintf("%.1f", row.WeightedCount), fmt.Sprintf("%.1f%%", row.WeightedPercentage))
+		}
+		table.Append(cells)
+	}
+
+	table.Render()
+
+	fmt.Printf("\n回答者数: %s、異なり語数: %s（上位%d語を表示）\n", formatNumber(result.Respondents), formatNumber(result.DistinctWords), len(result.Rows))
+	if result.IsWeighted() {
+		fmt.Printf("ウェイト: %s（ウェイト付き回答者数: %.1f）\n", result.WeightColumn, result.WeightedRespondents)
+	}
+	fmt.Println()
+}
+
+// DisplayKeywordContexts はキーワードを含む回答をキーワードの前後の文字列とともに表示（KWIC）
+func DisplayKeywordContexts(result *analyzer.KeywordContextResult) {
+	fmt.Printf("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	fmt.Printf("キーワードを含む回答: %s\n", result.Column)
+	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("前", "キーワード", "後")
+	for _, row := range result.Rows {
+		table.Append([]string{row.Left, row.Keyword, row.Right})
+	}
+	table.Render()
+
+	fmt.Printf("\nキーワード: %s\n", strings.Join(result.Keywords, "、"))
+	fmt.Printf("キーワードを含む回答者数: %s / %s\n", formatNumber(result.Matches), formatNumber(result.Respondents))
+	if result.Truncated {
+		fmt.Printf("（先頭の%d件のみ表示）\n", len(result.Rows))
+	}
+	fmt.Println()
+}
+
+// DisplayKeywordCrosstab はキーワードを含む回答者の表を表示（行: キーワード、列: 全体とバナーの値）
+func DisplayKeywordCrosstab(result *analyzer.KeywordCrosstabResult) {
+	fmt.Printf("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	if result.IsGrouped() {
+		fmt.Printf("キーワードを含む回答者: %s × %s\n", result.Column, result.BannerColumn)
+	} else {
+		fmt.Printf("キーワードを含む回答者: %s\n", result.Column)
+	}
+	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	header := []string{"キーワード"}
+	totals := []string{"回答者数"}
+	for _, column := range result.Columns {
+		if column.Banner == "" {
+			header = append(header, "全体")
+		} else {
+			header = append(header, column.Banner)
+		}
+		totals = append(totals, formatNumber(column.Total))
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header(header)
+	table.Append(totals)
+
+	for _, row := range result.Rows {
+		cells := []string{row.Keyword}
+		for _, cell := range row.Cells {
+			p := cell.Percentage
+			if result.IsWeighted() {
+				p = cell.WeightedPercentage
+			}
+			cells = append(cells, fmt.Sprintf("%.1f%% (%d)", p, cell.Count))
+		}
+		table.Append(cells)
+	}
+
+	table.Render()
+
+	if result.IsWeighted() {
+		fmt.Printf("\nウェイト: %s（割合はウェイト付き、人数は実数）\n", result.WeightColumn)
+	}
+	fmt.Println()
 }
 
 // formatNumber は数値を3桁カンマ区切りにフォーマット
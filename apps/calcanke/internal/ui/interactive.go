@@ -7,10 +7,20 @@ import (
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/exporter"
 )
 
+// Options は対話的な分析の設定
+type Options struct {
+	OutputPath   string // 集計結果の出力先（.csv / .xlsx、空なら画面表示のみ）
+	WeightColumn string // ウェイト列名（空ならウェイトなし）
+
+	PercentageBase analyzer.PercentageBase // クロス集計の割合の基準（空なら行%）
+}
+
 // RunInteractive は対話的な分析フローを実行
-func RunInteractive(dbPath, table string) error {
+// opts.OutputPath が指定されている場合は、集計結果を同じ内容でファイルにも書き出す（.csv / .xlsx）
+func RunInteractive(dbPath, table string, opts Options) error {
 	// Analyzerを初期化
 	a, err := analyzer.NewAnalyzer(dbPath, table)
 	if err != nil {
@@ -25,7 +35,14 @@ func RunInteractive(dbPath, table string) error {
 	fmt.Printf("└─────────────────────────────────────────┘\n\n")
 	fmt.Printf("データベース: %s\n", dbPath)
 	fmt.Printf("テーブル: %s\n", table)
-	fmt.Printf("総レコード数: %s件\n\n", formatNumber(count))
+	fmt.Printf("総レコード数: %s件\n", formatNumber(count))
+	if opts.OutputPath != "" {
+		fmt.Printf("出力先: %s\n", opts.OutputPath)
+	}
+	if opts.WeightColumn != "" {
+		fmt.Printf("ウェイト: %s\n", opts.WeightColumn)
+	}
+	fmt.Printf("\n")
 	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
 
 	// 列情報を取得
@@ -34,6 +51,11 @@ func RunInteractive(dbPath, table string) error {
 		return fmt.Errorf("failed to get columns: %w", err)
 	}
 
+	// ウェイト列を確認
+	if opts.WeightColumn != "" && columns.FindByName(opts.WeightColumn) == nil {
+		return fmt.Errorf("weight column not found: %s", opts.WeightColumn)
+	}
+
 	// メインループ：分析タイプ選択と集計実行を繰り返す
 	for {
 		// 分析タイプを選択
@@ -43,6 +65,11 @@ func RunInteractive(dbPath, table string) error {
 			Options: []string{
 				"単純集計（1列）",
 				"クロス集計（2列）",
+				"数値の記述統計量",
+				"評価尺度（Top/Bottom Box・NPS）",
+				"マトリクス設問",
+				"複数回答の分析（選択数・同時選択）",
+				"自由回答の分析（頻出語・KWIC・キーワード）",
 				"終了",
 			},
 		}, &analysisType)
@@ -58,10 +85,20 @@ func RunInteractive(dbPath, table string) error {
 		// 選択された分析タイプに応じて処理を振り分け
 		var continueAnalysis bool
 		if analysisType == "単純集計（1列）" {
-			continueAnalysis, err = runSimpletabFlow(a, columns)
+			continueAnalysis, err = runSimpletabFlow(a, columns, opts)
+		} else if analysisType == "数値の記述統計量" {
+			continueAnalysis, err = runNumericStatsFlow(a, columns, opts)
+		} else if analysisType == "評価尺度（Top/Bottom Box・NPS）" {
+			continueAnalysis, err = runScaleSummaryFlow(a, columns, opts)
+		} else if analysisType == "マトリクス設問" {
+			continueAnalysis, err = runGridFlow(a, columns, opts)
+		} else if analysisType == "複数回答の分析（選択数・同時選択）" {
+			continueAnalysis, err = runMultiAnswerFlow(a, columns, opts)
+		} else if analysisType == "自由回答の分析（頻出語・KWIC・キーワード）" {
+			continueAnalysis, err = runTextFlow(a, columns, opts)
 		} else {
 			// クロス集計フロー
-			continueAnalysis, err = runCrosstabFlow(a, columns)
+			continueAnalysis, err = runCrosstabFlow(a, columns, opts)
 		}
 
 		if err != nil {
@@ -79,7 +116,7 @@ func RunInteractive(dbPath, table string) error {
 	}
 }
 
-func runCrosstabFlow(a *analyzer.Analyzer, columns analyzer.ColumnList) (bool, error) {
+func runCrosstabFlow(a *analyzer.Analyzer, columns analyzer.ColumnList, opts Options) (bool, error) {
 	// X軸の列を選択
 	var xSelection string
 	err := survey.AskOne(&survey.Select{
@@ -132,8 +169,13 @@ func runCrosstabFlow(a *analyzer.Analyzer, columns analyzer.ColumnList) (bool, e
 
 	// 分析設定を作成
 	config := analyzer.AnalysisConfig{
-		XColumn: xColumn,
-		YColumn: yColumn,
+		AnalysisType: "crosstab",
+		XColumn:      xColumn,
+		YColumn:      yColumn,
+		WeightColumn: columns.FindByName(opts.WeightColumn),
+		OutputPath:   opts.OutputPath,
+
+		PercentageBase: opts.PercentageBase,
 	}
 
 	// X軸が複数回答の場合、分割するか確認（派生列は除く）
@@ -156,23 +198,108 @@ func runCrosstabFlow(a *analyzer.Analyzer, columns analyzer.ColumnList) (bool, e
 		config.SplitY = splitY
 	}
 
+	// 層（3重クロス集計）を選択
+	zColumn, err := selectLayerColumn(columns)
+	if err != nil {
+		return false, err
+	}
+	config.ZColumn = zColumn
+
+	// 層が複数回答の場合、分割するか確認（派生列は除く）
+	if zColumn != nil && zColumn.IsMulti && !zColumn.IsDerived {
+		var splitZ bool
+		survey.AskOne(&survey.Confirm{
+			Message: "層を複数回答として分割しますか？",
+			Default: true,
+		}, &splitZ)
+		config.SplitZ = splitZ
+	}
+
 	// フィルタを選択
 	selectedFilter, err := selectFilter(a)
 	if err != nil {
 		return false, err
 	}
+	config.Filter = selectedFilter
+
+	// 層が選択されている場合は層別クロス集計
+	if config.ZColumn != nil {
+		if err := runLayeredCrosstab(a, config); err != nil {
+			return false, err
+		}
+		return askNextAction(), nil
+	}
 
 	// 集計実行
 	fmt.Println("\n集計中...")
-	result, err := a.CrosstabWithFilter(config, selectedFilter)
+	result, err := a.CrosstabWithFilter(config, config.Filter)
 	if err != nil {
 		return false, fmt.Errorf("failed to execute crosstab: %w", err)
 	}
 
 	// 結果表示
-	DisplayCrosstabResult(result)
+	pivot := result.ToPivotWithAnalyzer(a)
+	DisplayCrosstabPivot(pivot)
+
+	// ファイル出力
+	if config.OutputPath != "" {
+		sheet := exporter.CrosstabSheet(pivot, config.Filter)
+		if err := writeOutput(config.OutputPath, sheet); err != nil {
+			return false, err
+		}
+	}
 
 	// 次のアクション
+	return askNextAction(), nil
+}
+
+// selectLayerColumn は層（Z軸）の列を選択する（「なし」の場合はnil）
+func selectLayerColumn(columns analyzer.ColumnList) (*analyzer.Column, error) {
+	const none = "なし（2軸のクロス集計）"
+
+	var zSelection string
+	err := survey.AskOne(&survey.Select{
+		Message: "層（Z軸）の列を選択してください:",
+		Options: append([]string{none}, columns.ToOptions()...),
+		Default: none,
+	}, &zSelection)
+	if err != nil {
+		return nil, err
+	}
+	if zSelection == none {
+		return nil, nil
+	}
+
+	zColumn := &columns[parseSelectionIndex(zSelection)-1]
+	fmt.Printf("\n✓ 層: %s\n\n", zColumn.Name)
+	return zColumn, nil
+}
+
+// runLayeredCrosstab は層別クロス集計を実行して、全体と層ごとの表を表示する
+func runLayeredCrosstab(a *analyzer.Analyzer, config analyzer.AnalysisConfig) error {
+	fmt.Println("\n集計中...")
+	result, err := a.LayeredCrosstab(config, config.Filter)
+	if err != nil {
+		return fmt.Errorf("failed to execute layered crosstab: %w", err)
+	}
+
+	fmt.Printf("\n■ 全体\n")
+	DisplayCrosstabPivot(result.Overall)
+	for _, layer := range result.Layers {
+		fmt.Printf("\n■ %s: %s\n", result.ZColumn, layer.Value)
+		DisplayCrosstabPivot(layer.Pivot)
+	}
+
+	// ファイル出力（全体と層ごとにシートを分ける）
+	if config.OutputPath != "" {
+		return writeOutput(config.OutputPath, exporter.LayeredCrosstabSheets(result, config.Filter)...)
+	}
+	return nil
+}
+
+// askNextAction は次の操作を確認する
+// 「別の集計を実行」の場合はtrue、「終了」の場合はfalseを返す
+func askNextAction() bool {
 	var nextAction string
 	survey.AskOne(&survey.Select{
 		Message: "次の操作を選択してください:",
@@ -182,11 +309,10 @@ func runCrosstabFlow(a *analyzer.Analyzer, columns analyzer.ColumnList) (bool, e
 		},
 	}, &nextAction)
 
-	// 「別の集計を実行」の場合はtrue、「終了」の場合はfalseを返す
-	return nextAction == "別の集計を実行", nil
+	return nextAction == "別の集計を実行"
 }
 
-func runSimpletabFlow(a *analyzer.Analyzer, columns analyzer.ColumnList) (bool, error) {
+func runSimpletabFlow(a *analyzer.Analyzer, columns analyzer.ColumnList, opts Options) (bool, error) {
 	// 列を選択
 	var selection string
 	err := survey.AskOne(&survey.Select{
@@ -229,7 +355,7 @@ func runSimpletabFlow(a *analyzer.Analyzer, columns analyzer.ColumnList) (bool,
 
 	// 集計実行
 	fmt.Println("\n集計中...")
-	result, err := a.SimpletabWithFilter(column, split, selectedFilter)
+	result, err := a.SimpletabWithWeight(column, split, selectedFilter, columns.FindByName(opts.WeightColumn))
 	if err != nil {
 		return false, fmt.Errorf("failed to execute simpletab: %w", err)
 	}
@@ -237,18 +363,421 @@ func runSimpletabFlow(a *analyzer.Analyzer, columns analyzer.ColumnList) (bool,
 	// 結果表示
 	DisplaySimpletabResult(result)
 
+	// ファイル出力
+	if opts.OutputPath != "" {
+		if err := writeOutput(opts.OutputPath, exporter.SimpletabSheet(result, selectedFilter)); err != nil {
+			return false, err
+		}
+	}
+
 	// 次のアクション
-	var nextAction string
-	survey.AskOne(&survey.Select{
-		Message: "次の操作を選択してください:",
-		Options: []string{
-			"別の集計を実行",
-			"終了",
+	return askNextAction(), nil
+}
+
+// runNumericStatsFlow は列の値を数値として記述統計量を集計する
+// グループの列を選択した場合は、その値ごとにも集計する
+func runNumericStatsFlow(a *analyzer.Analyzer, columns analyzer.ColumnList, opts Options) (bool, error) {
+	// 数値型の列を先頭に並べて選択
+	numeric := columns.NumericColumns()
+	candidates := append(analyzer.ColumnList{}, numeric...)
+	for _, col := range columns {
+		if !col.IsNumeric() {
+			candidates = append(candidates, col)
+		}
+	}
+
+	var selection string
+	err := survey.AskOne(&survey.Select{
+		Message: "統計量を求める列を選択してください:",
+		Options: candidates.ToOptions(),
+		Description: func(value string, index int) string {
+			if index < len(numeric) {
+				return "数値型の列"
+			}
+			return "数値に変換できない値は欠損として扱います"
 		},
-	}, &nextAction)
+	}, &selection)
+	if err != nil {
+		return false, err
+	}
+
+	config := analyzer.NumericStatsConfig{
+		Column:       &columns[parseSelectionIndex(selection)-1],
+		WeightColumn: columns.FindByName(opts.WeightColumn),
+	}
+	fmt.Printf("\n✓ 集計列: %s\n\n", config.Column.Name)
+
+	// グループの列を選択（任意）
+	const none = "なし（全体のみ）"
+	var groupSelection string
+	err = survey.AskOne(&survey.Select{
+		Message: "グループ分けする列を選択してください:",
+		Options: append([]string{none}, columns.ToOptions()...),
+		Default: none,
+	}, &groupSelection)
+	if err != nil {
+		return false, err
+	}
+	if groupSelection != none {
+		config.GroupColumn = &columns[parseSelectionIndex(groupSelection)-1]
+		fmt.Printf("\n✓ グループ: %s\n\n", config.GroupColumn.Name)
+
+		if config.GroupColumn.IsMulti {
+			survey.AskOne(&survey.Confirm{
+				Message: "グループの列を複数回答として分割しますか？",
+				Default: true,
+			}, &config.SplitGroup)
+		}
+	}
+
+	// フィルタを選択
+	if config.Filter, err = selectFilter(a); err != nil {
+		return false, err
+	}
+
+	fmt.Println("\n集計中...")
+	result, err := a.NumericStats(config)
+	if err != nil {
+		return false, fmt.Errorf("failed to execute numeric stats: %w", err)
+	}
+
+	DisplayNumericStats(result)
 
-	// 「別の集計を実行」の場合はtrue、「終了」の場合はfalseを返す
-	return nextAction == "別の集計を実行", nil
+	if opts.OutputPath != "" {
+		if err := writeOutput(opts.OutputPath, exporter.NumericStatsSheet(result, config.Filter)); err != nil {
+			return false, err
+		}
+	}
+
+	return askNextAction(), nil
+}
+
+// runScaleSummaryFlow は評価尺度の列を Top/Bottom Box・平均点、またはNPSで集計する
+// グループの列を選択した場合は、その値ごとにも集計する
+func runScaleSummaryFlow(a *analyzer.Analyzer, columns analyzer.ColumnList, opts Options) (bool, error) {
+	const likert, nps = "段階評価（Top/Bottom Box・平均点）", "NPS（0〜10の推奨度）"
+	var scaleType string
+	err := survey.AskOne(&survey.Select{
+		Message: "集計方法を選択してください:",
+		Options: []string{likert, nps},
+	}, &scaleType)
+	if err != nil {
+		return false, err
+	}
+
+	var selection string
+	err = survey.AskOne(&survey.Select{
+		Message: "評価尺度の列を選択してください:",
+		Options: columns.ToOptions(),
+	}, &selection)
+	if err != nil {
+		return false, err
+	}
+
+	config := analyzer.ScaleSummaryConfig{
+		Column:       &columns[parseSelectionIndex(selection)-1],
+		ScaleType:    analyzer.ScaleLikert,
+		WeightColumn: columns.FindByName(opts.WeightColumn),
+	}
+	if scaleType == nps {
+		config.ScaleType = analyzer.ScaleNPS
+	}
+	fmt.Printf("\n✓ 集計列: %s\n\n", config.Column.Name)
+
+	// グループの列を選択（任意）
+	const none = "なし（全体のみ）"
+	var groupSelection string
+	err = survey.AskOne(&survey.Select{
+		Message: "グループ分けする列を選択してください:",
+		Options: append([]string{none}, columns.ToOptions()...),
+		Default: none,
+	}, &groupSelection)
+	if err != nil {
+		return false, err
+	}
+	if groupSelection != none {
+		config.GroupColumn = &columns[parseSelectionIndex(groupSelection)-1]
+		fmt.Printf("\n✓ グループ: %s\n\n", config.GroupColumn.Name)
+
+		if config.GroupColumn.IsMulti {
+			survey.AskOne(&survey.Confirm{
+				Message: "グループの列を複数回答として分割しますか？",
+				Default: true,
+			}, &config.SplitGroup)
+		}
+	}
+
+	// フィルタを選択
+	if config.Filter, err = selectFilter(a); err != nil {
+		return false, err
+	}
+
+	fmt.Println("\n集計中...")
+	result, err := a.ScaleSummary(config)
+	if err != nil {
+		return false, fmt.Errorf("failed to execute scale summary: %w", err)
+	}
+
+	DisplayScaleSummary(result)
+
+	if opts.OutputPath != "" {
+		if err := writeOutput(opts.OutputPath, exporter.ScaleSummarySheet(result, config.Filter)); err != nil {
+			return false, err
+		}
+	}
+
+	return askNextAction(), nil
+}
+
+// runMultiAnswerFlow は複数回答の列を回答者単位で分析する（選択数の分布、または選択肢の同時選択）
+func runMultiAnswerFlow(a *analyzer.Analyzer, columns analyzer.ColumnList, opts Options) (bool, error) {
+	var multi analyzer.ColumnList
+	for _, col := range columns {
+		if col.IsMulti {
+			multi = append(multi, col)
+		}
+	}
+	if len(multi) == 0 {
+		fmt.Println("\n複数回答の列がありません")
+		return askNextAction(), nil
+	}
+
+	const selectionCount, cooccurrence = "選択数の分布（1人あたりの選択数）", "同時選択（選択肢の共起）"
+	var mode string
+	err := survey.AskOne(&survey.Select{
+		Message: "分析方法を選択してください:",
+		Options: []string{selectionCount, cooccurrence},
+	}, &mode)
+	if err != nil {
+		return false, err
+	}
+
+	var selection string
+	err = survey.AskOne(&survey.Select{
+		Message: "複数回答の列を選択してください:",
+		Options: multi.ToOptions(),
+	}, &selection)
+	if err != nil {
+		return false, err
+	}
+
+	column := &columns[parseSelectionIndex(selection)-1]
+	fmt.Printf("\n✓ 集計列: %s\n\n", column.Name)
+
+	// フィルタを選択
+	selectedFilter, err := selectFilter(a)
+	if err != nil {
+		return false, err
+	}
+
+	fmt.Println("\n集計中...")
+	weight := columns.FindByName(opts.WeightColumn)
+	var sheet exporter.Sheet
+	if mode == selectionCount {
+		result, err := a.SelectionCount(column, selectedFilter, weight)
+		if err != nil {
+			return false, fmt.Errorf("failed to execute selection count: %w", err)
+		}
+		DisplaySelectionCount(result)
+		sheet = exporter.SelectionCountSheet(result, selectedFilter)
+	} else {
+		result, err := a.Cooccurrence(column, selectedFilter, weight)
+		if err != nil {
+			return false, fmt.Errorf("failed to execute cooccurrence: %w", err)
+		}
+		DisplayCooccurrence(result)
+		sheet = exporter.CooccurrenceSheet(result, selectedFilter)
+	}
+
+	if opts.OutputPath != "" {
+		if err := writeOutput(opts.OutputPath, sheet); err != nil {
+			return false, err
+		}
+	}
+
+	return askNextAction(), nil
+}
+
+// runTextFlow は自由回答の列を分析する（語の出現頻度、キーワードを含む回答の一覧、キーワードを含む回答者の表）
+// 語の出現頻度では configs/stopwords.yaml の語も除く
+func runTextFlow(a *analyzer.Analyzer, columns analyzer.ColumnList, opts Options) (bool, error) {
+	const frequency, context, keywordCross = "語の出現頻度", "キーワードを含む回答の一覧（KWIC）", "キーワードを含む回答者の割合（バナー別）"
+	var mode string
+	err := survey.AskOne(&survey.Select{
+		Message: "分析方法を選択してください:",
+		Options: []string{frequency, context, keywordCross},
+	}, &mode)
+	if err != nil {
+		return false, err
+	}
+
+	var selection string
+	err = survey.AskOne(&survey.Select{
+		Message: "自由回答の列を選択してください:",
+		Options: columns.ToOptions(),
+	}, &selection)
+	if err != nil {
+		return false, err
+	}
+
+	config := analyzer.TextConfig{
+		Column:       &columns[parseSelectionIndex(selection)-1],
+		WeightColumn: columns.FindByName(opts.WeightColumn),
+	}
+	fmt.Printf("\n✓ 集計列: %s\n\n", config.Column.Name)
+
+	if mode == frequency {
+		config.Stopwords, _ = analyzer.LoadStopwords("configs/stopwords.yaml")
+	} else {
+		var keywords string
+		err = survey.AskOne(&survey.Input{
+			Message: "キーワードを入力してください（カンマ区切り）:",
+		}, &keywords, survey.WithValidator(survey.Required))
+		if err != nil {
+			return false, err
+		}
+		config.Keywords = analyzer.ParseKeywords(keywords)
+	}
+
+	// バナーの列を選択（任意）
+	if mode == keywordCross {
+		const none = "なし（全体のみ）"
+		var bannerSelection string
+		err = survey.AskOne(&survey.Select{
+			Message: "バナーの列を選択してください:",
+			Options: append([]string{none}, columns.ToOptions()...),
+			Default: none,
+		}, &bannerSelection)
+		if err != nil {
+			return false, err
+		}
+		if bannerSelection != none {
+			config.BannerColumn = &columns[parseSelectionIndex(bannerSelection)-1]
+			fmt.Printf("\n✓ バナー: %s\n\n", config.BannerColumn.Name)
+
+			if config.BannerColumn.IsMulti {
+				survey.AskOne(&survey.Confirm{
+					Message: "バナーの列を複数回答として分割しますか？",
+					Default: true,
+				}, &config.SplitBanner)
+			}
+		}
+	}
+
+	// フィルタを選択
+	if config.Filter, err = selectFilter(a); err != nil {
+		return false, err
+	}
+
+	fmt.Println("\n集計中...")
+	var sheet exporter.Sheet
+	switch mode {
+	case frequency:
+		result, err := a.WordFrequency(config)
+		if err != nil {
+			return false, fmt.Errorf("failed to execute word frequency: %w", err)
+		}
+		DisplayWordFrequency(result)
+		sheet = exporter.WordFrequencySheet(result, config.Filter)
+	case context:
+		result, err := a.KeywordContexts(config)
+		if err != nil {
+			return false, fmt.Errorf("failed to execute keyword context: %w", err)
+		}
+		DisplayKeywordContexts(result)
+		sheet = exporter.KeywordContextSheet(result, config.Filter)
+	default:
+		result, err := a.KeywordCrosstab(config)
+		if err != nil {
+			return false, fmt.Errorf("failed to execute keyword crosstab: %w", err)
+		}
+		DisplayKeywordCrosstab(result)
+		sheet = exporter.KeywordCrosstabSheet(result, config.Filter)
+	}
+
+	if opts.OutputPath != "" {
+		if err := writeOutput(opts.OutputPath, sheet); err != nil {
+			return false, err
+		}
+	}
+
+	return askNextAction(), nil
+}
+
+// runGridFlow はマトリクス設問（configs/question_groups.yaml）を項目×選択肢の表に集計する
+// バナーの列を選択した場合は、項目ごとにバナーの値ごとの行も集計する
+func runGridFlow(a *analyzer.Analyzer, columns analyzer.ColumnList, opts Options) (bool, error) {
+	groups, err := analyzer.LoadQuestionGroups("configs/question_groups.yaml")
+	if err != nil || len(groups) == 0 {
+		fmt.Println("\nマトリクス設問が定義されていません（configs/question_groups.yaml）")
+		return askNextAction(), nil
+	}
+
+	var options []string
+	for _, g := range groups {
+		options = append(options, g.Name)
+	}
+	var name string
+	err = survey.AskOne(&survey.Select{
+		Message: "マトリクス設問を選択してください:",
+		Options: options,
+		Description: func(value string, index int) string {
+			return fmt.Sprintf("%d項目", len(groups[index].Columns))
+		},
+	}, &name)
+	if err != nil {
+		return false, err
+	}
+
+	config := analyzer.GridConfig{
+		Group:        *analyzer.FindQuestionGroup(groups, name),
+		WeightColumn: columns.FindByName(opts.WeightColumn),
+	}
+	fmt.Printf("\n✓ マトリクス設問: %s\n\n", name)
+
+	// バナーの列を選択（任意）
+	const none = "なし（全体のみ）"
+	var bannerSelection string
+	err = survey.AskOne(&survey.Select{
+		Message: "バナーの列を選択してください:",
+		Options: append([]string{none}, columns.ToOptions()...),
+		Default: none,
+	}, &bannerSelection)
+	if err != nil {
+		return false, err
+	}
+	if bannerSelection != none {
+		config.BannerColumn = &columns[parseSelectionIndex(bannerSelection)-1]
+		fmt.Printf("\n✓ バナー: %s\n\n", config.BannerColumn.Name)
+
+		if config.BannerColumn.IsMulti {
+			survey.AskOne(&survey.Confirm{
+				Message: "バナーの列を複数回答として分割しますか？",
+				Default: true,
+			}, &config.SplitBanner)
+		}
+	}
+
+	// フィルタを選択
+	if config.Filter, err = selectFilter(a); err != nil {
+		return false, err
+	}
+
+	fmt.Println("\n集計中...")
+	result, err := a.Grid(config)
+	if err != nil {
+		return false, fmt.Errorf("failed to execute grid: %w", err)
+	}
+
+	DisplayGrid(result)
+
+	if opts.OutputPath != "" {
+		if err := writeOutput(opts.OutputPath, exporter.GridSheet(result, config.Filter)); err != nil {
+			return false, err
+		}
+	}
+
+	return askNextAction(), nil
 }
 
 // parseSelectionIndex は選択された文字列から列番号を抽出
@@ -299,7 +828,7 @@ func selectFilter(a *analyzer.Analyzer) (*analyzer.Filter, error) {
 
 	// 「フィルタなし」が選択された場合はnilを返す
 	if selection == "フィルタなし（全データ）" {
-		fmt.Println("\n✓ フィルタ: なし\n")
+		fmt.Print("\n✓ フィルタ: なし\n\n")
 		return nil, nil
 	}
 
@@ -315,3 +844,12 @@ func selectFilter(a *analyzer.Analyzer) (*analyzer.Filter, error) {
 	// 見つからない場合はnilを返す（通常は発生しない）
 	return nil, nil
 }
+
+// writeOutput は集計結果をファイルに書き出す（形式は拡張子から判定）
+func writeOutput(path string, sheets ...exporter.Sheet) error {
+	if err := exporter.WriteFile(path, sheets); err != nil {
+		return fmt.Errorf("failed to export result: %w", err)
+	}
+	fmt.Printf("✓ %s に出力しました\n\n", path)
+	return nil
+}
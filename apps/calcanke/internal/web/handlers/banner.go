@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/exporter"
+)
+
+// BannerTables はバナー表（表側の設問ごとに全体と表頭の各列で集計した表）をダウンロードする
+// banners（表頭）と stubs（表側）に列番号を複数指定する。format を省略した場合はXLSX
+func (h *Handler) BannerTables(c echo.Context) error {
+	formatName := c.FormValue("format")
+	if formatName == "" {
+		formatName = string(exporter.FormatXLSX)
+	}
+	format, err := exporter.ParseFormat(formatName)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	a, err := h.getAnalyzer()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to initialize analyzer")
+	}
+	defer a.Close()
+
+	config, err := parseBannerTableRequest(c, a)
+	if err != nil {
+		return c.String(requestErrorStatus(err), err.Error())
+	}
+
+	book, err := a.BannerTables(*config)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to execute banner tables: "+err.Error())
+	}
+
+	return streamExport(c, format, "バナー表", exporter.BannerTableSheets(book, config.Filter))
+}
+
+// parseBannerTableRequest はバナー表のリクエストから集計設定を取得する
+func parseBannerTableRequest(c echo.Context, a *analyzer.Analyzer) (*analyzer.BannerTableConfig, error) {
+	form, err := c.FormParams()
+	if err != nil {
+		return nil, fmt.Errorf("invalid form: %w", err)
+	}
+
+	columns, err := a.GetColumns()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errGetColumns, err)
+	}
+
+	banners, err := columnsByIndex(columns, form["banners"])
+	if err != nil {
+		return nil, err
+	}
+	stubs, err := columnsByIndex(columns, form["stubs"])
+	if err != nil {
+		return nil, err
+	}
+	if len(banners) == 0 {
+		return nil, fmt.Errorf("select at least one banner column")
+	}
+	if len(stubs) == 0 {
+		return nil, fmt.Errorf("select at least one stub column")
+	}
+
+	weight, err := findWeightColumn(columns, c.FormValue("weight"))
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := requestFilter(c, a)
+	if err != nil {
+		return nil, err
+	}
+
+	return &analyzer.BannerTableConfig{
+		Banners:      banners,
+		Stubs:        stubs,
+		Filter:       filter,
+		WeightColumn: weight,
+	}, nil
+}
+
+// columnsByIndex は列番号（1始まり）のリストから列を取得する
+func columnsByIndex(columns analyzer.ColumnList, indexes []string) ([]*analyzer.Column, error) {
+	result := make([]*analyzer.Column, 0, len(indexes))
+	for _, s := range indexes {
+		index, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid column index: %s", s)
+		}
+		if index < 1 || index > len(columns) {
+			return nil, fmt.Errorf("column index out of range: %d", index)
+		}
+		result = append(result, &columns[index-1])
+	}
+	return result, nil
+}
@@ -9,8 +9,9 @@ import (
 
 // ColumnsData は列選択UIのテンプレートデータ
 type ColumnsData struct {
-	AnalysisType string
-	Columns      analyzer.ColumnList
+	AnalysisType   string
+	Columns        analyzer.ColumnList
+	QuestionGroups []analyzer.QuestionGroup // マトリクス設問（analysis_type=grid の場合）
 }
 
 // GetColumns は列選択UIを返す（htmx用）
@@ -32,6 +33,9 @@ func (h *Handler) GetColumns(c echo.Context) error {
 		AnalysisType: analysisType,
 		Columns:      columns,
 	}
+	if analysisType == "grid" {
+		data.QuestionGroups = h.loadQuestionGroups()
+	}
 
 	return c.Render(http.StatusOK, "column_selector.html", data)
 }
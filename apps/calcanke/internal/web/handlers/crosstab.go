@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"html/template"
 	"net/http"
 	"strconv"
@@ -26,85 +27,145 @@ func (h *Handler) Crosstab(c echo.Context) error {
 	}
 	defer a.Close()
 
+	config, filter, err := parseCrosstabRequest(c, a)
+	if err != nil {
+		return c.String(requestErrorStatus(err), err.Error())
+	}
+
+	// 集計実行
+	result, err := a.CrosstabWithFilter(*config, filter)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to execute crosstab: "+err.Error())
+	}
+
+	// ピボット形式のデータも生成（列の値の順序を考慮）
+	pivot := result.ToPivotWithAnalyzer(a)
+
+	// ピボットデータをJSONに変換
+	pivotJSON, err := json.Marshal(pivot)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to marshal pivot data: "+err.Error())
+	}
+
+	data := CrosstabResultData{
+		Result:    result,
+		Pivot:     pivot,
+		PivotJSON: template.JS(pivotJSON),
+		Filter:    filter,
+	}
+
+	return c.Render(http.StatusOK, "crosstab_result.html", data)
+}
+
+// LayeredCrosstabResultData は層別クロス集計結果のテンプレートデータ
+type LayeredCrosstabResultData struct {
+	Result *analyzer.LayeredCrosstabResult
+	Filter *analyzer.Filter
+}
+
+// LayeredCrosstab は層（z_column）の値ごとにクロス集計を実行する
+func (h *Handler) LayeredCrosstab(c echo.Context) error {
+	a, err := h.getAnalyzer()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to initialize analyzer")
+	}
+	defer a.Close()
+
+	config, filter, err := parseCrosstabRequest(c, a)
+	if err != nil {
+		return c.String(requestErrorStatus(err), err.Error())
+	}
+	if config.ZColumn == nil {
+		return c.String(http.StatusBadRequest, "z_column is required")
+	}
+
+	result, err := a.LayeredCrosstab(*config, filter)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to execute layered crosstab: "+err.Error())
+	}
+
+	data := LayeredCrosstabResultData{
+		Result: result,
+		Filter: filter,
+	}
+
+	return c.Render(http.StatusOK, "layered_crosstab_result.html", data)
+}
+
+// parseCrosstabRequest はクロス集計のリクエストから集計設定とフィルタを取得する
+// z_column（層）は省略可能
+func parseCrosstabRequest(c echo.Context, a *analyzer.Analyzer) (*analyzer.AnalysisConfig, *analyzer.Filter, error) {
 	// パラメータ取得
 	xColumnIndexStr := c.FormValue("x_column")
 	yColumnIndexStr := c.FormValue("y_column")
 	splitXStr := c.FormValue("split_x")
 	splitYStr := c.FormValue("split_y")
-	filterName := c.FormValue("filter")
 
 	// 列インデックスをパース
 	xColumnIndex, err := strconv.Atoi(xColumnIndexStr)
 	if err != nil {
-		return c.String(http.StatusBadRequest, "Invalid X column index")
+		return nil, nil, fmt.Errorf("invalid X column index")
 	}
 
 	yColumnIndex, err := strconv.Atoi(yColumnIndexStr)
 	if err != nil {
-		return c.String(http.StatusBadRequest, "Invalid Y column index")
+		return nil, nil, fmt.Errorf("invalid Y column index")
 	}
 
 	// 列を取得
 	columns, err := a.GetColumns()
 	if err != nil {
-		return c.String(http.StatusInternalServerError, "Failed to get columns")
+		return nil, nil, fmt.Errorf("%w: %w", errGetColumns, err)
 	}
 
 	if xColumnIndex < 1 || xColumnIndex > len(columns) {
-		return c.String(http.StatusBadRequest, "X column index out of range")
+		return nil, nil, fmt.Errorf("X column index out of range")
 	}
 
 	if yColumnIndex < 1 || yColumnIndex > len(columns) {
-		return c.String(http.StatusBadRequest, "Y column index out of range")
+		return nil, nil, fmt.Errorf("Y column index out of range")
 	}
 
-	xColumn := &columns[xColumnIndex-1]
-	yColumn := &columns[yColumnIndex-1]
-
-	// 分割フラグ
-	splitX := splitXStr == "true" || splitXStr == "on"
-	splitY := splitYStr == "true" || splitYStr == "on"
-
-	// フィルタを取得
-	var filter *analyzer.Filter
-	if filterName != "" {
-		for i := range a.Filters {
-			if a.Filters[i].Name == filterName {
-				filter = &a.Filters[i]
-				break
-			}
+	// 層（省略時は層なし）
+	var zColumn *analyzer.Column
+	if zColumnIndexStr := c.FormValue("z_column"); zColumnIndexStr != "" {
+		zColumnIndex, err := strconv.Atoi(zColumnIndexStr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid Z column index")
+		}
+		if zColumnIndex < 1 || zColumnIndex > len(columns) {
+			return nil, nil, fmt.Errorf("Z column index out of range")
 		}
+		zColumn = &columns[zColumnIndex-1]
 	}
 
-	// 集計設定
-	config := analyzer.AnalysisConfig{
-		XColumn: xColumn,
-		YColumn: yColumn,
-		SplitX:  splitX,
-		SplitY:  splitY,
+	weight, err := findWeightColumn(columns, c.FormValue("weight"))
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// 集計実行
-	result, err := a.CrosstabWithFilter(config, filter)
+	percentageBase, err := analyzer.ParsePercentageBase(c.FormValue("percent_base"))
 	if err != nil {
-		return c.String(http.StatusInternalServerError, "Failed to execute crosstab: "+err.Error())
+		return nil, nil, err
 	}
 
-	// ピボット形式のデータも生成（列の値の順序を考慮）
-	pivot := result.ToPivotWithAnalyzer(a)
-
-	// ピボットデータをJSONに変換
-	pivotJSON, err := json.Marshal(pivot)
-	if err != nil {
-		return c.String(http.StatusInternalServerError, "Failed to marshal pivot data: "+err.Error())
+	// 集計設定
+	config := &analyzer.AnalysisConfig{
+		XColumn:      &columns[xColumnIndex-1],
+		YColumn:      &columns[yColumnIndex-1],
+		SplitX:       splitXStr == "true" || splitXStr == "on",
+		SplitY:       splitYStr == "true" || splitYStr == "on",
+		ZColumn:      zColumn,
+		SplitZ:       c.FormValue("split_z") == "true" || c.FormValue("split_z") == "on",
+		WeightColumn: weight,
+
+		PercentageBase: percentageBase,
 	}
 
-	data := CrosstabResultData{
-		Result:    result,
-		Pivot:     pivot,
-		PivotJSON: template.JS(pivotJSON),
-		Filter:    filter,
+	filter, err := requestFilter(c, a)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return c.Render(http.StatusOK, "crosstab_result.html", data)
+	return config, filter, nil
 }
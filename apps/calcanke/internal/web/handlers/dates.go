@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DateParseReport は日付を解析する派生列（日付の正規化・学年・学校種別・年齢）の解析結果を返す
+// index は派生列の番号、filter はフィルタ名、limit は解析できなかった値を返す数（省略時は30）
+func (h *Handler) DateParseReport(c echo.Context) error {
+	a, err := h.getAnalyzer()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to initialize analyzer"})
+	}
+	defer a.Close()
+
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil || index < 0 || index >= len(a.DerivedColumns) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid index"})
+	}
+	dc := a.DerivedColumns[index]
+
+	limit := 0
+	if s := c.QueryParam("limit"); s != "" {
+		if limit, err = strconv.Atoi(s); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid limit: " + s})
+		}
+	}
+
+	filter, err := namedFilter(a, c.QueryParam("filter"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	result, err := a.DateParseReport(dc.Name, filter, limit)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, result)
+}
@@ -1,13 +1,220 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
 
 	"github.com/labstack/echo/v4"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/exporter"
 )
 
-// Export はCSVエクスポートを実行する（Phase 2で実装予定）
+// Export は集計結果をCSVまたはXLSXでエクスポートする
+// リクエストは単純集計・クロス集計と同じパラメータに analysis_type と format を加えたもの
+// scale を指定した場合は評価尺度の集計、multi_analysis を指定した場合は複数回答の分析、
+// numeric_stats=true の場合は記述統計量、analysis_type=text の場合は自由回答の分析を出力する
 func (h *Handler) Export(c echo.Context) error {
-	// TODO: Phase 2で実装
-	return c.String(http.StatusNotImplemented, "Export feature is not implemented yet")
+	format, err := exporter.ParseFormat(c.FormValue("format"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	a, err := h.getAnalyzer()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to initialize analyzer")
+	}
+	defer a.Close()
+
+	var sheets []exporter.Sheet
+	var filename string
+
+	// 評価尺度として集計する場合は Top/Bottom Box・平均点・NPS を出力
+	if c.FormValue("scale") != "" {
+		config, err := parseScaleSummaryRequest(c, a)
+		if err != nil {
+			return c.String(requestErrorStatus(err), err.Error())
+		}
+
+		result, err := a.ScaleSummary(*config)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, "Failed to execute scale summary: "+err.Error())
+		}
+
+		sheet := exporter.ScaleSummarySheet(result, config.Filter)
+		return streamExport(c, format, "評価尺度_"+sheet.Name, []exporter.Sheet{sheet})
+	}
+
+	// 複数回答の選択数の分布・同時選択の表を出力
+	if multi := c.FormValue("multi_analysis"); multi != "" && c.FormValue("analysis_type") != "cross" {
+		config, filter, err := parseSimpletabRequest(c, a)
+		if err != nil {
+			return c.String(requestErrorStatus(err), err.Error())
+		}
+
+		switch multi {
+		case analyzer.MultiAnswerSelectionCount:
+			result, err := a.SelectionCount(config.XColumn, filter, config.WeightColumn)
+			if err != nil {
+				return c.String(http.StatusInternalServerError, "Failed to execute selection count: "+err.Error())
+			}
+			return streamExport(c, format, "選択数_"+result.Column, []exporter.Sheet{exporter.SelectionCountSheet(result, filter)})
+		case analyzer.MultiAnswerCooccurrence:
+			result, err := a.Cooccurrence(config.XColumn, filter, config.WeightColumn)
+			if err != nil {
+				return c.String(http.StatusInternalServerError, "Failed to execute cooccurrence: "+err.Error())
+			}
+			return streamExport(c, format, "同時選択_"+result.Column, []exporter.Sheet{exporter.CooccurrenceSheet(result, filter)})
+		default:
+			return c.String(http.StatusBadRequest, "invalid multi_analysis: "+multi)
+		}
+	}
+
+	// 数値として集計する場合は記述統計量を出力
+	if c.FormValue("numeric_stats") == "true" {
+		config, err := parseNumericStatsRequest(c, a)
+		if err != nil {
+			return c.String(requestErrorStatus(err), err.Error())
+		}
+
+		result, err := a.NumericStats(*config)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, "Failed to execute numeric stats: "+err.Error())
+		}
+
+		sheet := exporter.NumericStatsSheet(result, config.Filter)
+		return streamExport(c, format, "記述統計量_"+sheet.Name, []exporter.Sheet{sheet})
+	}
+
+	switch c.FormValue("analysis_type") {
+	case "", "simple":
+		config, filter, err := parseSimpletabRequest(c, a)
+		if err != nil {
+			return c.String(requestErrorStatus(err), err.Error())
+		}
+
+		// 比較するフィルタを指定した場合は、フィルタごとの列を並べた表を出力（filter は各列に共通して適用）
+		compareFilters, err := requestCompareFilters(c, a)
+		if err != nil {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
+		if compareFilters != nil {
+			result, err := a.CompareFilters(config.XColumn, config.SplitX, compareFilters, filter, config.WeightColumn)
+			if err != nil {
+				return c.String(http.StatusInternalServerError, "Failed to execute filter comparison: "+err.Error())
+			}
+
+			sheets = []exporter.Sheet{exporter.FilterComparisonSheet(result)}
+			filename = fmt.Sprintf("フィルタ比較_%s", result.Column)
+			break
+		}
+
+		result, err := a.SimpletabWithWeight(config.XColumn, config.SplitX, filter, config.WeightColumn)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, "Failed to execute simpletab: "+err.Error())
+		}
+
+		sheets = []exporter.Sheet{exporter.SimpletabSheet(result, filter)}
+		filename = fmt.Sprintf("単純集計_%s", result.Column)
+
+	case "cross":
+		config, filter, err := parseCrosstabRequest(c, a)
+		if err != nil {
+			return c.String(requestErrorStatus(err), err.Error())
+		}
+
+		// 層が指定されている場合は全体と層ごとの表をシートに分けて出力
+		if config.ZColumn != nil {
+			result, err := a.LayeredCrosstab(*config, filter)
+			if err != nil {
+				return c.String(http.StatusInternalServerError, "Failed to execute layered crosstab: "+err.Error())
+			}
+
+			sheets = exporter.LayeredCrosstabSheets(result, filter)
+			filename = fmt.Sprintf("層別クロス集計_%s×%s×%s", result.XColumn, result.YColumn, result.ZColumn)
+			break
+		}
+
+		result, err := a.CrosstabWithFilter(*config, filter)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, "Failed to execute crosstab: "+err.Error())
+		}
+
+		pivot := result.ToPivotWithAnalyzer(a)
+		sheets = []exporter.Sheet{exporter.CrosstabSheet(pivot, filter)}
+		filename = fmt.Sprintf("クロス集計_%s×%s", result.XColumn, result.YColumn)
+
+	case "grid":
+		config, err := h.parseGridRequest(c, a)
+		if err != nil {
+			return c.String(requestErrorStatus(err), err.Error())
+		}
+
+		result, err := a.Grid(*config)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, "Failed to execute grid: "+err.Error())
+		}
+
+		sheets = []exporter.Sheet{exporter.GridSheet(result, config.Filter)}
+		filename = "マトリクス設問_" + result.Group
+		if result.IsGrouped() {
+			filename += "×" + result.BannerColumn
+		}
+
+	case "text":
+		config, err := h.parseTextRequest(c, a)
+		if err != nil {
+			return c.String(requestErrorStatus(err), err.Error())
+		}
+
+		switch c.FormValue("text_analysis") {
+		case "", analyzer.TextAnalysisFrequency:
+			result, err := a.WordFrequency(*config)
+			if err != nil {
+				return c.String(http.StatusInternalServerError, "Failed to execute word frequency: "+err.Error())
+			}
+			sheets = []exporter.Sheet{exporter.WordFrequencySheet(result, config.Filter)}
+			filename = "頻出語_" + result.Column
+		case analyzer.TextAnalysisContext:
+			result, err := a.KeywordContexts(*config)
+			if err != nil {
+				return c.String(http.StatusInternalServerError, "Failed to execute keyword context: "+err.Error())
+			}
+			sheets = []exporter.Sheet{exporter.KeywordContextSheet(result, config.Filter)}
+			filename = "KWIC_" + result.Column
+		case analyzer.TextAnalysisKeywordCross:
+			result, err := a.KeywordCrosstab(*config)
+			if err != nil {
+				return c.String(http.StatusInternalServerError, "Failed to execute keyword crosstab: "+err.Error())
+			}
+			sheets = []exporter.Sheet{exporter.KeywordCrosstabSheet(result, config.Filter)}
+			filename = "キーワード_" + result.Column
+			if result.IsGrouped() {
+				filename += "×" + result.BannerColumn
+			}
+		default:
+			return c.String(http.StatusBadRequest, "invalid text_analysis: "+c.FormValue("text_analysis"))
+		}
+
+	default:
+		return c.String(http.StatusBadRequest, "Invalid analysis type")
+	}
+
+	return streamExport(c, format, filename, sheets)
+}
+
+// streamExport はシートをダウンロード用のレスポンスとして書き出す
+func streamExport(c echo.Context, format exporter.Format, filename string, sheets []exporter.Sheet) error {
+	filename = filename + "." + format.Extension()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, format.ContentType())
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(
+		`attachment; filename="export.%s"; filename*=UTF-8''%s`,
+		format.Extension(),
+		url.PathEscape(filename),
+	))
+	res.WriteHeader(http.StatusOK)
+
+	return exporter.Write(res, format, sheets)
 }
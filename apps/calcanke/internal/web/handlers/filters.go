@@ -9,7 +9,8 @@ import (
 
 // FiltersData はフィルタ選択UIのテンプレートデータ
 type FiltersData struct {
-	Filters []analyzer.Filter
+	Filters       []analyzer.Filter
+	WeightColumns analyzer.ColumnList // ウェイト列の候補（数値型の列）
 }
 
 // GetFilters はフィルタ選択UIを返す（htmx用）
@@ -20,8 +21,14 @@ func (h *Handler) GetFilters(c echo.Context) error {
 	}
 	defer a.Close()
 
+	columns, err := a.GetColumns()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to get columns")
+	}
+
 	data := FiltersData{
-		Filters: a.Filters,
+		Filters:       a.Filters,
+		WeightColumns: columns.NumericColumns(),
 	}
 
 	return c.Render(http.StatusOK, "filter_selector.html", data)
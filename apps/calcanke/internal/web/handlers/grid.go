@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+)
+
+// GridResultData はマトリクス設問の集計結果のテンプレートデータ
+type GridResultData struct {
+	Result *analyzer.GridResult
+	Filter *analyzer.Filter
+}
+
+// Grid はマトリクス設問を項目×選択肢の表に集計する
+func (h *Handler) Grid(c echo.Context) error {
+	a, err := h.getAnalyzer()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to initialize analyzer")
+	}
+	defer a.Close()
+
+	config, err := h.parseGridRequest(c, a)
+	if err != nil {
+		return c.String(requestErrorStatus(err), err.Error())
+	}
+
+	result, err := a.Grid(*config)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to execute grid: "+err.Error())
+	}
+
+	data := GridResultData{
+		Result: result,
+		Filter: config.Filter,
+	}
+
+	return c.Render(http.StatusOK, "grid_result.html", data)
+}
+
+// parseGridRequest はマトリクス設問のリクエストから集計設定を取得する
+// question_group は設問グループの名前、banner_column はバナーの列番号（省略可）
+func (h *Handler) parseGridRequest(c echo.Context, a *analyzer.Analyzer) (*analyzer.GridConfig, error) {
+	name := c.FormValue("question_group")
+	group := analyzer.FindQuestionGroup(h.loadQuestionGroups(), name)
+	if group == nil {
+		return nil, fmt.Errorf("question group not found: %s", name)
+	}
+
+	columns, err := a.GetColumns()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errGetColumns, err)
+	}
+
+	weight, err := findWeightColumn(columns, c.FormValue("weight"))
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := requestFilter(c, a)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &analyzer.GridConfig{
+		Group:        *group,
+		Filter:       filter,
+		WeightColumn: weight,
+	}
+
+	if banner := c.FormValue("banner_column"); banner != "" {
+		banners, err := columnsByIndex(columns, []string{banner})
+		if err != nil {
+			return nil, err
+		}
+		config.BannerColumn = banners[0]
+		splitBanner := c.FormValue("split_banner")
+		config.SplitBanner = splitBanner == "true" || splitBanner == "on"
+	}
+
+	return config, nil
+}
@@ -1,17 +1,35 @@
 package handlers
 
 import (
+	"errors"
+	"net/http"
+
 	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
 )
 
+// errGetColumns は集計のリクエストの解析中に列の取得に失敗したことを表す
+// リクエストの誤りではなくデータベースのエラーのため、400ではなく500を返す
+var errGetColumns = errors.New("failed to get columns")
+
+// requestErrorStatus はリクエストの解析のエラーに対するステータスコードを返す
+func requestErrorStatus(err error) int {
+	if errors.Is(err, errGetColumns) {
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
 // Handler はHTTPハンドラーの基底構造
 type Handler struct {
 	analyzer           *analyzer.Analyzer
 	dbPath             string
 	table              string
-	derivedColumnsPath string // オプショナル：プロジェクト固有の派生列設定パス
-	filtersPath        string // オプショナル：プロジェクト固有のフィルタ設定パス
-	columnOrdersPath   string // オプショナル：プロジェクト固有の列順序設定パス
+	joins              []analyzer.TableJoin // オプショナル：集計対象に結合するテーブル
+	derivedColumnsPath string               // オプショナル：プロジェクト固有の派生列設定パス
+	filtersPath        string               // オプショナル：プロジェクト固有のフィルタ設定パス
+	columnOrdersPath   string               // オプショナル：プロジェクト固有の列順序設定パス
+	questionGroupsPath string               // オプショナル：プロジェクト固有のマトリクス設問設定パス
+	stopwordsPath      string               // オプショナル：プロジェクト固有の自由回答の除外語設定パス
 }
 
 // NewHandler はハンドラーを作成する（デフォルトの設定パスを使用）
@@ -33,12 +51,46 @@ func NewHandlerWithConfigs(dbPath, table, derivedColumnsPath, filtersPath, colum
 	}
 }
 
+// NewHandlerWithSource は集計対象（テーブルの結合を含む）と設定ファイルパスを指定してハンドラーを作成する
+func NewHandlerWithSource(dbPath string, source analyzer.DataSource, derivedColumnsPath, filtersPath, columnOrdersPath string) *Handler {
+	h := NewHandlerWithConfigs(dbPath, source.Table, derivedColumnsPath, filtersPath, columnOrdersPath)
+	h.joins = source.Joins
+	return h
+}
+
 // getAnalyzer はAnalyzerのインスタンスを取得する
 // 各リクエストごとに新しいインスタンスを作成
 func (h *Handler) getAnalyzer() (*analyzer.Analyzer, error) {
 	// 設定ファイルパスが指定されている場合はそれを使用
 	if h.derivedColumnsPath != "" && h.filtersPath != "" && h.columnOrdersPath != "" {
-		return analyzer.NewAnalyzerWithConfigs(h.dbPath, h.table, h.derivedColumnsPath, h.filtersPath, h.columnOrdersPath)
+		source := analyzer.DataSource{Table: h.table, Joins: h.joins}
+		return analyzer.NewAnalyzerWithSource(h.dbPath, source, h.derivedColumnsPath, h.filtersPath, h.columnOrdersPath)
 	}
 	return analyzer.NewAnalyzer(h.dbPath, h.table)
 }
+
+// loadQuestionGroups はマトリクス設問の設定を読み込む（設定ファイルがない場合は空）
+func (h *Handler) loadQuestionGroups() []analyzer.QuestionGroup {
+	path := h.questionGroupsPath
+	if path == "" {
+		path = "configs/question_groups.yaml"
+	}
+	groups, err := analyzer.LoadQuestionGroups(path)
+	if err != nil {
+		return []analyzer.QuestionGroup{}
+	}
+	return groups
+}
+
+// loadStopwords は自由回答の語の出現頻度から除く語を読み込む（設定ファイルがない場合は空）
+func (h *Handler) loadStopwords() []string {
+	path := h.stopwordsPath
+	if path == "" {
+		path = "configs/stopwords.yaml"
+	}
+	stopwords, err := analyzer.LoadStopwords(path)
+	if err != nil {
+		return nil
+	}
+	return stopwords
+}
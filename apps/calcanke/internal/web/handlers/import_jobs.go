@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/project"
+)
+
+// importEventsInterval はSSEでインポート状況を確認する間隔
+const importEventsInterval = 500 * time.Millisecond
+
+// importStatus はインポート状況APIで返す内容
+type importStatus struct {
+	Status       string             `json:"status"`        // プロジェクトの状態（ready, importing, error）
+	ErrorMessage string             `json:"error_message"` // インポートに失敗した場合のエラー
+	Job          *project.ImportJob `json:"job"`           // 最新のインポートジョブ（ない場合はnull）
+}
+
+// finished はインポートが終了している（これ以上状態が変わらない）かどうかを返す
+func (s *importStatus) finished() bool {
+	return s.Job == nil || s.Job.IsFinished()
+}
+
+// loadImportStatus はプロジェクトと最新ジョブからインポート状況を作成
+func (h *ProjectHandler) loadImportStatus(id string) (*importStatus, error) {
+	p, err := h.repo.FindByID(id)
+	if err != nil || p == nil {
+		return nil, err
+	}
+
+	job, err := h.repo.FindLatestImportJob(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &importStatus{
+		Status:       p.Status,
+		ErrorMessage: p.ErrorMessage,
+		Job:          job,
+	}, nil
+}
+
+// GetImportStatus はインポートの進捗を返す（ポーリング用）
+func (h *ProjectHandler) GetImportStatus(c echo.Context) error {
+	status, err := h.loadImportStatus(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load import status"})
+	}
+	if status == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Project not found"})
+	}
+
+	return c.JSON(http.StatusOK, status)
+}
+
+// StreamImportEvents はインポートの進捗をServer-Sent Eventsで送る
+// 状態が変わるたびに status イベントを送り、終了したら done イベントを送って閉じる
+func (h *ProjectHandler) StreamImportEvents(c echo.Context) error {
+	id := c.Param("id")
+
+	status, err := h.loadImportStatus(id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load import status"})
+	}
+	if status == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Project not found"})
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(importEventsInterval)
+	defer ticker.Stop()
+
+	var last []byte
+	for {
+		data, err := json.Marshal(status)
+		if err != nil {
+			return err
+		}
+
+		// 変化があったときだけ送る
+		if string(data) != string(last) {
+			if _, err := fmt.Fprintf(res, "event: status\ndata: %s\n\n", data); err != nil {
+				return nil
+			}
+			res.Flush()
+			last = data
+		}
+
+		if status.finished() {
+			fmt.Fprintf(res, "event: done\ndata: %s\n\n", data)
+			res.Flush()
+			return nil
+		}
+
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		status, err = h.loadImportStatus(id)
+		if err != nil || status == nil {
+			// プロジェクトが削除された場合なども含めて終了
+			return nil
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+)
+
+// SelectionCountResultData は選択数の分布のテンプレートデータ
+type SelectionCountResultData struct {
+	Result *analyzer.SelectionCountResult
+	Filter *analyzer.Filter
+}
+
+// CooccurrenceResultData は同時選択の表のテンプレートデータ
+type CooccurrenceResultData struct {
+	Result *analyzer.CooccurrenceResult
+	Filter *analyzer.Filter
+}
+
+// MultiAnswer は複数回答の列を回答者単位で分析する
+// multi_analysis=selection_count は選択数の分布、cooccurrence は選択肢の同時選択の表を返す
+func (h *Handler) MultiAnswer(c echo.Context) error {
+	a, err := h.getAnalyzer()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to initialize analyzer")
+	}
+	defer a.Close()
+
+	config, filter, err := parseSimpletabRequest(c, a)
+	if err != nil {
+		return c.String(requestErrorStatus(err), err.Error())
+	}
+
+	switch c.FormValue("multi_analysis") {
+	case analyzer.MultiAnswerSelectionCount:
+		result, err := a.SelectionCount(config.XColumn, filter, config.WeightColumn)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, "Failed to execute selection count: "+err.Error())
+		}
+		return c.Render(http.StatusOK, "selection_count_result.html", SelectionCountResultData{Result: result, Filter: filter})
+
+	case analyzer.MultiAnswerCooccurrence:
+		result, err := a.Cooccurrence(config.XColumn, filter, config.WeightColumn)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, "Failed to execute cooccurrence: "+err.Error())
+		}
+		return c.Render(http.StatusOK, "cooccurrence_result.html", CooccurrenceResultData{Result: result, Filter: filter})
+
+	default:
+		return c.String(http.StatusBadRequest, fmt.Sprintf("invalid multi_analysis: %s", c.FormValue("multi_analysis")))
+	}
+}
@@ -1,16 +1,20 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
 	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/importer"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/jobs"
 	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/project"
 )
 
@@ -18,6 +22,7 @@ import (
 type ProjectHandler struct {
 	repo       *project.Repository
 	projectDir string
+	jobs       *jobs.Runner
 }
 
 // NewProjectHandler はプロジェクトハンドラを作成
@@ -25,9 +30,15 @@ func NewProjectHandler(repo *project.Repository, projectDir string) *ProjectHand
 	return &ProjectHandler{
 		repo:       repo,
 		projectDir: projectDir,
+		jobs:       jobs.NewRunner(repo, 2),
 	}
 }
 
+// WaitJobs は実行中のインポートがすべて終わるまで待つ（サーバー停止時に使用）
+func (h *ProjectHandler) WaitJobs() {
+	h.jobs.Wait()
+}
+
 // List はプロジェクト一覧を表示
 func (h *ProjectHandler) List(c echo.Context) error {
 	projects, err := h.repo.FindAll()
@@ -194,7 +205,8 @@ func (h *ProjectHandler) GetProjectAPI(c echo.Context) error {
 	return c.JSON(http.StatusOK, p)
 }
 
-// Upload はExcelファイルをアップロードしてインポートする
+// Upload はファイルをアップロードしてインポートを開始する
+// sheets が指定されていない場合はExcelの全シートをそれぞれ別テーブルとしてインポートする
 func (h *ProjectHandler) Upload(c echo.Context) error {
 	id := c.Param("id")
 
@@ -208,54 +220,305 @@ func (h *ProjectHandler) Upload(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "Project not found"})
 	}
 
+	// 実行中のインポートが読んでいる元ファイルを上書きしないように、保存する前に確認する
+	if status, err := h.checkImportNotRunning(p); err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	uploadPath, status, err := h.saveUploadedFile(c, p)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return h.importSheets(c, p, uploadPath)
+}
+
+// checkImportNotRunning は実行中のインポートがないことを確認する
+// エラー時はレスポンスに使うステータスコードを返す
+func (h *ProjectHandler) checkImportNotRunning(p *project.Project) (int, error) {
+	latest, err := h.repo.FindLatestImportJob(p.ID)
+	if err != nil {
+		return http.StatusInternalServerError, errors.New("Failed to load import job")
+	}
+	if latest != nil && !latest.IsFinished() {
+		return http.StatusConflict, errors.New("Import is already running")
+	}
+	return http.StatusOK, nil
+}
+
+// アップロードした一時ファイルの名前（upload-<ランダム>.<形式>.upload）
+const (
+	uploadPrefix = "upload-"
+	uploadSuffix = ".upload"
+)
+
+// saveUploadedFile はアップロードされたファイルを形式を判定してプロジェクトディレクトリの一時ファイルに保存する
+// 元ファイルへの置き換えは replaceSourceFile で行う
+// 保存したパスを返し、エラー時はレスポンスに使うステータスコードを返す
+func (h *ProjectHandler) saveUploadedFile(c echo.Context, p *project.Project) (string, int, error) {
 	// ファイルを取得
 	file, err := c.FormFile("file")
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No file uploaded"})
+		return "", http.StatusBadRequest, errors.New("No file uploaded")
+	}
+
+	// 拡張子から形式を判定
+	format, err := importer.DetectFormat(file.Filename)
+	if err != nil {
+		return "", http.StatusBadRequest, err
 	}
 
 	// ファイルを開く
 	src, err := file.Open()
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to open file"})
+		return "", http.StatusInternalServerError, errors.New("Failed to open file")
 	}
 	defer src.Close()
 
-	// 保存先パス
-	excelPath := p.GetExcelPath(h.projectDir)
-
-	// ファイルを保存
-	dst, err := os.Create(excelPath)
+	// 保存先は同時に行われた他のアップロードと重ならない一時ファイルにする
+	p.SourceFormat = string(format)
+	p.SourceFilename = filepath.Base(file.Filename)
+	dst, err := os.CreateTemp(p.GetProjectDir(h.projectDir), uploadPrefix+"*."+format.Extension()+uploadSuffix)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create file"})
+		return "", http.StatusInternalServerError, errors.New("Failed to create file")
 	}
 	defer dst.Close()
+	uploadPath := dst.Name()
 
+	// ファイルを保存
 	if _, err = io.Copy(dst, src); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save file"})
+		os.Remove(uploadPath)
+		return "", http.StatusInternalServerError, errors.New("Failed to save file")
 	}
 
-	// DuckDBにインポート
-	duckdbPath := p.GetDuckDBPath(h.projectDir)
-	tableName := "excel_import"
+	return uploadPath, http.StatusOK, nil
+}
 
-	if err := importer.ImportExcel(excelPath, duckdbPath, tableName); err != nil {
-		// インポート失敗時はステータスをエラーに
-		p.Status = string(project.StatusError)
-		h.repo.Update(p)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to import Excel: " + err.Error()})
+// findUpload は UploadSheets が返した一時ファイル名からアップロード済みファイルのパスと形式を返す
+func (h *ProjectHandler) findUpload(p *project.Project, name string) (string, importer.Format, error) {
+	if name != filepath.Base(name) || !strings.HasPrefix(name, uploadPrefix) || !strings.HasSuffix(name, uploadSuffix) {
+		return "", "", errors.New("Invalid upload")
 	}
 
-	// プロジェクト情報を更新
-	p.TableName = tableName
-	p.ExcelFilename = filepath.Base(file.Filename)
-	p.Status = string(project.StatusReady)
+	format, err := importer.DetectFormat(strings.TrimSuffix(name, uploadSuffix))
+	if err != nil {
+		return "", "", errors.New("Invalid upload")
+	}
+
+	path := filepath.Join(p.GetProjectDir(h.projectDir), name)
+	if _, err := os.Stat(path); err != nil {
+		return "", "", errors.New("Upload not found")
+	}
+	return path, format, nil
+}
+
+// replaceSourceFile はアップロードした一時ファイルでプロジェクトの元ファイルを置き換える
+// 形式が変わった場合に残る以前の形式の元ファイルは削除する
+func (h *ProjectHandler) replaceSourceFile(p *project.Project, uploadPath string) error {
+	sourcePath := p.GetSourcePath(h.projectDir)
+	if err := os.Rename(uploadPath, sourcePath); err != nil {
+		os.Remove(uploadPath)
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+
+	oldSources, err := filepath.Glob(filepath.Join(p.GetProjectDir(h.projectDir), "source.*"))
+	if err != nil {
+		return err
+	}
+	for _, path := range oldSources {
+		if filepath.Clean(path) != filepath.Clean(sourcePath) {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove old file: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// openSource はファイルを元ファイルの形式で開く（CSVの文字コード・区切り文字はフォームで指定可）
+func (h *ProjectHandler) openSource(c echo.Context, p *project.Project, path string) (importer.Source, error) {
+	format := importer.FormatXLSX
+	if p.SourceFormat != "" {
+		format = importer.Format(p.SourceFormat)
+	}
+
+	// 対応していない文字コードは形式にかかわらず受け付けない
+	encoding, err := importer.ParseEncoding(c.FormValue("encoding"))
+	if err != nil {
+		return nil, err
+	}
+
+	return importer.Open(path, format, importer.Options{
+		Encoding:  encoding,
+		Delimiter: c.FormValue("delimiter"),
+	})
+}
+
+// importSheets は元ファイルから指定シートをインポートするジョブを開始する
+// フォームの sheets（複数指定可）でシートを、main_sheet で集計に使う基準シートを指定する
+// uploadPath を指定した場合はアップロードした一時ファイルのシートを確認し、ジョブを登録できてから元ファイルを置き換える
+// インポートはバックグラウンドで行い、進捗は import-status / import-events で取得する
+func (h *ProjectHandler) importSheets(c echo.Context, p *project.Project, uploadPath string) error {
+	readPath := p.GetSourcePath(h.projectDir)
+	if uploadPath != "" {
+		readPath = uploadPath
+	}
+
+	sheetTables, err := h.selectSheetTables(c, p, readPath)
+	if err != nil {
+		if uploadPath != "" {
+			os.Remove(uploadPath)
+		}
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	// ジョブを登録（実行中のインポートがある場合は開始しない）
+	job := project.NewImportJob(uuid.New().String(), p.ID)
+	if err := h.repo.CreateImportJob(job); err != nil {
+		if uploadPath != "" {
+			os.Remove(uploadPath)
+		}
+		if errors.Is(err, project.ErrImportRunning) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "Import is already running"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create import job"})
+	}
+
+	// ジョブを開始できない場合は、登録したジョブを失敗にする
+	abort := func(status int, err error) error {
+		if finishErr := h.repo.FinishJob(job.ID, err); finishErr != nil {
+			c.Logger().Error(finishErr)
+		}
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
 
+	if uploadPath != "" {
+		if err := h.replaceSourceFile(p, uploadPath); err != nil {
+			return abort(http.StatusInternalServerError, err)
+		}
+	}
+
+	src, err := h.openSource(c, p, p.GetSourcePath(h.projectDir))
+	if err != nil {
+		return abort(http.StatusInternalServerError, fmt.Errorf("Failed to open file: %w", err))
+	}
+
+	// インポート中にする
+	p.Status = string(project.StatusImporting)
+	p.ErrorMessage = ""
 	if err := h.repo.Update(p); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update project"})
+		src.Close()
+		return abort(http.StatusInternalServerError, errors.New("Failed to update project"))
+	}
+
+	mainSheet := c.FormValue("main_sheet")
+	h.jobs.Start(job.ID, func(report jobs.ProgressFunc) error {
+		defer src.Close()
+
+		err := h.runImport(p, src, sheetTables, mainSheet, report)
+		if err != nil {
+			// インポート失敗時はステータスをエラーにしてメッセージを残す
+			p.Status = string(project.StatusError)
+			p.ErrorMessage = err.Error()
+			if updateErr := h.repo.Update(p); updateErr != nil {
+				return fmt.Errorf("%w (failed to update project: %v)", err, updateErr)
+			}
+		}
+		return err
+	})
+
+	return c.JSON(http.StatusAccepted, map[string]interface{}{
+		"message":    "Import started",
+		"project_id": p.ID,
+		"job_id":     job.ID,
+	})
+}
+
+// selectSheetTables はファイルのシートのうちフォームの sheets で指定されたものを返す（指定がなければ全シート）
+// テーブル名は全シートで重複しないように割り当てる
+func (h *ProjectHandler) selectSheetTables(c echo.Context, p *project.Project, path string) ([]importer.SheetTable, error) {
+	src, err := h.openSource(c, p, path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	sheetNames, err := src.Sheets()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read file: %w", err)
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{"message": "Upload successful", "project_id": p.ID})
+	selected := make(map[string]bool)
+	form, _ := c.FormParams()
+	for _, sheet := range form["sheets"] {
+		selected[sheet] = true
+	}
+
+	var sheetTables []importer.SheetTable
+	for _, st := range importer.DefaultSheetTables(sheetNames, p.SourceFilename) {
+		if len(selected) == 0 || selected[st.Sheet] {
+			sheetTables = append(sheetTables, st)
+		}
+	}
+	if len(sheetTables) == 0 {
+		return nil, errors.New("No sheets selected")
+	}
+
+	return sheetTables, nil
+}
+
+// runImport はDuckDBへのインポートとプロジェクト情報の更新を行う（バックグラウンドで実行）
+func (h *ProjectHandler) runImport(p *project.Project, src importer.Source, sheetTables []importer.SheetTable, mainSheet string, report jobs.ProgressFunc) error {
+	// 以前のインポートで作ったテーブルは、今回読み込まないものを削除する
+	staleTables := []string{p.TableName}
+	for _, t := range p.Tables {
+		staleTables = append(staleTables, t.Name)
+	}
+
+	// DuckDBにインポート（進捗は 5〜90% をシート数で按分）
+	duckdbPath := p.GetDuckDBPath(h.projectDir)
+	imported, err := importer.ImportWithProgress(src, duckdbPath, sheetTables, false, staleTables, func(done, total int, st importer.SheetTable) {
+		name := st.Sheet
+		if name == "" {
+			name = p.SourceFilename
+		}
+		report(5+done*85/total, fmt.Sprintf("読み込み中: %s (%d/%d)", name, done+1, total))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import: %w", err)
+	}
+
+	report(90, "テーブル情報を保存中")
+
+	// インポートしたテーブルを記録（基準シートが指定されていなければ先頭シート）
+	tables := make([]project.Table, len(imported))
+	p.TableName = imported[0].Table
+	for i, t := range imported {
+		tables[i] = project.Table{Name: t.Table, SheetName: t.Sheet, RowCount: t.RowCount}
+		if t.Sheet == mainSheet {
+			p.TableName = t.Table
+		}
+	}
+	if err := h.repo.SaveTables(p.ID, tables); err != nil {
+		return err
+	}
+	p.Tables = tables
+
+	// 以前の結合設定は無効になるため削除
+	if err := os.Remove(p.GetDataSourcePath(h.projectDir)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to reset data source: %w", err)
+	}
+
+	// SQL式の派生列を新しいデータで確認し直す
+	if err := h.recheckSQLExpressions(p); err != nil {
+		return err
+	}
+
+	// プロジェクト情報を更新
+	p.Status = string(project.StatusReady)
+	p.ErrorMessage = ""
+
+	return h.repo.Update(p)
 }
 
 // ShowAnalysis はプロジェクトの分析画面を表示
@@ -284,7 +547,8 @@ func (h *ProjectHandler) ShowAnalysis(c echo.Context) error {
 	columnOrdersPath := p.GetColumnOrdersPath(h.projectDir)
 
 	// Analyzerを作成してテーブル情報を取得
-	a, err := analyzer.NewAnalyzerWithConfigs(dbPath, p.TableName, derivedColumnsPath, filtersPath, columnOrdersPath)
+	source := h.loadDataSource(p)
+	a, err := analyzer.NewAnalyzerWithSource(dbPath, source, derivedColumnsPath, filtersPath, columnOrdersPath)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, "Failed to initialize analyzer")
 	}
@@ -298,13 +562,71 @@ func (h *ProjectHandler) ShowAnalysis(c echo.Context) error {
 	data := map[string]interface{}{
 		"Project": p,
 		"DBPath":  dbPath,
-		"Table":   p.TableName,
+		"Table":   source.Table,
+		"Joins":   source.Joins,
+		"Tables":  projectTables(p),
 		"Total":   total,
 	}
 
 	return c.Render(http.StatusOK, "project_analysis.html", data)
 }
 
+// ShowData はプロジェクトのデータの一覧画面を表示
+// filter・af（絞り込みの条件のJSON）を指定した場合は、そのフィルタを適用した状態で開く
+func (h *ProjectHandler) ShowData(c echo.Context) error {
+	id := c.Param("id")
+
+	p, err := h.repo.FindByID(id)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load project: "+err.Error())
+	}
+
+	if p == nil {
+		return c.String(http.StatusNotFound, "Project not found")
+	}
+
+	if p.Status != string(project.StatusReady) {
+		return c.String(http.StatusBadRequest, "Project is not ready for analysis")
+	}
+
+	handler, err := h.getProjectHandler(id)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to initialize analyzer")
+	}
+	a, err := handler.getAnalyzer()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to initialize analyzer")
+	}
+	defer a.Close()
+
+	total, err := a.GetTableInfo()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to get table info")
+	}
+
+	source := h.loadDataSource(p)
+	data := map[string]interface{}{
+		"Project": p,
+		"Table":   source.Table,
+		"Joins":   source.Joins,
+		"Total":   total,
+		"Filters": a.Filters,
+		"Filter":  c.QueryParam("filter"),
+	}
+
+	return c.Render(http.StatusOK, "project_data.html", data)
+}
+
+// ProjectRecords はプロジェクトのデータの一覧（1ページ分）を返す
+func (h *ProjectHandler) ProjectRecords(c echo.Context) error {
+	projectID := c.Param("id")
+	handler, err := h.getProjectHandler(projectID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return handler.Records(c)
+}
+
 // getProjectHandler はプロジェクト用のHandlerを作成する
 // 既存のHandlerメソッドを再利用するため
 func (h *ProjectHandler) getProjectHandler(projectID string) (*Handler, error) {
@@ -325,7 +647,10 @@ func (h *ProjectHandler) getProjectHandler(projectID string) (*Handler, error) {
 	filtersPath := p.GetFiltersPath(h.projectDir)
 	columnOrdersPath := p.GetColumnOrdersPath(h.projectDir)
 
-	return NewHandlerWithConfigs(dbPath, p.TableName, derivedColumnsPath, filtersPath, columnOrdersPath), nil
+	handler := NewHandlerWithSource(dbPath, h.loadDataSource(p), derivedColumnsPath, filtersPath, columnOrdersPath)
+	handler.questionGroupsPath = p.GetQuestionGroupsPath(h.projectDir)
+	handler.stopwordsPath = p.GetStopwordsPath(h.projectDir)
+	return handler, nil
 }
 
 // GetProjectColumns はプロジェクトのカラム一覧をHTML形式で返す（htmx用）
@@ -378,6 +703,86 @@ func (h *ProjectHandler) ProjectCrosstab(c echo.Context) error {
 	return handler.Crosstab(c)
 }
 
+// ProjectLayeredCrosstab はプロジェクトの層別クロス集計を実行
+func (h *ProjectHandler) ProjectLayeredCrosstab(c echo.Context) error {
+	projectID := c.Param("id")
+	handler, err := h.getProjectHandler(projectID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return handler.LayeredCrosstab(c)
+}
+
+// ProjectNumericStats はプロジェクト固有の記述統計量を実行
+func (h *ProjectHandler) ProjectNumericStats(c echo.Context) error {
+	projectID := c.Param("id")
+	handler, err := h.getProjectHandler(projectID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return handler.NumericStats(c)
+}
+
+// ProjectGrid はプロジェクト固有のマトリクス設問の集計を実行
+func (h *ProjectHandler) ProjectGrid(c echo.Context) error {
+	projectID := c.Param("id")
+	handler, err := h.getProjectHandler(projectID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return handler.Grid(c)
+}
+
+// ProjectMultiAnswer はプロジェクト固有の複数回答の分析を実行
+func (h *ProjectHandler) ProjectMultiAnswer(c echo.Context) error {
+	projectID := c.Param("id")
+	handler, err := h.getProjectHandler(projectID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return handler.MultiAnswer(c)
+}
+
+// ProjectText はプロジェクト固有の自由回答の分析を実行
+func (h *ProjectHandler) ProjectText(c echo.Context) error {
+	projectID := c.Param("id")
+	handler, err := h.getProjectHandler(projectID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return handler.Text(c)
+}
+
+// ProjectScaleSummary はプロジェクト固有の評価尺度の集計を実行
+func (h *ProjectHandler) ProjectScaleSummary(c echo.Context) error {
+	projectID := c.Param("id")
+	handler, err := h.getProjectHandler(projectID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return handler.ScaleSummary(c)
+}
+
+// ProjectBannerTables はプロジェクトのバナー表をダウンロード
+func (h *ProjectHandler) ProjectBannerTables(c echo.Context) error {
+	projectID := c.Param("id")
+	handler, err := h.getProjectHandler(projectID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return handler.BannerTables(c)
+}
+
+// ProjectCellRecords はプロジェクトの集計表のセルの回答者を返す
+func (h *ProjectHandler) ProjectCellRecords(c echo.Context) error {
+	projectID := c.Param("id")
+	handler, err := h.getProjectHandler(projectID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return handler.CellRecords(c)
+}
+
 // ProjectExport はプロジェクトのエクスポートを実行
 func (h *ProjectHandler) ProjectExport(c echo.Context) error {
 	projectID := c.Param("id")
@@ -421,6 +826,12 @@ func (h *ProjectHandler) AddDerivedColumn(c echo.Context) error {
 	if err := c.Bind(&newColumn); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
 	}
+	if err := newColumn.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if err := h.validateSQLExpression(id, &newColumn); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
 
 	// 既存の派生列を読み込み
 	derivedColumnsPath := p.GetDerivedColumnsPath(h.projectDir)
@@ -459,6 +870,12 @@ func (h *ProjectHandler) UpdateDerivedColumn(c echo.Context) error {
 	if err := c.Bind(&updatedColumn); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
 	}
+	if err := updatedColumn.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if err := h.validateSQLExpression(id, &updatedColumn); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
 
 	// 既存の派生列を読み込み
 	derivedColumnsPath := p.GetDerivedColumnsPath(h.projectDir)
@@ -483,6 +900,59 @@ func (h *ProjectHandler) UpdateDerivedColumn(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]string{"message": "Derived column updated successfully"})
 }
 
+// validateSQLExpression はSQL式の派生列の式をプロジェクトのデータに対して確認し、確認を通ったことを派生列に記録する
+// 他の計算タイプは確認しない
+func (h *ProjectHandler) validateSQLExpression(projectID string, dc *analyzer.DerivedColumn) error {
+	if dc.CalculationType != "sql_expression" {
+		return nil
+	}
+
+	handler, err := h.getProjectHandler(projectID)
+	if err != nil {
+		return err
+	}
+	a, err := handler.getAnalyzer()
+	if err != nil {
+		return fmt.Errorf("failed to initialize analyzer: %w", err)
+	}
+	defer a.Close()
+
+	return a.CheckSQLExpression(dc)
+}
+
+// recheckSQLExpressions はデータ（インポートしたテーブルや集計対象）が変わったときに、
+// 保存済みのSQL式の派生列を確認し直して記録を更新する（確認できなくなった式の値はNULLになる）
+func (h *ProjectHandler) recheckSQLExpressions(p *project.Project) error {
+	derivedColumnsPath := p.GetDerivedColumnsPath(h.projectDir)
+	columns, err := analyzer.LoadDerivedColumns(derivedColumnsPath)
+	if err != nil {
+		return nil // 派生列の設定がなければ確認するものはない
+	}
+
+	a, err := analyzer.NewAnalyzerWithSource(p.GetDuckDBPath(h.projectDir), h.loadDataSource(p), "", "", "")
+	if err != nil {
+		return fmt.Errorf("failed to initialize analyzer: %w", err)
+	}
+	defer a.Close()
+
+	changed := false
+	for i := range columns {
+		dc := &columns[i]
+		if dc.CalculationType != "sql_expression" {
+			continue
+		}
+		checked := dc.CheckedExpression
+		if err := a.CheckSQLExpression(dc); err != nil {
+			log.Printf("derived column %s: %v", dc.Name, err)
+		}
+		changed = changed || dc.CheckedExpression != checked
+	}
+	if !changed {
+		return nil
+	}
+	return analyzer.SaveDerivedColumns(derivedColumnsPath, columns)
+}
+
 // DeleteDerivedColumn は派生列を削除
 func (h *ProjectHandler) DeleteDerivedColumn(c echo.Context) error {
 	id := c.Param("id")
@@ -520,6 +990,26 @@ func (h *ProjectHandler) DeleteDerivedColumn(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]string{"message": "Derived column deleted successfully"})
 }
 
+// GetKeywordCodingCoverage はキーワードによるコーディングの派生列のカバー率を取得
+func (h *ProjectHandler) GetKeywordCodingCoverage(c echo.Context) error {
+	projectID := c.Param("id")
+	handler, err := h.getProjectHandler(projectID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return handler.KeywordCodingCoverage(c)
+}
+
+// GetDateParseReport は日付を解析する派生列の解析結果を取得
+func (h *ProjectHandler) GetDateParseReport(c echo.Context) error {
+	projectID := c.Param("id")
+	handler, err := h.getProjectHandler(projectID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return handler.DateParseReport(c)
+}
+
 // GetDerivedColumnTemplates はテンプレートライブラリから派生列テンプレートを取得
 func (h *ProjectHandler) GetDerivedColumnTemplates(c echo.Context) error {
 	// configs/derived_columns.yaml からテンプレートを読み込む
@@ -590,6 +1080,12 @@ func (h *ProjectHandler) ImportDerivedColumnTemplates(c echo.Context) error {
 		template.Name = newName
 		existingNames[newName] = true
 
+		// SQL式はプロジェクトのデータで確認し、通った場合だけ確認済みとして保存する
+		template.CheckedExpression = ""
+		if err := h.validateSQLExpression(id, &template); err != nil {
+			c.Logger().Warnf("derived column %s: %v", newName, err)
+		}
+
 		// 派生列を追加
 		columns = append(columns, template)
 		importedCount++
@@ -639,6 +1135,9 @@ func (h *ProjectHandler) AddFilterConfig(c echo.Context) error {
 	if err := c.Bind(&newFilter); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
 	}
+	if err := newFilter.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
 
 	// 既存のフィルタを読み込み
 	filtersPath := p.GetFiltersPath(h.projectDir)
@@ -677,6 +1176,9 @@ func (h *ProjectHandler) UpdateFilterConfig(c echo.Context) error {
 	if err := c.Bind(&updatedFilter); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
 	}
+	if err := updatedFilter.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
 
 	// 既存のフィルタを読み込み
 	filtersPath := p.GetFiltersPath(h.projectDir)
@@ -782,3 +1284,124 @@ func (h *ProjectHandler) UpdateColumnOrders(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "Column orders updated successfully"})
 }
+
+// GetQuestionGroups はマトリクス設問の設定を取得する
+func (h *ProjectHandler) GetQuestionGroups(c echo.Context) error {
+	projectID := c.Param("id")
+
+	// プロジェクトを取得
+	p, err := h.repo.FindByID(projectID)
+	if err != nil || p == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Project not found"})
+	}
+
+	// 設定ファイルがない場合は空のリストを返す
+	groups, err := analyzer.LoadQuestionGroups(p.GetQuestionGroupsPath(h.projectDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return c.JSON(http.StatusOK, []analyzer.QuestionGroup{})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load question groups"})
+	}
+	if groups == nil {
+		groups = []analyzer.QuestionGroup{}
+	}
+
+	return c.JSON(http.StatusOK, groups)
+}
+
+// UpdateQuestionGroups はマトリクス設問の設定を更新する
+func (h *ProjectHandler) UpdateQuestionGroups(c echo.Context) error {
+	projectID := c.Param("id")
+
+	// プロジェクトを取得
+	p, err := h.repo.FindByID(projectID)
+	if err != nil || p == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Project not found"})
+	}
+
+	// リクエストボディをパース
+	var groups []analyzer.QuestionGroup
+	if err := c.Bind(&groups); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+
+	// 名前の重複と列のない設問グループは保存しない
+	names := make(map[string]bool)
+	for _, g := range groups {
+		if g.Name == "" || len(g.Columns) == 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Question group requires a name and at least one column"})
+		}
+		if names[g.Name] {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Duplicate question group name: " + g.Name})
+		}
+		names[g.Name] = true
+	}
+
+	// 保存
+	if err := analyzer.SaveQuestionGroups(p.GetQuestionGroupsPath(h.projectDir), groups); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save question groups"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Question groups updated successfully"})
+}
+
+// GetStopwords は自由回答の語の出現頻度から除く語の設定を取得する
+func (h *ProjectHandler) GetStopwords(c echo.Context) error {
+	projectID := c.Param("id")
+
+	// プロジェクトを取得
+	p, err := h.repo.FindByID(projectID)
+	if err != nil || p == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Project not found"})
+	}
+
+	// 設定ファイルがない場合は空のリストを返す
+	stopwords, err := analyzer.LoadStopwords(p.GetStopwordsPath(h.projectDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return c.JSON(http.StatusOK, []string{})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load stopwords"})
+	}
+	if stopwords == nil {
+		stopwords = []string{}
+	}
+
+	return c.JSON(http.StatusOK, stopwords)
+}
+
+// UpdateStopwords は自由回答の語の出現頻度から除く語の設定を更新する
+func (h *ProjectHandler) UpdateStopwords(c echo.Context) error {
+	projectID := c.Param("id")
+
+	// プロジェクトを取得
+	p, err := h.repo.FindByID(projectID)
+	if err != nil || p == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Project not found"})
+	}
+
+	// リクエストボディをパース
+	var stopwords []string
+	if err := c.Bind(&stopwords); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+
+	// 空の語と重複は保存しない
+	seen := make(map[string]bool)
+	cleaned := []string{}
+	for _, w := range stopwords {
+		if w == "" || seen[w] {
+			continue
+		}
+		seen[w] = true
+		cleaned = append(cleaned, w)
+	}
+
+	// 保存
+	if err := analyzer.SaveStopwords(p.GetStopwordsPath(h.projectDir), cleaned); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save stopwords"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Stopwords updated successfully"})
+}
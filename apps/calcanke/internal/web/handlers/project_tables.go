@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/labstack/echo/v4"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/importer"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/project"
+)
+
+// tableInfo はテーブル一覧APIで返すテーブルの情報
+type tableInfo struct {
+	project.Table
+	Columns []string `json:"columns"`
+}
+
+// UploadSheets はファイルを一時ファイルに保存してシート一覧を返す（インポートは ImportSheets で行う）
+// シートを持たない形式（CSVなど）は1件だけ返す
+// 元ファイルはまだ置き換えず、返した upload を ImportSheets に渡すとインポート開始時に置き換える
+func (h *ProjectHandler) UploadSheets(c echo.Context) error {
+	id := c.Param("id")
+
+	p, err := h.repo.FindByID(id)
+	if err != nil || p == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Project not found"})
+	}
+
+	uploadPath, status, err := h.saveUploadedFile(c, p)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	src, err := h.openSource(c, p, uploadPath)
+	if err != nil {
+		os.Remove(uploadPath)
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to open file: " + err.Error()})
+	}
+
+	sheets, err := src.Sheets()
+	src.Close()
+	if err != nil {
+		os.Remove(uploadPath)
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to read file: " + err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"upload":   filepath.Base(uploadPath),
+		"filename": p.SourceFilename,
+		"format":   p.SourceFormat,
+		"sheets":   importer.DefaultSheetTables(sheets, p.SourceFilename),
+	})
+}
+
+// ImportSheets は選択したシートをインポートする
+// upload（UploadSheets が返した値）と filename を指定した場合はそのファイルで元ファイルを置き換えてから、
+// 指定しない場合は現在の元ファイルからインポートする
+func (h *ProjectHandler) ImportSheets(c echo.Context) error {
+	id := c.Param("id")
+
+	p, err := h.repo.FindByID(id)
+	if err != nil || p == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Project not found"})
+	}
+
+	upload := c.FormValue("upload")
+	if upload == "" {
+		return h.importSheets(c, p, "")
+	}
+
+	uploadPath, format, err := h.findUpload(p, upload)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	p.SourceFormat = string(format)
+	p.SourceFilename = "source." + format.Extension()
+	if filename := c.FormValue("filename"); filename != "" {
+		p.SourceFilename = filepath.Base(filename)
+	}
+
+	return h.importSheets(c, p, uploadPath)
+}
+
+// GetTables はプロジェクトのテーブル一覧（列名付き）と現在の集計対象を返す
+func (h *ProjectHandler) GetTables(c echo.Context) error {
+	id := c.Param("id")
+
+	p, err := h.repo.FindByID(id)
+	if err != nil || p == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Project not found"})
+	}
+
+	tables := projectTables(p)
+	if len(tables) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Project has no tables"})
+	}
+
+	a, err := analyzer.NewAnalyzerWithConfigs(p.GetDuckDBPath(h.projectDir), tables[0].Name, "", "", "")
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to initialize analyzer"})
+	}
+	defer a.Close()
+
+	infos := make([]tableInfo, len(tables))
+	for i, t := range tables {
+		columns, err := a.TableColumnNames(t.Name)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		infos[i] = tableInfo{Table: t, Columns: columns}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"tables":      infos,
+		"data_source": h.loadDataSource(p),
+	})
+}
+
+// UpdateDataSource は集計対象のテーブル（または結合）を更新する
+func (h *ProjectHandler) UpdateDataSource(c echo.Context) error {
+	id := c.Param("id")
+
+	p, err := h.repo.FindByID(id)
+	if err != nil || p == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Project not found"})
+	}
+
+	var source analyzer.DataSource
+	if err := c.Bind(&source); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+
+	// プロジェクトのテーブルのみ指定可能
+	known := make(map[string]bool)
+	for _, t := range projectTables(p) {
+		known[t.Name] = true
+	}
+	if !known[source.Table] {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown table: " + source.Table})
+	}
+	for _, join := range source.Joins {
+		if !known[join.Table] {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown table: " + join.Table})
+		}
+	}
+
+	// 結合できることを確認してから保存
+	a, err := analyzer.NewAnalyzerWithSource(p.GetDuckDBPath(h.projectDir), source, "", "", "")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	a.Close()
+
+	if err := analyzer.SaveDataSource(p.GetDataSourcePath(h.projectDir), source); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save data source"})
+	}
+
+	// SQL式の派生列を新しい集計対象で確認し直す
+	if err := h.recheckSQLExpressions(p); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Data source updated successfully"})
+}
+
+// loadDataSource はプロジェクトの集計対象を読み込む（未設定の場合は基準テーブルのみ）
+func (h *ProjectHandler) loadDataSource(p *project.Project) analyzer.DataSource {
+	source, err := analyzer.LoadDataSource(p.GetDataSourcePath(h.projectDir))
+	if err != nil || source.Table == "" {
+		return analyzer.DataSource{Table: p.TableName}
+	}
+	return *source
+}
+
+// projectTables はプロジェクトのテーブル一覧を返す
+// テーブル一覧を持たない以前のプロジェクトは TableName のみのテーブルとして扱う
+func projectTables(p *project.Project) []project.Table {
+	if len(p.Tables) == 0 && p.TableName != "" {
+		return []project.Table{{Name: p.TableName}}
+	}
+	return p.Tables
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/exporter"
+)
+
+const (
+	defaultRecordsLimit = 50   // 回答者の一覧の1ページの行数
+	maxRecordsLimit     = 1000 // 回答者の一覧の1ページの行数の上限
+)
+
+// CellRecords は集計表のセルの回答者（元データの行）を返す
+// リクエストは単純集計・クロス集計と同じパラメータに、セルの値 x_value・y_value・z_value
+// （全体の行・列は省略）、出力する列名 columns（複数指定、省略時は全ての列）、limit・offset を加えたもの
+// format を指定した場合は、ページに分けずに全ての行をCSV・XLSXでダウンロードする
+func (h *Handler) CellRecords(c echo.Context) error {
+	a, err := h.getAnalyzer()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to initialize analyzer"})
+	}
+	defer a.Close()
+
+	config, err := parseCellRecordsRequest(c, a)
+	if err != nil {
+		return c.JSON(requestErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+
+	return respondRecords(c, a, config)
+}
+
+// Records は元データの行（派生列を含む）を1ページずつ返す（データの一覧画面用）
+// columns は出力する列名（複数指定、省略時は全ての列）、search は列名から検索する文字列へのJSONオブジェクト、
+// sort は並べ替える列名（order=desc で降順）、filter・adhoc_filter は集計と同じフィルタ、limit・offset はページ
+// format を指定した場合は、ページに分けずに全ての行をCSV・XLSXでダウンロードする
+func (h *Handler) Records(c echo.Context) error {
+	a, err := h.getAnalyzer()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to initialize analyzer"})
+	}
+	defer a.Close()
+
+	config, err := parseRecordsRequest(c, a)
+	if err != nil {
+		return c.JSON(requestErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+
+	return respondRecords(c, a, config)
+}
+
+// respondRecords は回答者の一覧をJSONで返す（format を指定した場合は全ての行をダウンロード）
+func respondRecords(c echo.Context, a *analyzer.Analyzer, config *analyzer.RecordsConfig) error {
+	if formatName := c.FormValue("format"); formatName != "" {
+		format, err := exporter.ParseFormat(formatName)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		config.Limit, config.Offset = 0, 0
+
+		result, err := a.Records(*config)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		return streamExport(c, format, "回答者一覧", []exporter.Sheet{exporter.RecordsSheet(result, config.Filter)})
+	}
+
+	result, err := a.Records(*config)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// parseCellRecordsRequest はセルの回答者のリクエストから一覧の設定を取得する
+func parseCellRecordsRequest(c echo.Context, a *analyzer.Analyzer) (*analyzer.RecordsConfig, error) {
+	form, err := c.FormParams()
+	if err != nil {
+		return nil, fmt.Errorf("invalid form: %w", err)
+	}
+
+	var config *analyzer.AnalysisConfig
+	var filter *analyzer.Filter
+	if c.FormValue("analysis_type") == "cross" {
+		config, filter, err = parseCrosstabRequest(c, a)
+	} else {
+		config, filter, err = parseSimpletabRequest(c, a)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cell := &analyzer.CellSelection{Config: *config}
+	cell.XValue = optionalFormValue(form, "x_value")
+	cell.YValue = optionalFormValue(form, "y_value")
+	cell.ZValue = optionalFormValue(form, "z_value")
+	if cell.ZValue != nil && config.ZColumn == nil {
+		return nil, fmt.Errorf("z_value requires z_column")
+	}
+
+	columns, err := a.GetColumns()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errGetColumns, err)
+	}
+	selected, err := columnsByName(columns, form["columns"])
+	if err != nil {
+		return nil, err
+	}
+
+	limit, offset, err := parsePaging(c, defaultRecordsLimit, maxRecordsLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &analyzer.RecordsConfig{
+		Columns: selected,
+		Filter:  filter,
+		Cell:    cell,
+		Limit:   limit,
+		Offset:  offset,
+	}, nil
+}
+
+// parseRecordsRequest はデータの一覧のリクエストから一覧の設定を取得する
+func parseRecordsRequest(c echo.Context, a *analyzer.Analyzer) (*analyzer.RecordsConfig, error) {
+	form, err := c.FormParams()
+	if err != nil {
+		return nil, fmt.Errorf("invalid form: %w", err)
+	}
+
+	columns, err := a.GetColumns()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errGetColumns, err)
+	}
+	selected, err := columnsByName(columns, form["columns"])
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := requestFilter(c, a)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &analyzer.RecordsConfig{
+		Columns:    selected,
+		Filter:     filter,
+		Descending: c.FormValue("order") == "desc",
+	}
+
+	if search := c.FormValue("search"); search != "" {
+		var texts map[string]string
+		if err := json.Unmarshal([]byte(search), &texts); err != nil {
+			return nil, fmt.Errorf("invalid search: %w", err)
+		}
+		for name, text := range texts {
+			column := columns.FindByName(name)
+			if column == nil {
+				return nil, fmt.Errorf("column not found: %s", name)
+			}
+			config.Searches = append(config.Searches, analyzer.RecordSearch{Column: column, Text: text})
+		}
+	}
+
+	if name := c.FormValue("sort"); name != "" {
+		if config.Sort = columns.FindByName(name); config.Sort == nil {
+			return nil, fmt.Errorf("column not found: %s", name)
+		}
+	}
+
+	if config.Limit, config.Offset, err = parsePaging(c, defaultRecordsLimit, maxRecordsLimit); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// columnsByName は列名のリストから列を取得する
+func columnsByName(columns analyzer.ColumnList, names []string) ([]*analyzer.Column, error) {
+	result := make([]*analyzer.Column, 0, len(names))
+	for _, name := range names {
+		column := columns.FindByName(name)
+		if column == nil {
+			return nil, fmt.Errorf("column not found: %s", name)
+		}
+		result = append(result, column)
+	}
+	return result, nil
+}
+
+// optionalFormValue はフォームの値を返す（パラメータがない場合はnil、空文字は空文字の値）
+func optionalFormValue(form url.Values, name string) *string {
+	values, ok := form[name]
+	if !ok || len(values) == 0 {
+		return nil
+	}
+	return &values[0]
+}
+
+// parsePaging はリクエストの limit・offset を取得する（limit は省略時は既定の行数、上限を超える場合は上限）
+func parsePaging(c echo.Context, defaultLimit, maxLimit int) (int, int, error) {
+	limit := defaultLimit
+	if s := c.FormValue("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return 0, 0, fmt.Errorf("invalid limit: %s", s)
+		}
+		limit = min(n, maxLimit)
+	}
+
+	offset := 0
+	if s := c.FormValue("offset"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return 0, 0, fmt.Errorf("invalid offset: %s", s)
+		}
+		offset = n
+	}
+	return limit, offset, nil
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+)
+
+// ScaleSummaryResultData は評価尺度の集計結果のテンプレートデータ
+type ScaleSummaryResultData struct {
+	Result *analyzer.ScaleSummaryResult
+	Filter *analyzer.Filter
+}
+
+// ScaleSummary は評価尺度（段階評価・NPS）の列を集計する
+func (h *Handler) ScaleSummary(c echo.Context) error {
+	a, err := h.getAnalyzer()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to initialize analyzer")
+	}
+	defer a.Close()
+
+	config, err := parseScaleSummaryRequest(c, a)
+	if err != nil {
+		return c.String(requestErrorStatus(err), err.Error())
+	}
+
+	result, err := a.ScaleSummary(*config)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to execute scale summary: "+err.Error())
+	}
+
+	data := ScaleSummaryResultData{
+		Result: result,
+		Filter: config.Filter,
+	}
+
+	return c.Render(http.StatusOK, "scale_summary_result.html", data)
+}
+
+// parseScaleSummaryRequest は評価尺度の集計のリクエストから集計設定を取得する
+// 集計する列とグループは記述統計量と同じ（クロス集計の場合はY軸の列をX軸の値ごとに集計）
+// 集計方法は scale（likert または nps）で指定する
+func parseScaleSummaryRequest(c echo.Context, a *analyzer.Analyzer) (*analyzer.ScaleSummaryConfig, error) {
+	scaleType := c.FormValue("scale")
+	if scaleType != analyzer.ScaleLikert && scaleType != analyzer.ScaleNPS {
+		return nil, fmt.Errorf("invalid scale type: %s", scaleType)
+	}
+
+	stats, err := parseNumericStatsRequest(c, a)
+	if err != nil {
+		return nil, err
+	}
+
+	return &analyzer.ScaleSummaryConfig{
+		Column:       stats.Column,
+		GroupColumn:  stats.GroupColumn,
+		SplitGroup:   stats.SplitGroup,
+		ScaleType:    scaleType,
+		Filter:       stats.Filter,
+		WeightColumn: stats.WeightColumn,
+	}, nil
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -14,6 +16,11 @@ type SimpletabResultData struct {
 	Filter *analyzer.Filter
 }
 
+// FilterComparisonResultData はフィルタ比較の結果のテンプレートデータ
+type FilterComparisonResultData struct {
+	Result *analyzer.FilterComparisonResult
+}
+
 // Simpletab は単純集計を実行する
 func (h *Handler) Simpletab(c echo.Context) error {
 	a, err := h.getAnalyzer()
@@ -22,53 +29,184 @@ func (h *Handler) Simpletab(c echo.Context) error {
 	}
 	defer a.Close()
 
+	config, filter, err := parseSimpletabRequest(c, a)
+	if err != nil {
+		return c.String(requestErrorStatus(err), err.Error())
+	}
+
+	// 比較するフィルタを指定した場合は、フィルタごとの列を並べた表（filter は各列に共通して適用）
+	compareFilters, err := requestCompareFilters(c, a)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if compareFilters != nil {
+		result, err := a.CompareFilters(config.XColumn, config.SplitX, compareFilters, filter, config.WeightColumn)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, "Failed to execute filter comparison: "+err.Error())
+		}
+		return c.Render(http.StatusOK, "filter_comparison_result.html", FilterComparisonResultData{Result: result})
+	}
+
+	// 集計実行
+	result, err := a.SimpletabWithWeight(config.XColumn, config.SplitX, filter, config.WeightColumn)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to execute simpletab: "+err.Error())
+	}
+
+	data := SimpletabResultData{
+		Result: result,
+		Filter: filter,
+	}
+
+	return c.Render(http.StatusOK, "simpletab_result.html", data)
+}
+
+// parseSimpletabRequest は単純集計のリクエストから集計設定とフィルタを取得する
+// 集計列は XColumn、複数回答の分割は SplitX に入れる
+func parseSimpletabRequest(c echo.Context, a *analyzer.Analyzer) (*analyzer.AnalysisConfig, *analyzer.Filter, error) {
 	// パラメータ取得
 	columnIndexStr := c.FormValue("column")
 	splitStr := c.FormValue("split")
-	filterName := c.FormValue("filter")
 
 	// 列インデックスをパース
 	columnIndex, err := strconv.Atoi(columnIndexStr)
 	if err != nil {
-		return c.String(http.StatusBadRequest, "Invalid column index")
+		return nil, nil, fmt.Errorf("invalid column index")
 	}
 
 	// 列を取得
 	columns, err := a.GetColumns()
 	if err != nil {
-		return c.String(http.StatusInternalServerError, "Failed to get columns")
+		return nil, nil, fmt.Errorf("%w: %w", errGetColumns, err)
 	}
 
 	if columnIndex < 1 || columnIndex > len(columns) {
-		return c.String(http.StatusBadRequest, "Column index out of range")
+		return nil, nil, fmt.Errorf("column index out of range")
+	}
+
+	weight, err := findWeightColumn(columns, c.FormValue("weight"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 集計設定
+	config := &analyzer.AnalysisConfig{
+		AnalysisType: "simple",
+		XColumn:      &columns[columnIndex-1],
+		SplitX:       splitStr == "true" || splitStr == "on",
+		WeightColumn: weight,
 	}
 
-	column := &columns[columnIndex-1]
+	filter, err := requestFilter(c, a)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	// 分割フラグ
-	split := splitStr == "true" || splitStr == "on"
+	return config, filter, nil
+}
 
-	// フィルタを取得
-	var filter *analyzer.Filter
-	if filterName != "" {
-		for i := range a.Filters {
-			if a.Filters[i].Name == filterName {
-				filter = &a.Filters[i]
-				break
-			}
+// findWeightColumn は名前からウェイト列を取得する（空の場合はnil）
+func findWeightColumn(columns analyzer.ColumnList, name string) (*analyzer.Column, error) {
+	if name == "" {
+		return nil, nil
+	}
+	weight := columns.FindByName(name)
+	if weight == nil {
+		return nil, fmt.Errorf("weight column not found: %s", name)
+	}
+	return weight, nil
+}
+
+// findFilter は名前からフィルタを取得する（見つからない場合はnil）
+func findFilter(a *analyzer.Analyzer, filterName string) *analyzer.Filter {
+	if filterName == "" {
+		return nil
+	}
+	for i := range a.Filters {
+		if a.Filters[i].Name == filterName {
+			return &a.Filters[i]
 		}
 	}
+	return nil
+}
 
-	// 集計実行
-	result, err := a.SimpletabWithFilter(column, split, filter)
-	if err != nil {
-		return c.String(http.StatusInternalServerError, "Failed to execute simpletab: "+err.Error())
+// namedFilter は名前で指定されたフィルタを返す（名前が空ならnil）
+// findFilter と異なり、見つからない名前はエラーにする
+func namedFilter(a *analyzer.Analyzer, filterName string) (*analyzer.Filter, error) {
+	filter := findFilter(a, filterName)
+	if filterName != "" && filter == nil {
+		return nil, fmt.Errorf("filter not found: %s", filterName)
 	}
+	return filter, nil
+}
 
-	data := SimpletabResultData{
-		Result: result,
-		Filter: filter,
+// adhocFilterName はその場で指定した条件だけのフィルタの名前
+const adhocFilterName = "絞り込み"
+
+// requestFilter はリクエストのフィルタを返す（フィルタがなければnil）
+// filter は保存済みのフィルタ名、adhoc_filter はその場で指定する条件（FilterCondition のJSON配列）
+// 両方ある場合は、保存済みのフィルタの条件にその場の条件を加えて絞り込む
+func requestFilter(c echo.Context, a *analyzer.Analyzer) (*analyzer.Filter, error) {
+	saved := findFilter(a, c.FormValue("filter"))
+
+	adhoc := c.FormValue("adhoc_filter")
+	if adhoc == "" {
+		return saved, nil
+	}
+	var conditions []analyzer.FilterCondition
+	if err := json.Unmarshal([]byte(adhoc), &conditions); err != nil {
+		return nil, fmt.Errorf("invalid adhoc_filter: %w", err)
+	}
+	if len(conditions) == 0 {
+		return saved, nil
+	}
+	for i := range conditions {
+		if err := conditions[i].Validate(); err != nil {
+			return nil, err
+		}
 	}
 
-	return c.Render(http.StatusOK, "simpletab_result.html", data)
+	filter := &analyzer.Filter{
+		Name:        adhocFilterName,
+		Description: analyzer.DescribeFilterConditions(conditions),
+		Conditions:  conditions,
+	}
+	if saved != nil {
+		filter.Name = saved.Name + " ＋ " + adhocFilterName
+		filter.Conditions = append(append([]analyzer.FilterCondition{}, saved.Conditions...), conditions...)
+	}
+	return filter, nil
+}
+
+// requestCompareFilters はリクエストの比較するフィルタを返す（指定がなければnil）
+// compare_filters はフィルタ名のJSON配列で、空文字はフィルタなし（全体）の列
+func requestCompareFilters(c echo.Context, a *analyzer.Analyzer) ([]*analyzer.Filter, error) {
+	value := c.FormValue("compare_filters")
+	if value == "" {
+		return nil, nil
+	}
+	var names []string
+	if err := json.Unmarshal([]byte(value), &names); err != nil {
+		return nil, fmt.Errorf("invalid compare_filters: %w", err)
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	if len(names) < 2 {
+		return nil, fmt.Errorf("select at least two filters to compare")
+	}
+
+	filters := make([]*analyzer.Filter, 0, len(names))
+	for _, name := range names {
+		if name == "" {
+			filters = append(filters, nil)
+			continue
+		}
+		filter := findFilter(a, name)
+		if filter == nil {
+			return nil, fmt.Errorf("filter not found: %s", name)
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
 }
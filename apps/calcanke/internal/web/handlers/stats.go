@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+)
+
+// NumericStatsResultData は記述統計量のテンプレートデータ
+type NumericStatsResultData struct {
+	Result *analyzer.NumericStatsResult
+	Filter *analyzer.Filter
+}
+
+// NumericStats は数値の記述統計量を集計する
+func (h *Handler) NumericStats(c echo.Context) error {
+	a, err := h.getAnalyzer()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to initialize analyzer")
+	}
+	defer a.Close()
+
+	config, err := parseNumericStatsRequest(c, a)
+	if err != nil {
+		return c.String(requestErrorStatus(err), err.Error())
+	}
+
+	result, err := a.NumericStats(*config)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to execute numeric stats: "+err.Error())
+	}
+
+	data := NumericStatsResultData{
+		Result: result,
+		Filter: config.Filter,
+	}
+
+	return c.Render(http.StatusOK, "numeric_stats_result.html", data)
+}
+
+// parseNumericStatsRequest は記述統計量のリクエストから集計設定を取得する
+// 単純集計（analysis_type=simple）は集計列の統計量、
+// クロス集計（analysis_type=cross）はY軸の列の統計量をX軸の値ごとに集計する
+func parseNumericStatsRequest(c echo.Context, a *analyzer.Analyzer) (*analyzer.NumericStatsConfig, error) {
+	if c.FormValue("analysis_type") == "cross" {
+		config, filter, err := parseCrosstabRequest(c, a)
+		if err != nil {
+			return nil, err
+		}
+		return &analyzer.NumericStatsConfig{
+			Column:       config.YColumn,
+			GroupColumn:  config.XColumn,
+			SplitGroup:   config.SplitX,
+			Filter:       filter,
+			WeightColumn: config.WeightColumn,
+		}, nil
+	}
+
+	config, filter, err := parseSimpletabRequest(c, a)
+	if err != nil {
+		return nil, err
+	}
+	return &analyzer.NumericStatsConfig{
+		Column:       config.XColumn,
+		Filter:       filter,
+		WeightColumn: config.WeightColumn,
+	}, nil
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/naozine/nz-mono-repo/apps/calcanke/internal/analyzer"
+)
+
+// WordFrequencyResultData は語の出現頻度のテンプレートデータ
+type WordFrequencyResultData struct {
+	Result *analyzer.WordFrequencyResult
+	Filter *analyzer.Filter
+}
+
+// KeywordContextResultData はキーワードを含む回答の一覧のテンプレートデータ
+type KeywordContextResultData struct {
+	Result *analyzer.KeywordContextResult
+	Filter *analyzer.Filter
+}
+
+// KeywordCrosstabResultData はキーワードを含む回答者の表のテンプレートデータ
+type KeywordCrosstabResultData struct {
+	Result *analyzer.KeywordCrosstabResult
+	Filter *analyzer.Filter
+}
+
+// Text は自由回答の列を分析する
+// text_analysis=frequency は語の出現頻度、context はキーワードを含む回答の一覧（KWIC）、
+// keyword_cross はキーワードを含む回答者の表（バナーの値ごと）を返す
+func (h *Handler) Text(c echo.Context) error {
+	a, err := h.getAnalyzer()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to initialize analyzer")
+	}
+	defer a.Close()
+
+	config, err := h.parseTextRequest(c, a)
+	if err != nil {
+		return c.String(requestErrorStatus(err), err.Error())
+	}
+
+	switch c.FormValue("text_analysis") {
+	case "", analyzer.TextAnalysisFrequency:
+		result, err := a.WordFrequency(*config)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, "Failed to execute word frequency: "+err.Error())
+		}
+		return c.Render(http.StatusOK, "word_frequency_result.html", WordFrequencyResultData{Result: result, Filter: config.Filter})
+
+	case analyzer.TextAnalysisContext:
+		result, err := a.KeywordContexts(*config)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, "Failed to execute keyword context: "+err.Error())
+		}
+		return c.Render(http.StatusOK, "keyword_context_result.html", KeywordContextResultData{Result: result, Filter: config.Filter})
+
+	case analyzer.TextAnalysisKeywordCross:
+		result, err := a.KeywordCrosstab(*config)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, "Failed to execute keyword crosstab: "+err.Error())
+		}
+		return c.Render(http.StatusOK, "keyword_crosstab_result.html", KeywordCrosstabResultData{Result: result, Filter: config.Filter})
+
+	default:
+		return c.String(http.StatusBadRequest, fmt.Sprintf("invalid text_analysis: %s", c.FormValue("text_analysis")))
+	}
+}
+
+// parseTextRequest は自由回答の分析のリクエストから設定を取得する
+// column は列番号、keywords・stopwords はカンマ・読点・改行区切り、banner_column はバナーの列番号（省略可）
+// 除く語は設定ファイル（stopwords.yaml）の語に stopwords の語を加えたもの
+func (h *Handler) parseTextRequest(c echo.Context, a *analyzer.Analyzer) (*analyzer.TextConfig, error) {
+	columns, err := a.GetColumns()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errGetColumns, err)
+	}
+
+	selected, err := columnsByIndex(columns, []string{c.FormValue("column")})
+	if err != nil {
+		return nil, err
+	}
+
+	weight, err := findWeightColumn(columns, c.FormValue("weight"))
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := requestFilter(c, a)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &analyzer.TextConfig{
+		Column:       selected[0],
+		Keywords:     analyzer.ParseKeywords(c.FormValue("keywords")),
+		Stopwords:    append(h.loadStopwords(), analyzer.ParseKeywords(c.FormValue("stopwords"))...),
+		Filter:       filter,
+		WeightColumn: weight,
+	}
+
+	if limit := c.FormValue("limit"); limit != "" {
+		if config.Limit, err = strconv.Atoi(limit); err != nil {
+			return nil, fmt.Errorf("invalid limit: %s", limit)
+		}
+	}
+
+	if banner := c.FormValue("banner_column"); banner != "" {
+		banners, err := columnsByIndex(columns, []string{banner})
+		if err != nil {
+			return nil, err
+		}
+		config.BannerColumn = banners[0]
+		splitBanner := c.FormValue("split_banner")
+		config.SplitBanner = splitBanner == "true" || splitBanner == "on"
+	}
+
+	return config, nil
+}
+
+// KeywordCodingCoverage はキーワードによるコーディングの派生列のカバー率を返す
+// index は派生列の番号、filter はフィルタ名、limit はコードなしの回答を返す数（省略時は30）
+func (h *Handler) KeywordCodingCoverage(c echo.Context) error {
+	a, err := h.getAnalyzer()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to initialize analyzer"})
+	}
+	defer a.Close()
+
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil || index < 0 || index >= len(a.DerivedColumns) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid index"})
+	}
+	dc := a.DerivedColumns[index]
+	if dc.CalculationType != "keyword_coding" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Derived column is not keyword_coding: " + dc.Name})
+	}
+
+	limit := 0
+	if s := c.QueryParam("limit"); s != "" {
+		if limit, err = strconv.Atoi(s); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid limit: " + s})
+		}
+	}
+
+	filter, err := namedFilter(a, c.QueryParam("filter"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	result, err := a.KeywordCodingCoverage(dc.Name, filter, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, result)
+}
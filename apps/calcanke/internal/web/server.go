@@ -1,6 +1,8 @@
 package web
 
 import (
+	"context"
+	"fmt"
 	"html/template"
 	"io"
 	"log"
@@ -21,8 +23,55 @@ func (t *TemplateRenderer) Render(w io.Writer, name string, data interface{}, c
 	return t.templates.ExecuteTemplate(w, name, data)
 }
 
+// templateFuncs はテンプレートで使う関数
+var templateFuncs = template.FuncMap{
+	// dict はキーと値の組から部分テンプレートに渡すマップを作成する
+	"dict": func(pairs ...interface{}) (map[string]interface{}, error) {
+		if len(pairs)%2 != 0 {
+			return nil, fmt.Errorf("dict requires key-value pairs")
+		}
+		m := make(map[string]interface{}, len(pairs)/2)
+		for i := 0; i < len(pairs); i += 2 {
+			key, ok := pairs[i].(string)
+			if !ok {
+				return nil, fmt.Errorf("dict keys must be strings")
+			}
+			m[key] = pairs[i+1]
+		}
+		return m, nil
+	},
+}
+
+// Server はWebサーバー
+// 停止時はリクエストの処理に加えて、バックグラウンドのインポートの終了も待つ
+type Server struct {
+	*echo.Echo
+	projects *handlers.ProjectHandler
+}
+
+// Shutdown はリクエストの受け付けを止め、処理中のリクエストとインポートが終わるまで待つ
+// ctx の期限までに終わらなかったインポートは、次回の起動時にエラーとして記録される
+func (s *Server) Shutdown(ctx context.Context) error {
+	if err := s.Echo.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.projects.WaitJobs()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("import jobs did not finish: %w", ctx.Err())
+	}
+}
+
 // NewServer はWebサーバーを作成する
-func NewServer(dbPath, table, projectsDir string) *echo.Echo {
+func NewServer(dbPath, table, projectsDir string) *Server {
 	e := echo.New()
 
 	// ミドルウェアの設定
@@ -30,7 +79,7 @@ func NewServer(dbPath, table, projectsDir string) *echo.Echo {
 	e.Use(middleware.Recover())
 
 	// テンプレートの読み込み
-	templates := template.Must(template.ParseGlob("web/templates/*.html"))
+	templates := template.Must(template.New("").Funcs(templateFuncs).ParseGlob("web/templates/*.html"))
 	templates = template.Must(templates.ParseGlob("web/templates/partials/*.html"))
 	// componentsは将来の拡張用（現在は未使用）
 	// templates = template.Must(templates.ParseGlob("web/templates/components/*.html"))
@@ -45,6 +94,11 @@ func NewServer(dbPath, table, projectsDir string) *echo.Echo {
 		log.Fatalf("Failed to initialize project repository: %v", err)
 	}
 
+	// 前回の起動中に終わらなかったインポートはエラーにする
+	if err := projectRepo.FailInterruptedJobs(); err != nil {
+		log.Fatalf("Failed to recover import jobs: %v", err)
+	}
+
 	// ハンドラーの初期化
 	h := handlers.NewHandler(dbPath, table)
 	projectHandler := handlers.NewProjectHandler(projectRepo, projectsDir)
@@ -55,24 +109,44 @@ func NewServer(dbPath, table, projectsDir string) *echo.Echo {
 	e.POST("/projects", projectHandler.Create)
 	e.GET("/projects/:id/upload", projectHandler.ShowUploadForm)
 	e.POST("/api/projects/:id/upload", projectHandler.Upload)
+	e.POST("/api/projects/:id/sheets", projectHandler.UploadSheets)
+	e.POST("/api/projects/:id/import", projectHandler.ImportSheets)
+	e.GET("/api/projects/:id/import-status", projectHandler.GetImportStatus)
+	e.GET("/api/projects/:id/import-events", projectHandler.StreamImportEvents)
 	e.GET("/api/projects", projectHandler.GetProjectListAPI)
 	e.GET("/api/projects/:id", projectHandler.GetProjectAPI)
 	e.DELETE("/api/projects/:id", projectHandler.Delete)
 
 	// ルーティング - プロジェクトごとの集計機能
 	e.GET("/projects/:id", projectHandler.ShowAnalysis)
+	e.GET("/projects/:id/data", projectHandler.ShowData)
 	e.GET("/api/projects/:id/columns", projectHandler.GetProjectColumns)
 	e.GET("/api/projects/:id/columns-json", projectHandler.GetProjectColumnsJSON)
 	e.GET("/api/projects/:id/filters", projectHandler.GetProjectFilters)
 	e.POST("/api/projects/:id/simpletab", projectHandler.ProjectSimpletab)
 	e.POST("/api/projects/:id/crosstab", projectHandler.ProjectCrosstab)
+	e.POST("/api/projects/:id/crosstab/layered", projectHandler.ProjectLayeredCrosstab)
 	e.POST("/api/projects/:id/export", projectHandler.ProjectExport)
+	e.POST("/api/projects/:id/banner-tables", projectHandler.ProjectBannerTables)
+	e.POST("/api/projects/:id/stats", projectHandler.ProjectNumericStats)
+	e.POST("/api/projects/:id/scale", projectHandler.ProjectScaleSummary)
+	e.POST("/api/projects/:id/grid", projectHandler.ProjectGrid)
+	e.POST("/api/projects/:id/multi-answer", projectHandler.ProjectMultiAnswer)
+	e.POST("/api/projects/:id/text", projectHandler.ProjectText)
+	e.POST("/api/projects/:id/cell-records", projectHandler.ProjectCellRecords)
+	e.GET("/api/projects/:id/records", projectHandler.ProjectRecords)
+
+	// ルーティング - 集計対象テーブル（複数シート・結合）
+	e.GET("/api/projects/:id/tables", projectHandler.GetTables)
+	e.PUT("/api/projects/:id/data-source", projectHandler.UpdateDataSource)
 
 	// ルーティング - 派生列管理
 	e.GET("/api/projects/:id/derived-columns", projectHandler.GetDerivedColumns)
 	e.POST("/api/projects/:id/derived-columns", projectHandler.AddDerivedColumn)
 	e.PUT("/api/projects/:id/derived-columns/:index", projectHandler.UpdateDerivedColumn)
 	e.DELETE("/api/projects/:id/derived-columns/:index", projectHandler.DeleteDerivedColumn)
+	e.GET("/api/projects/:id/derived-columns/:index/coverage", projectHandler.GetKeywordCodingCoverage)
+	e.GET("/api/projects/:id/derived-columns/:index/date-report", projectHandler.GetDateParseReport)
 
 	// ルーティング - 派生列テンプレート
 	e.GET("/api/projects/:id/derived-columns/templates", projectHandler.GetDerivedColumnTemplates)
@@ -87,6 +161,10 @@ func NewServer(dbPath, table, projectsDir string) *echo.Echo {
 	// ルーティング - 列順序管理
 	e.GET("/api/projects/:id/column-orders", projectHandler.GetColumnOrders)
 	e.PUT("/api/projects/:id/column-orders", projectHandler.UpdateColumnOrders)
+	e.GET("/api/projects/:id/question-groups", projectHandler.GetQuestionGroups)
+	e.PUT("/api/projects/:id/question-groups", projectHandler.UpdateQuestionGroups)
+	e.GET("/api/projects/:id/stopwords", projectHandler.GetStopwords)
+	e.PUT("/api/projects/:id/stopwords", projectHandler.UpdateStopwords)
 
 	// ルーティング - 集計機能（既存、後でプロジェクトIDベースに変更予定）
 	e.GET("/analysis", h.Index) // 一時的に /analysis に移動
@@ -94,7 +172,16 @@ func NewServer(dbPath, table, projectsDir string) *echo.Echo {
 	e.GET("/api/filters", h.GetFilters)
 	e.POST("/api/simpletab", h.Simpletab)
 	e.POST("/api/crosstab", h.Crosstab)
+	e.POST("/api/crosstab/layered", h.LayeredCrosstab)
 	e.POST("/api/export", h.Export)
-
-	return e
+	e.POST("/api/banner-tables", h.BannerTables)
+	e.POST("/api/stats", h.NumericStats)
+	e.POST("/api/scale", h.ScaleSummary)
+	e.POST("/api/grid", h.Grid)
+	e.POST("/api/multi-answer", h.MultiAnswer)
+	e.POST("/api/text", h.Text)
+	e.POST("/api/cell-records", h.CellRecords)
+	e.GET("/api/records", h.Records)
+
+	return &Server{Echo: e, projects: projectHandler}
 }